@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/MahendraDani/gitloom.git/internal/index"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var addCmd = &cobra.Command{
+	Use:   "add <paths...>",
+	Short: "Add file contents to the index",
+	Long: `gitloom add stages the current contents of the given files in .gitloom/index.
+Directories are added recursively, and paths that were deleted from the
+working tree are removed from the index.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+
+		if err := index.Add(r, args); err != nil {
+			return fmt.Errorf("failed to add files: %v", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(addCmd)
+}
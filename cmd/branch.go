@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/branch"
+	"github.com/MahendraDani/gitloom.git/internal/history"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	branchDelete        bool
+	branchForceDelete   bool
+	branchMove          bool
+	branchForceMove     bool
+	branchForce         bool
+	branchVerbose       int
+	branchAll           bool
+	branchRemotes       bool
+	branchShowCurrent   bool
+	branchSetUpstream   string
+	branchUnsetUpstream bool
+)
+
+var branchCmd = &cobra.Command{
+	Use:   "branch [<name> [<start>]]",
+	Short: "List, create, rename or delete branches",
+	Long: `gitloom branch lists local branches, marking the checked out one with "*".
+-r lists remote-tracking branches and -a both. -v adds each branch's commit and
+how far it is ahead of or behind its upstream; -vv also names the upstream.
+
+Usage:
+  gitloom branch topic                   # create topic at HEAD
+  gitloom branch topic v1.0              # create topic at another revision
+  gitloom branch -f topic main           # move an existing branch
+  gitloom branch -d topic                # delete a merged branch (-D forces)
+  gitloom branch -m old new              # rename (-M replaces an existing new)
+  gitloom branch -u origin/main [topic]  # track an upstream branch
+  gitloom branch --unset-upstream [topic]
+
+Starting a branch from a remote-tracking branch makes it track that branch.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+		out := cmd.OutOrStdout()
+
+		switch {
+		case branchDelete || branchForceDelete:
+			if len(args) == 0 {
+				return errors.New("branch name required")
+			}
+			for _, name := range args {
+				hash, err := branch.Delete(r, name, branchForceDelete || branchForce)
+				if errors.Is(err, branch.ErrNotMerged) {
+					return fmt.Errorf("the branch '%s' is not fully merged; run 'gitloom branch -D %s' to delete it anyway", name, name)
+				}
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "Deleted branch %s (was %s).\n", name, hash[:7])
+			}
+			return nil
+
+		case branchMove || branchForceMove:
+			var oldName, newName string
+			switch len(args) {
+			case 1:
+				if oldName, err = currentBranchName(r); err != nil {
+					return err
+				}
+				newName = args[0]
+			case 2:
+				oldName, newName = args[0], args[1]
+			default:
+				return errors.New("branch rename takes one or two branch names")
+			}
+			return branch.Rename(r, oldName, newName, branchForceMove || branchForce)
+
+		case cmd.Flags().Changed("set-upstream-to"):
+			name, err := branchArgOrCurrent(r, args)
+			if err != nil {
+				return err
+			}
+			if err := branch.SetUpstream(r, name, branchSetUpstream); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "branch '%s' set up to track '%s'.\n", name, branchSetUpstream)
+			return nil
+
+		case branchUnsetUpstream:
+			name, err := branchArgOrCurrent(r, args)
+			if err != nil {
+				return err
+			}
+			return branch.UnsetUpstream(r, name)
+
+		case branchShowCurrent:
+			name, err := branch.Current(r)
+			if err != nil {
+				return err
+			}
+			if name != "" {
+				fmt.Fprintln(out, name)
+			}
+			return nil
+
+		case len(args) > 0:
+			if len(args) > 2 {
+				return errors.New("too many arguments to create a branch")
+			}
+			start := refs.Head
+			if len(args) == 2 {
+				start = args[1]
+			}
+			return branch.Create(r, args[0], start, branchForce)
+		}
+
+		return listBranches(r, out)
+	},
+}
+
+// currentBranchName returns the checked out branch or fails when HEAD is detached.
+func currentBranchName(r *repo.Repo) (string, error) {
+	name, err := branch.Current(r)
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		return "", errors.New("HEAD is detached: name a branch")
+	}
+	return name, nil
+}
+
+func branchArgOrCurrent(r *repo.Repo, args []string) (string, error) {
+	switch len(args) {
+	case 0:
+		return currentBranchName(r)
+	case 1:
+		return args[0], nil
+	}
+	return "", errors.New("too many branch names")
+}
+
+// branchLine is one row of the branch listing.
+type branchLine struct {
+	current bool
+	name    string
+	hash    string
+	info    string // tracking summary shown with -v
+}
+
+func listBranches(r *repo.Repo, w io.Writer) error {
+	var lines []branchLine
+
+	if !branchRemotes || branchAll {
+		current, err := branch.Current(r)
+		if err != nil {
+			return err
+		}
+		if current == "" {
+			if hash, err := refs.Resolve(r, refs.Head); err == nil {
+				lines = append(lines, branchLine{current: true, name: fmt.Sprintf("(HEAD detached at %s)", hash[:7]), hash: hash})
+			}
+		}
+
+		branches, err := branch.List(r)
+		if err != nil {
+			return err
+		}
+		for _, b := range branches {
+			lines = append(lines, branchLine{current: b.Current, name: b.Name, hash: b.Hash, info: trackingInfo(b)})
+		}
+	}
+
+	if branchRemotes || branchAll {
+		remotes, err := branch.ListRemote(r)
+		if err != nil {
+			return err
+		}
+		for _, ref := range remotes {
+			name := branch.ShortName(ref.Name)
+			if branchAll {
+				name = strings.TrimPrefix(ref.Name, "refs/")
+			}
+			lines = append(lines, branchLine{name: name, hash: ref.Hash})
+		}
+	}
+
+	width := 0
+	for _, l := range lines {
+		width = max(width, len(l.name))
+	}
+
+	for _, l := range lines {
+		marker := "  "
+		if l.current {
+			marker = "* "
+		}
+		if branchVerbose == 0 {
+			fmt.Fprintf(w, "%s%s\n", marker, l.name)
+			continue
+		}
+
+		subject := ""
+		if c, err := history.ReadCommit(r, l.hash); err == nil {
+			subject = history.Subject(c.Message)
+		}
+		info := l.info
+		if info != "" {
+			info += " "
+		}
+		fmt.Fprintf(w, "%s%-*s %s %s%s\n", marker, width, l.name, l.hash[:7], info, subject)
+	}
+	return nil
+}
+
+// trackingInfo formats the upstream state of b for -v and -vv, e.g.
+// "[origin/main: ahead 1, behind 2]".
+func trackingInfo(b branch.Branch) string {
+	if b.Upstream == "" {
+		return ""
+	}
+
+	var state []string
+	switch {
+	case b.Gone:
+		state = append(state, "gone")
+	default:
+		if b.Ahead > 0 {
+			state = append(state, fmt.Sprintf("ahead %d", b.Ahead))
+		}
+		if b.Behind > 0 {
+			state = append(state, fmt.Sprintf("behind %d", b.Behind))
+		}
+	}
+
+	summary := strings.Join(state, ", ")
+	if branchVerbose < 2 {
+		if summary == "" {
+			return ""
+		}
+		return "[" + summary + "]"
+	}
+	if summary == "" {
+		return "[" + branch.ShortName(b.Upstream) + "]"
+	}
+	return "[" + branch.ShortName(b.Upstream) + ": " + summary + "]"
+}
+
+func init() {
+	rootCmd.AddCommand(branchCmd)
+	f := branchCmd.Flags()
+	f.BoolVarP(&branchDelete, "delete", "d", false, "Delete fully merged branches")
+	f.BoolVarP(&branchForceDelete, "force-delete", "D", false, "Delete branches even if not merged")
+	f.BoolVarP(&branchMove, "move", "m", false, "Rename a branch")
+	f.BoolVarP(&branchForceMove, "force-move", "M", false, "Rename a branch even if the new name exists")
+	f.BoolVarP(&branchForce, "force", "f", false, "Move an existing branch, or delete or rename regardless")
+	f.CountVarP(&branchVerbose, "verbose", "v", "Show hash, subject and upstream state (twice for the upstream name)")
+	f.BoolVarP(&branchAll, "all", "a", false, "List local and remote-tracking branches")
+	f.BoolVarP(&branchRemotes, "remotes", "r", false, "List remote-tracking branches")
+	f.BoolVar(&branchShowCurrent, "show-current", false, "Print the name of the current branch")
+	f.StringVarP(&branchSetUpstream, "set-upstream-to", "u", "", "Track the given upstream branch")
+	f.BoolVar(&branchUnsetUpstream, "unset-upstream", false, "Stop tracking an upstream branch")
+}
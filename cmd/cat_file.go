@@ -3,9 +3,12 @@ package cmd
 import (
 	"fmt"
 	"log"
+	"os"
 
+	"github.com/MahendraDani/gitloom.git/internal"
 	"github.com/MahendraDani/gitloom.git/internal/object"
 	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/revision"
 	"github.com/spf13/cobra"
 )
 
@@ -24,16 +27,22 @@ in the .gitloom/objects directory.
 Usage:
   gitloom cat-file -p <hash>   # print object contents
   gitloom cat-file -s <hash>   # print object size
-  gitloom cat-file -t <hash>   # print object type`,
+  gitloom cat-file -t <hash>   # print object type
+
+Any revision accepted by rev-parse can be used in place of <hash>,
+for example HEAD, a1b2c3 or main:README.md.`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		hash := args[0]
-
 		r, err := repo.FindRepo(".")
 		if err != nil {
 			log.Fatalf("Error: %v", err)
 		}
 
+		hash, err := revision.Resolve(r, args[0])
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+
 		flag := ""
 		switch {
 		case printFlag:
@@ -46,6 +55,20 @@ Usage:
 			flag = "p" // default behavior
 		}
 
+		// Stream blobs straight to stdout so large files are never held in memory
+		if flag == "p" {
+			objType, _, err := r.ObjectInfo(hash)
+			if err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+			if objType == internal.BlobType {
+				if err := object.WriteBlob(r, hash, os.Stdout); err != nil {
+					log.Fatalf("Error: %v", err)
+				}
+				return
+			}
+		}
+
 		output, err := object.CatFile(r, hash, flag)
 		if err != nil {
 			log.Fatalf("Error: %v", err)
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MahendraDani/gitloom.git/internal/ignore"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var checkIgnoreVerbose bool
+
+var checkIgnoreCmd = &cobra.Command{
+	Use:   "check-ignore [-v] <paths...>",
+	Short: "Debug .gitloomignore and exclude rules",
+	Long: `gitloom check-ignore prints each given path that is ignored. With -v it also
+prints the rule that decided the outcome as <source>:<line>:<pattern>, including
+negated rules that re-include a path.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+
+		m, err := ignore.New(r)
+		if err != nil {
+			return fmt.Errorf("failed to load ignore rules: %v", err)
+		}
+
+		root := r.WorkTree()
+		matched := false
+		for _, p := range args {
+			abs, err := filepath.Abs(p)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(root, abs)
+			if err != nil {
+				return err
+			}
+
+			isDir := false
+			if fi, err := os.Stat(abs); err == nil {
+				isDir = fi.IsDir()
+			}
+
+			ignored, rule, err := m.Ignored(filepath.ToSlash(rel), isDir)
+			if err != nil {
+				return err
+			}
+
+			if checkIgnoreVerbose && rule != nil {
+				fmt.Printf("%s:%d:%s\t%s\n", rule.Source, rule.Line, rule.Pattern, p)
+				matched = true
+			} else if ignored {
+				fmt.Println(p)
+				matched = true
+			}
+		}
+
+		if !matched {
+			return fmt.Errorf("no path is ignored")
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkIgnoreCmd)
+	checkIgnoreCmd.Flags().BoolVarP(&checkIgnoreVerbose, "verbose", "v", false, "Show the matching rule for each path")
+}
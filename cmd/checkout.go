@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/MahendraDani/gitloom.git/internal/checkout"
+	"github.com/MahendraDani/gitloom.git/internal/history"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/revision"
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkoutForce     bool
+	checkoutDetach    bool
+	checkoutNewBranch string
+)
+
+var checkoutCmd = &cobra.Command{
+	Use:   "checkout [-b <new>] <branch|commit> | [<commit>] -- <paths...>",
+	Short: "Switch branches or restore working tree files",
+	Long: `gitloom checkout <branch> updates the index and working tree to the branch's
+commit and makes it the current branch. Any other revision detaches HEAD at
+that commit. Files tracked only by the old commit are deleted. Uncommitted
+changes to files the checkout has to touch, and untracked files standing
+where it has to write, make it refuse; changes to other files carry over.
+
+Usage:
+  gitloom checkout topic            # switch to a branch
+  gitloom checkout -b topic [start] # create a branch and switch to it
+  gitloom checkout v1.0             # detach HEAD at a commit
+  gitloom checkout -- file.txt      # discard unstaged changes to a file
+  gitloom checkout main -- dir      # restore and stage dir as of main
+
+-f throws away local changes instead of refusing.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			return restorePaths(r, args[:dash], args[dash:])
+		}
+
+		rev := ""
+		switch {
+		case len(args) == 1:
+			rev = args[0]
+		case len(args) > 1:
+			return errors.New("too many revisions: separate paths with --")
+		case checkoutNewBranch == "":
+			return errors.New("branch or commit required")
+		}
+
+		return switchTo(r, cmd.OutOrStdout(), rev, checkout.Options{
+			Force:     checkoutForce,
+			Detach:    checkoutDetach,
+			NewBranch: checkoutNewBranch,
+		})
+	},
+}
+
+// restorePaths restores paths from the index, or from the commit named
+// in revs.
+func restorePaths(r *repo.Repo, revs, paths []string) error {
+	if len(paths) == 0 {
+		return errors.New("no paths given after --")
+	}
+	treeHash := ""
+	switch len(revs) {
+	case 0:
+	case 1:
+		hash, err := revision.ResolveCommit(r, revs[0])
+		if err != nil {
+			return fmt.Errorf("not a valid commit: '%s'", revs[0])
+		}
+		c, err := history.ReadCommit(r, hash)
+		if err != nil {
+			return err
+		}
+		treeHash = c.Tree
+	default:
+		return errors.New("only one revision may be given before --")
+	}
+	return checkout.RestorePaths(r, treeHash, paths)
+}
+
+// switchTo checks out rev and reports the result as git does.
+func switchTo(r *repo.Repo, out io.Writer, rev string, opts checkout.Options) error {
+	res, err := checkout.Switch(r, rev, opts)
+	if err != nil {
+		return err
+	}
+
+	if res.PreviousDetached != "" && res.PreviousDetached != res.Hash {
+		fmt.Fprintf(out, "Previous HEAD position was %s\n", describeCommit(r, res.PreviousDetached))
+	}
+	switch {
+	case res.Branch == "":
+		fmt.Fprintf(out, "HEAD is now at %s\n", describeCommit(r, res.Hash))
+	case res.Created:
+		fmt.Fprintf(out, "Switched to a new branch '%s'\n", res.Branch)
+	case res.Already:
+		fmt.Fprintf(out, "Already on '%s'\n", res.Branch)
+	default:
+		fmt.Fprintf(out, "Switched to branch '%s'\n", res.Branch)
+	}
+	return nil
+}
+
+// describeCommit formats a commit as its abbreviated hash and subject.
+func describeCommit(r *repo.Repo, hash string) string {
+	c, err := history.ReadCommit(r, hash)
+	if err != nil {
+		return hash[:7]
+	}
+	return hash[:7] + " " + history.Subject(c.Message)
+}
+
+func init() {
+	rootCmd.AddCommand(checkoutCmd)
+	checkoutCmd.Flags().BoolVarP(&checkoutForce, "force", "f", false, "Discard local changes")
+	checkoutCmd.Flags().BoolVar(&checkoutDetach, "detach", false, "Detach HEAD even when checking out a branch")
+	checkoutCmd.Flags().StringVarP(&checkoutNewBranch, "branch", "b", "", "Create a new branch and check it out")
+}
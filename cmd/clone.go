@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/remote"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var cloneBare bool
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone [--bare] <repository> [<directory>]",
+	Short: "Copy a repository into a new directory",
+	Long: `gitloom clone creates a repository in <directory>, configures <repository>, a
+path or file:// URL, as its origin remote and fetches it. The branch the
+remote has checked out is created locally, set to track origin's and checked
+out.
+
+Without <directory>, the last part of the repository path is used, minus any
+.git suffix. --bare creates a repository without a working tree whose
+branches mirror the remote's; it can serve as a shared remote that others
+push to, e.g. on an NFS mount.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url := args[0]
+		dir := cloneDir(url, cloneBare)
+		if len(args) == 2 {
+			dir = args[1]
+		}
+
+		out := cmd.OutOrStdout()
+		if cloneBare {
+			fmt.Fprintf(out, "Cloning into bare repository '%s'...\n", dir)
+		} else {
+			fmt.Fprintf(out, "Cloning into '%s'...\n", dir)
+		}
+		res, err := remote.Clone(url, dir, remote.CloneOptions{Bare: cloneBare})
+		if err != nil {
+			return err
+		}
+		if res.Empty {
+			fmt.Fprintln(out, "warning: You appear to have cloned an empty repository.")
+			return nil
+		}
+		fmt.Fprintln(out, "done.")
+		return nil
+	},
+}
+
+// cloneDir is the directory a clone of url goes to by default: the last
+// part of its path without a .git suffix, plus .git for a bare clone.
+func cloneDir(url string, bare bool) string {
+	path := strings.TrimRight(strings.TrimPrefix(url, "file://"), "/")
+	for _, suffix := range []string{"/" + repo.GitDirName, "/" + repo.RepoDirName} {
+		path = strings.TrimSuffix(path, suffix)
+	}
+	name := strings.TrimSuffix(filepath.Base(path), repo.GitDirName)
+	if bare {
+		name += repo.GitDirName
+	}
+	return name
+}
+
+func init() {
+	rootCmd.AddCommand(cloneCmd)
+	cloneCmd.Flags().BoolVar(&cloneBare, "bare", false, "Create a bare repository")
+}
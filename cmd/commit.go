@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/branch"
+	"github.com/MahendraDani/gitloom.git/internal/commit"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var commitMessage string
+
+var commitCmd = &cobra.Command{
+	Use:   "commit -m <message>",
+	Short: "Record the staged changes as a new commit on the current branch",
+	Long: `gitloom commit writes a tree from the index, creates a commit whose parent
+is the commit HEAD currently points to, and advances the current branch to it.
+
+After a merge stopped by conflicts, committing the resolved index concludes
+the merge with a commit that also has the merged commit as its parent. The
+message prepared by the merge is used when -m is not given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+
+		message := commitMessage
+		if message == "" {
+			if message, err = commit.MergeMessage(r); err != nil {
+				return err
+			}
+			if message == "" {
+				return errors.New("no commit message given; use -m <message>")
+			}
+		}
+
+		res, err := commit.Commit(r, message)
+		if err != nil {
+			return fmt.Errorf("failed to commit: %v", err)
+		}
+
+		root := ""
+		if res.Root {
+			root = " (root-commit)"
+		}
+		subject, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+		fmt.Printf("[%s%s %s] %s\n", branch.ShortName(res.Branch), root, res.Hash[:7], subject)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(commitCmd)
+	commitCmd.Flags().StringVarP(&commitMessage, "message", "m", "", "Commit message")
+}
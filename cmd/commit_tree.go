@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/commit"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/revision"
+	"github.com/spf13/cobra"
+)
+
+var (
+	commitTreeParents []string
+	commitTreeMessage string
+)
+
+var commitTreeCmd = &cobra.Command{
+	Use:   "commit-tree <tree> [-p <parent>]... -m <message>",
+	Short: "Create a new commit object from a tree",
+	Long: `gitloom commit-tree creates a commit object pointing at the given tree
+and prints its hash. Each -p adds a parent commit.
+
+The author and committer are taken from GITLOOM_AUTHOR_NAME, GITLOOM_AUTHOR_EMAIL,
+GITLOOM_COMMITTER_NAME and GITLOOM_COMMITTER_EMAIL.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+
+		treeHash, err := revision.Resolve(r, args[0])
+		if err != nil {
+			return err
+		}
+		treeHash, err = revision.Peel(r, treeHash, internal.TreeType)
+		if err != nil {
+			return err
+		}
+
+		var parents []string
+		for _, p := range commitTreeParents {
+			parent, err := revision.ResolveCommit(r, p)
+			if err != nil {
+				return err
+			}
+			parents = append(parents, parent)
+		}
+
+		hash, err := commit.CommitTree(r, treeHash, parents, commitTreeMessage)
+		if err != nil {
+			return fmt.Errorf("failed to commit tree: %v", err)
+		}
+
+		fmt.Println(hash)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(commitTreeCmd)
+	commitTreeCmd.Flags().StringArrayVarP(&commitTreeParents, "parent", "p", nil, "Parent commit hash (may be repeated)")
+	commitTreeCmd.Flags().StringVarP(&commitTreeMessage, "message", "m", "", "Commit message")
+	commitTreeCmd.MarkFlagRequired("message")
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/MahendraDani/gitloom.git/internal/config"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configGlobal bool
+	configLocal  bool
+	configList   bool
+	configType   string
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config (get <key> | set <key> <value> | unset <key> | --list)",
+	Short: "Get and set repository or global options",
+	Long: `gitloom config reads and writes INI-style configuration files. Values come from
+three layers, each overriding the one before:
+
+  ~/.gitloomconfig      user-wide (--global), or $GITLOOM_CONFIG_GLOBAL
+  .gitloom/config       this repository (--local)
+  -c key=value          the current command only
+
+Keys are written section.name or section.subsection.name, e.g. user.email or
+remote.origin.url. get and --list read the merged layers unless --global or
+--local picks one; set and unset write the repository file unless --global is
+given. --type bool or --type int checks and normalises the value read.
+
+Examples:
+  gitloom config set user.name "Jane Doe"
+  gitloom config --global set init.defaultBranch trunk
+  gitloom config get core.compression --type int
+  gitloom config --list`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if configList {
+			return cobra.NoArgs(cmd, args)
+		}
+		if len(args) == 0 {
+			return errors.New("expected get, set, unset or --list")
+		}
+		want := map[string]int{"get": 2, "set": 3, "unset": 2}
+		n, ok := want[args[0]]
+		if !ok {
+			return fmt.Errorf("unknown action %q: expected get, set or unset", args[0])
+		}
+		if len(args) != n {
+			return fmt.Errorf("%s takes %d argument(s)", args[0], n-1)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if configGlobal && configLocal {
+			return errors.New("--global and --local are mutually exclusive")
+		}
+
+		if configList {
+			cfg, err := readConfig()
+			if err != nil {
+				return err
+			}
+			for _, e := range cfg.Entries() {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s=%s\n", e.Key, e.Value)
+			}
+			return nil
+		}
+
+		switch args[0] {
+		case "get":
+			cfg, err := readConfig()
+			if err != nil {
+				return err
+			}
+			value, ok := cfg.Get(args[1])
+			if !ok {
+				return fmt.Errorf("key not found: %s", args[1])
+			}
+			value, err = normaliseConfigValue(value)
+			if err != nil {
+				return fmt.Errorf("bad value for %s: %v", args[1], err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), value)
+
+		case "set":
+			f, err := configFileToWrite()
+			if err != nil {
+				return err
+			}
+			value, err := normaliseConfigValue(args[2])
+			if err != nil {
+				return fmt.Errorf("bad value for %s: %v", args[1], err)
+			}
+			if err := f.Set(args[1], value); err != nil {
+				return err
+			}
+			return f.Save()
+
+		case "unset":
+			f, err := configFileToWrite()
+			if err != nil {
+				return err
+			}
+			removed, err := f.Unset(args[1])
+			if err != nil {
+				return err
+			}
+			if !removed {
+				return fmt.Errorf("key not found: %s", args[1])
+			}
+			return f.Save()
+		}
+		return nil
+	},
+}
+
+// readConfig returns the layers selected by --global and --local.
+func readConfig() (*config.Config, error) {
+	if configGlobal {
+		global, err := config.LoadGlobal()
+		if err != nil {
+			return nil, err
+		}
+		return config.New(global), nil
+	}
+
+	r, err := repo.FindRepo(".")
+	if err != nil {
+		if configLocal {
+			return nil, fmt.Errorf("not a gitloom repository: %v", err)
+		}
+		// Outside a repository only the user-wide values apply
+		global, err := config.LoadGlobal()
+		if err != nil {
+			return nil, err
+		}
+		return config.New(global, config.CommandLine()), nil
+	}
+	if configLocal {
+		local, err := config.Load(r.ConfigPath())
+		if err != nil {
+			return nil, err
+		}
+		return config.New(local), nil
+	}
+	return r.Config()
+}
+
+// configFileToWrite loads the file that set and unset change.
+func configFileToWrite() (*config.File, error) {
+	if configGlobal {
+		return config.LoadGlobal()
+	}
+	r, err := repo.FindRepo(".")
+	if err != nil {
+		return nil, fmt.Errorf("not a gitloom repository: %v", err)
+	}
+	return config.Load(r.ConfigPath())
+}
+
+// normaliseConfigValue checks value against --type.
+func normaliseConfigValue(value string) (string, error) {
+	switch configType {
+	case "":
+		return value, nil
+	case "bool":
+		b, err := config.ParseBool(value)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatBool(b), nil
+	case "int":
+		n, err := config.ParseInt(value)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(n, 10), nil
+	}
+	return "", fmt.Errorf("unknown type %q: expected bool or int", configType)
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.Flags().BoolVar(&configGlobal, "global", false, "Use the user-wide configuration file")
+	configCmd.Flags().BoolVar(&configLocal, "local", false, "Use the repository configuration file")
+	configCmd.Flags().BoolVarP(&configList, "list", "l", false, "List all variables with their values")
+	configCmd.Flags().StringVar(&configType, "type", "", "Check the value is a bool or an int")
+}
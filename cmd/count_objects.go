@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/MahendraDani/gitloom.git/internal/gc"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var countObjectsVerbose bool
+
+var countObjectsCmd = &cobra.Command{
+	Use:   "count-objects [-v]",
+	Short: "Report object counts and disk usage",
+	Long: `gitloom count-objects prints the number of loose objects and the disk space
+they use. With -v it also reports packed objects, loose objects that are
+already packed and could be pruned, and leftover garbage files. Sizes are in
+KiB.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+
+		c, err := gc.CountObjects(r)
+		if err != nil {
+			return fmt.Errorf("failed to count objects: %v", err)
+		}
+
+		if !countObjectsVerbose {
+			fmt.Printf("%d objects, %d kilobytes\n", c.Loose, kib(c.LooseSize))
+			return nil
+		}
+		fmt.Printf("count: %d\n", c.Loose)
+		fmt.Printf("size: %d\n", kib(c.LooseSize))
+		fmt.Printf("in-pack: %d\n", c.InPack)
+		fmt.Printf("packs: %d\n", c.Packs)
+		fmt.Printf("size-pack: %d\n", kib(c.PackSize))
+		fmt.Printf("prune-packable: %d\n", c.PrunePackable)
+		fmt.Printf("garbage: %d\n", c.Garbage)
+		fmt.Printf("size-garbage: %d\n", kib(c.GarbageSize))
+		return nil
+	},
+}
+
+func kib(n int64) int64 {
+	return (n + 1023) / 1024
+}
+
+func init() {
+	rootCmd.AddCommand(countObjectsCmd)
+	countObjectsCmd.Flags().BoolVarP(&countObjectsVerbose, "verbose", "v", false, "Report packs and garbage as well")
+}
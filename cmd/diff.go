@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/diff"
+	"github.com/MahendraDani/gitloom.git/internal/history"
+	"github.com/MahendraDani/gitloom.git/internal/index"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffCached     bool
+	diffStat       bool
+	diffNameStatus bool
+	diffContext    int
+	diffAlgorithm  string
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [--cached] [<rev> [<rev>]]",
+	Short: "Show changes between the working tree, the index and commits",
+	Long: `gitloom diff shows what changed as a unified diff:
+
+  gitloom diff                 # unstaged changes: index to working tree
+  gitloom diff --cached        # staged changes: HEAD to index
+  gitloom diff <rev>           # <rev> to working tree (--cached: to index)
+  gitloom diff <rev> <rev>     # between two commits, also written a..b
+
+--stat summarises the changed lines per file and --name-status lists the
+files with A, D, M or T. -U sets the number of context lines and
+--diff-algorithm picks myers, patience or histogram; diff.context and
+diff.algorithm set the defaults. Files with NUL bytes are reported as
+binary.`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+
+		if len(args) == 1 {
+			if from, to, ok := strings.Cut(args[0], ".."); ok {
+				args = []string{orHead(from), orHead(to)}
+			}
+		}
+		if len(args) == 2 && diffCached {
+			return errors.New("--cached compares the index with one commit at most")
+		}
+
+		changes, err := diffChanges(r, args)
+		if err != nil {
+			return err
+		}
+		opts, err := diffOptions(cmd, r)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		switch {
+		case diffNameStatus:
+			return diff.WriteNameStatus(out, changes)
+		case diffStat:
+			stats, err := diff.Stats(r, changes, opts)
+			if err != nil {
+				return err
+			}
+			return diff.WriteStat(out, stats)
+		}
+		return diff.WritePatch(out, r, changes, opts)
+	},
+}
+
+// diffChanges compares the two sides picked by the revisions given and
+// --cached.
+func diffChanges(r *repo.Repo, args []string) ([]diff.FileChange, error) {
+	if len(args) == 2 {
+		oldTree, err := resolveTree(r, args[0])
+		if err != nil {
+			return nil, err
+		}
+		newTree, err := resolveTree(r, args[1])
+		if err != nil {
+			return nil, err
+		}
+		return diff.DiffTrees(r, oldTree, newTree, true)
+	}
+
+	idx, err := index.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	var old diff.Snapshot
+	switch {
+	case len(args) == 1:
+		var treeHash string
+		if treeHash, err = resolveTree(r, args[0]); err == nil {
+			old, err = diff.TreeSnapshot(r, treeHash)
+		}
+	case diffCached:
+		old, err = headSnapshot(r)
+	default:
+		old = diff.IndexSnapshot(idx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	new := diff.IndexSnapshot(idx)
+	if !diffCached {
+		if new, err = diff.WorkTreeSnapshot(r, idx); err != nil {
+			return nil, err
+		}
+	}
+	return diff.Compare(old, new), nil
+}
+
+// headSnapshot returns the files of HEAD, or none on an unborn branch.
+func headSnapshot(r *repo.Repo) (diff.Snapshot, error) {
+	head, err := refs.Resolve(r, refs.Head)
+	if errors.Is(err, refs.ErrNotFound) {
+		return diff.TreeSnapshot(r, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+	c, err := history.ReadCommit(r, head)
+	if err != nil {
+		return nil, err
+	}
+	return diff.TreeSnapshot(r, c.Tree)
+}
+
+// diffOptions combines the flags with diff.context and diff.algorithm.
+func diffOptions(cmd *cobra.Command, r *repo.Repo) (diff.Options, error) {
+	opts := diff.Options{Context: diffContext}
+	cfg, err := r.Config()
+	if err != nil {
+		return opts, err
+	}
+
+	if !cmd.Flags().Changed("unified") {
+		n, err := cfg.Int("diff.context", diff.DefaultContext)
+		if err != nil {
+			return opts, err
+		}
+		opts.Context = int(n)
+	}
+	if opts.Context < 0 {
+		return opts, fmt.Errorf("invalid number of context lines: %d", opts.Context)
+	}
+
+	name := diffAlgorithm
+	if !cmd.Flags().Changed("diff-algorithm") {
+		name = cfg.String("diff.algorithm", name)
+	}
+	opts.Algorithm, err = diff.ParseAlgorithm(name)
+	return opts, err
+}
+
+// orHead fills in an omitted side of a..b.
+func orHead(rev string) string {
+	if rev == "" {
+		return refs.Head
+	}
+	return rev
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	f := diffCmd.Flags()
+	f.BoolVar(&diffCached, "cached", false, "Compare the index instead of the working tree")
+	f.BoolVar(&diffCached, "staged", false, "Synonym for --cached")
+	f.BoolVar(&diffStat, "stat", false, "Summarise changed lines per file")
+	f.BoolVar(&diffNameStatus, "name-status", false, "List changed files with their status")
+	f.IntVarP(&diffContext, "unified", "U", diff.DefaultContext, "Number of context lines")
+	f.StringVar(&diffAlgorithm, "diff-algorithm", "myers", "Diff algorithm: myers, patience or histogram")
+}
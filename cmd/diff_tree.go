@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/diff"
+	"github.com/MahendraDani/gitloom.git/internal/history"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/revision"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffTreeRecursive    bool
+	diffTreeRenames      string
+	diffTreeCopies       string
+	diffTreeCopiesHarder bool
+	diffTreeNameOnly     bool
+	diffTreeNameStatus   bool
+	diffTreePatch        bool
+	diffTreeRoot         bool
+	diffTreeNoCommitID   bool
+)
+
+// defaultSimilarity is the score -M and -C take when given without one.
+var defaultSimilarity = strconv.Itoa(diff.DefaultSimilarity) + "%"
+
+var diffTreeCmd = &cobra.Command{
+	Use:   "diff-tree [-r] [-M[<n>]] [-C[<n>]] <tree-ish> [<tree-ish>]",
+	Short: "Compare the content and mode of blobs found via two tree objects",
+	Long: `gitloom diff-tree compares two trees, or a commit with its parent, and prints
+one line per changed entry in git's raw format:
+
+  :100644 100644 <old hash> <new hash> M	path
+
+Subtrees with equal hashes are skipped without being read. Without -r a
+changed subtree is a single entry; -r descends into it and lists files.
+
+-M detects renames and -C copies from changed files, by default when 50% of
+the content matches; -M=90% or --find-copies=75 set the score. --find-copies-harder also
+considers unchanged files as copy sources. Given a single commit, its hash
+is printed first (unless --no-commit-id) and it is compared with its first
+parent; a root commit needs --root and merges print nothing.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+		out := cmd.OutOrStdout()
+
+		var oldTree, newTree string
+		if len(args) == 2 {
+			if oldTree, err = resolveTree(r, args[0]); err != nil {
+				return err
+			}
+			if newTree, err = resolveTree(r, args[1]); err != nil {
+				return err
+			}
+		} else {
+			hash, err := revision.ResolveCommit(r, args[0])
+			if err != nil {
+				return fmt.Errorf("not a valid commit: '%s'", args[0])
+			}
+			c, err := history.ReadCommit(r, hash)
+			if err != nil {
+				return err
+			}
+			switch {
+			case len(c.Parents) > 1:
+				return nil
+			case len(c.Parents) == 0 && !diffTreeRoot:
+				return nil
+			case len(c.Parents) == 1:
+				parent, err := history.ReadCommit(r, c.Parents[0])
+				if err != nil {
+					return err
+				}
+				oldTree = parent.Tree
+			}
+			newTree = c.Tree
+			if !diffTreeNoCommitID {
+				fmt.Fprintln(out, hash)
+			}
+		}
+
+		changes, err := diff.DiffTrees(r, oldTree, newTree, diffTreeRecursive || diffTreePatch)
+		if err != nil {
+			return err
+		}
+		if changes, err = findRenames(cmd, r, changes, oldTree); err != nil {
+			return err
+		}
+		return writeTreeChanges(out, r, changes)
+	},
+}
+
+// findRenames applies -M, -C and --find-copies-harder to changes.
+func findRenames(cmd *cobra.Command, r *repo.Repo, changes []diff.FileChange, oldTree string) ([]diff.FileChange, error) {
+	renames := cmd.Flags().Changed("find-renames")
+	copies := cmd.Flags().Changed("find-copies") || diffTreeCopiesHarder
+	if !renames && !copies {
+		return changes, nil
+	}
+
+	opts := diff.RenameOptions{Threshold: diff.DefaultSimilarity, Copies: copies}
+	var err error
+	if renames {
+		if opts.Threshold, err = parseSimilarity(diffTreeRenames); err != nil {
+			return nil, err
+		}
+	}
+	if cmd.Flags().Changed("find-copies") {
+		if opts.Threshold, err = parseSimilarity(diffTreeCopies); err != nil {
+			return nil, err
+		}
+	}
+	if diffTreeCopiesHarder {
+		if opts.CopySources, err = diff.TreeSnapshot(r, oldTree); err != nil {
+			return nil, err
+		}
+	}
+	return diff.FindRenames(r, changes, opts)
+}
+
+// parseSimilarity reads a -M or -C score: a percentage such as "90%", or
+// digits read as a fraction, so "5" is 50% and "75" is 75% as in git.
+func parseSimilarity(value string) (int, error) {
+	if pct, ok := strings.CutSuffix(value, "%"); ok {
+		n, err := strconv.Atoi(pct)
+		if err != nil || n < 0 || n > 100 {
+			return 0, fmt.Errorf("invalid similarity score %q", value)
+		}
+		return n, nil
+	}
+	if _, err := strconv.Atoi(value); err != nil {
+		return 0, fmt.Errorf("invalid similarity score %q", value)
+	}
+	f, err := strconv.ParseFloat("0."+value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid similarity score %q", value)
+	}
+	return int(f * 100), nil
+}
+
+// resolveTree resolves rev and peels it to a tree.
+func resolveTree(r *repo.Repo, rev string) (string, error) {
+	hash, err := revision.Resolve(r, rev)
+	if err != nil {
+		return "", err
+	}
+	return revision.Peel(r, hash, internal.TreeType)
+}
+
+func writeTreeChanges(w io.Writer, r *repo.Repo, changes []diff.FileChange) error {
+	switch {
+	case diffTreeNameOnly:
+		for _, c := range changes {
+			if _, err := fmt.Fprintln(w, c.Path); err != nil {
+				return err
+			}
+		}
+		return nil
+	case diffTreeNameStatus:
+		return diff.WriteNameStatus(w, changes)
+	case diffTreePatch:
+		return diff.WritePatch(w, r, changes, diff.Options{Context: diff.DefaultContext})
+	}
+	return diff.WriteRaw(w, changes)
+}
+
+func init() {
+	rootCmd.AddCommand(diffTreeCmd)
+	f := diffTreeCmd.Flags()
+	f.BoolVarP(&diffTreeRecursive, "recursive", "r", false, "Recurse into subtrees")
+	f.StringVarP(&diffTreeRenames, "find-renames", "M", "", "Detect renames, optionally with a similarity score")
+	f.Lookup("find-renames").NoOptDefVal = defaultSimilarity
+	f.StringVarP(&diffTreeCopies, "find-copies", "C", "", "Detect copies as well as renames")
+	f.Lookup("find-copies").NoOptDefVal = defaultSimilarity
+	f.BoolVar(&diffTreeCopiesHarder, "find-copies-harder", false, "Also consider unchanged files as copy sources")
+	f.BoolVar(&diffTreeNameOnly, "name-only", false, "Show only the names of changed files")
+	f.BoolVar(&diffTreeNameStatus, "name-status", false, "Show the names and status of changed files")
+	f.BoolVarP(&diffTreePatch, "patch", "p", false, "Show a patch instead of raw output (implies -r)")
+	f.BoolVar(&diffTreeRoot, "root", false, "Show a root commit as adding all of its files")
+	f.BoolVar(&diffTreeNoCommitID, "no-commit-id", false, "Do not print the commit hash")
+}
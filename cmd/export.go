@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MahendraDani/gitloom.git/internal/interop"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [<git-dir>]",
+	Short: "Copy objects and refs from .gitloom into a git repository",
+	Long: `gitloom export copies every object and ref of the current repository into a
+git repository. Without an argument the .git directory of the working tree is
+used. A missing target is created, as a bare repository unless it is named .git.
+
+Afterwards git log, git fsck and the other git commands work on the target.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		src, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+		if src.GitCompatible() {
+			return errors.New("the repository is already stored in .git")
+		}
+
+		gitDir := filepath.Join(src.WorkTree(), repo.GitDirName)
+		if len(args) > 0 {
+			gitDir = args[0]
+		}
+		if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+			if err := repo.InitGitDir(gitDir, filepath.Base(gitDir) != repo.GitDirName); err != nil {
+				return err
+			}
+		}
+		dst, err := interop.OpenGitDir(gitDir)
+		if err != nil {
+			return err
+		}
+
+		res, err := interop.Copy(src, dst)
+		if err != nil {
+			return fmt.Errorf("export failed: %v", err)
+		}
+		printCopyResult(cmd, "Exported", src, dst, res)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+}
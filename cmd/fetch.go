@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/branch"
+	"github.com/MahendraDani/gitloom.git/internal/remote"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+// summaryWidth is the width of the column describing each ref update.
+const summaryWidth = 17
+
+var fetchPrune bool
+
+var fetchCmd = &cobra.Command{
+	Use:   "fetch [<remote>]",
+	Short: "Download objects and branches from a remote",
+	Long: `gitloom fetch copies the objects of a remote that this repository is missing,
+following history only as far as the two have in common, and updates the
+remote-tracking branches under refs/remotes/<remote>/. Tags pointing into the
+fetched history are fetched too.
+
+Without a remote, the current branch's upstream remote is used, or origin.
+--prune deletes remote-tracking branches whose branch is gone on the remote.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+		name, err := remoteArg(r, args)
+		if err != nil {
+			return err
+		}
+
+		res, err := remote.Fetch(r, name, remote.FetchOptions{Prune: fetchPrune})
+		if err != nil {
+			return err
+		}
+		printUpdates(cmd.OutOrStdout(), "From "+res.URL, res.Updates, true)
+		for _, u := range res.Updates {
+			if u.Status == remote.Rejected {
+				return fmt.Errorf("some refs of '%s' could not be updated", name)
+			}
+		}
+		return nil
+	},
+}
+
+// remoteArg returns the remote named on the command line, or else the
+// remote the current branch tracks, or else origin.
+func remoteArg(r *repo.Repo, args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	current, err := branch.Current(r)
+	if err != nil {
+		return "", err
+	}
+	cfg, err := r.Config()
+	if err != nil {
+		return "", err
+	}
+	if name, ok := cfg.Get("branch." + current + ".remote"); ok && current != "" && name != "." {
+		return name, nil
+	}
+	return remote.Origin, nil
+}
+
+// printUpdates prints ref updates under heading the way git fetch and push
+// do. Refs that were already up to date are left out, and nothing is
+// printed when no ref changed.
+func printUpdates(w io.Writer, heading string, updates []remote.RefUpdate, fetch bool) {
+	var shown []remote.RefUpdate
+	width := 0
+	for _, u := range updates {
+		if u.Status == remote.UpToDate {
+			continue
+		}
+		shown = append(shown, u)
+		width = max(width, len(updateSource(u)))
+	}
+	if len(shown) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, heading)
+	for _, u := range shown {
+		flag, summary, note := updateSummary(u)
+		src, dst := updateSource(u), branch.ShortName(u.Dst)
+		switch {
+		case fetch:
+			line := fmt.Sprintf(" %c %-*s %-*s -> %s", flag, summaryWidth, summary, width, src, dst)
+			if note != "" {
+				line += "  (" + note + ")"
+			}
+			fmt.Fprintln(w, line)
+		default:
+			line := fmt.Sprintf(" %c %-*s %s -> %s", flag, summaryWidth, summary, src, dst)
+			if u.New == "" {
+				// Deletions send nothing
+				line = fmt.Sprintf(" %c %-*s %s", flag, summaryWidth, summary, dst)
+			}
+			if note != "" {
+				line += " (" + note + ")"
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+// updateSource is the short name of the sending side of u.
+func updateSource(u remote.RefUpdate) string {
+	switch {
+	case u.Src != "":
+		return branch.ShortName(u.Src)
+	case u.New != "":
+		return u.New[:7]
+	}
+	return "(none)"
+}
+
+// updateSummary returns the flag, summary column and trailing note of u.
+func updateSummary(u remote.RefUpdate) (byte, string, string) {
+	switch u.Status {
+	case remote.Created:
+		switch {
+		case strings.HasPrefix(u.Dst, repo.TagsDir+"/"):
+			return '*', "[new tag]", ""
+		case strings.HasPrefix(u.Dst, repo.HeadsDir+"/"), strings.HasPrefix(u.Dst, branch.RemotesDir+"/"):
+			return '*', "[new branch]", ""
+		}
+		return '*', "[new ref]", ""
+	case remote.FastForward:
+		return ' ', u.Old[:7] + ".." + u.New[:7], ""
+	case remote.Forced:
+		return '+', u.Old[:7] + "..." + u.New[:7], "forced update"
+	case remote.Deleted:
+		return '-', "[deleted]", ""
+	case remote.Rejected:
+		return '!', "[rejected]", u.Reason
+	}
+	return '=', "[up to date]", ""
+}
+
+func init() {
+	rootCmd.AddCommand(fetchCmd)
+	fetchCmd.Flags().BoolVarP(&fetchPrune, "prune", "p", false, "Delete remote-tracking branches that no longer exist on the remote")
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/MahendraDani/gitloom.git/internal/fsck"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fsckUnreachable bool
+	fsckNoDangling  bool
+)
+
+var fsckCmd = &cobra.Command{
+	Use:   "fsck",
+	Short: "Verify object integrity and connectivity",
+	Long: `gitloom fsck re-hashes every loose and packed object, checks object headers
+and the syntax of trees, commits and tags, verifies pack checksums, and reports
+objects that are missing although reachable from refs, HEAD or the index.
+
+Objects that nothing points to are listed as dangling. With --unreachable every
+object not reachable from a ref is listed instead.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+
+		rep, err := fsck.Check(r)
+		if err != nil {
+			return fmt.Errorf("fsck failed: %v", err)
+		}
+
+		for _, issue := range rep.Issues {
+			fmt.Println(issue)
+		}
+		for _, bl := range rep.BrokenLinks {
+			fmt.Printf("broken link from %7s %s\n", bl.From.Type, bl.From.Hash)
+			fmt.Printf("              to %7s %s\n", bl.To.Type, bl.To.Hash)
+		}
+		for _, obj := range rep.Missing {
+			fmt.Printf("missing %s %s\n", obj.Type, obj.Hash)
+		}
+		if fsckUnreachable {
+			for _, obj := range rep.Unreachable {
+				fmt.Printf("unreachable %s %s\n", obj.Type, obj.Hash)
+			}
+		} else if !fsckNoDangling {
+			for _, obj := range rep.Dangling {
+				fmt.Printf("dangling %s %s\n", obj.Type, obj.Hash)
+			}
+		}
+
+		if !rep.OK() {
+			return fmt.Errorf("repository is corrupt")
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(fsckCmd)
+	fsckCmd.Flags().BoolVar(&fsckUnreachable, "unreachable", false, "List all unreachable objects")
+	fsckCmd.Flags().BoolVar(&fsckNoDangling, "no-dangling", false, "Do not list dangling objects")
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MahendraDani/gitloom.git/internal/gc"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var gcPrune string
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Pack objects and prune unreachable ones",
+	Long: `gitloom gc packs every object reachable from refs, HEAD and the index into a
+single pack, removes the packs and loose objects that makes redundant, and
+deletes unreachable objects older than the --prune expiry (two weeks by default).
+
+The expiry can be "now", "never", a duration such as "36h" or "14d", or a date
+like "2.weeks.ago".`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+
+		expire, err := gc.ParseExpire(gcPrune, time.Now())
+		if err != nil {
+			return err
+		}
+		if err := gc.Run(r, gc.Options{PruneExpire: expire, PruneNow: gcPrune == "now"}); err != nil {
+			return fmt.Errorf("gc failed: %v", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(gcCmd)
+	gcCmd.Flags().StringVar(&gcPrune, "prune", gc.DefaultPruneExpire, "Prune unreachable objects older than this")
+}
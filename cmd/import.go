@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/MahendraDani/gitloom.git/internal/interop"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import [<git-dir>]",
+	Short: "Copy objects and refs from a git repository into .gitloom",
+	Long: `gitloom import copies every object and ref of a git repository into the
+.gitloom directory of the current working tree, creating it if needed. Without an
+argument the .git directory of the working tree is imported.
+
+Refs already in .gitloom are overwritten; HEAD is only changed while the current
+branch has no commits. Once .gitloom exists it takes precedence over .git.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dst, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+		if dst.GitCompatible() {
+			// Only .git was found: give its working tree a .gitloom directory
+			dst = repo.NewRepo(dst.WorkTree())
+			if err := dst.Init(); err != nil {
+				return err
+			}
+		}
+
+		gitDir := filepath.Join(dst.WorkTree(), repo.GitDirName)
+		if len(args) > 0 {
+			gitDir = args[0]
+		}
+		src, err := interop.OpenGitDir(gitDir)
+		if err != nil {
+			return err
+		}
+
+		res, err := interop.Copy(src, dst)
+		if err != nil {
+			return fmt.Errorf("import failed: %v", err)
+		}
+		printCopyResult(cmd, "Imported", src, dst, res)
+		return nil
+	},
+}
+
+// printCopyResult summarises an import or export.
+func printCopyResult(cmd *cobra.Command, verb string, src, dst *repo.Repo, res *interop.Result) {
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "%s %d loose objects, %d packs and %d refs from %s into %s\n",
+		verb, res.Objects, res.Packs, len(res.Refs), src.Path, dst.Path)
+	for _, ref := range res.Refs {
+		fmt.Fprintf(out, " %s %s\n", ref.Hash[:7], ref.Name)
+	}
+	if res.Index {
+		fmt.Fprintln(out, "Copied the index")
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+}
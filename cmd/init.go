@@ -13,6 +13,8 @@ type ctxKey string
 
 const repoKey ctxKey = "repo"
 
+var initGitCompatible bool
+
 // initCmd represents the init command
 var initCmd = &cobra.Command{
 	Use:   "init",
@@ -21,6 +23,8 @@ var initCmd = &cobra.Command{
 
 gitloom init - creates a new gitloom repository within current working directory
 gitloom init dir-name - creates a new gitloom repository within dir-name directory. 
+gitloom init --git-compatible - stores the repository in .git instead, so git can use it too.
+  In an existing git repository it lets gitloom use that repository.
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		var path string
@@ -43,6 +47,20 @@ gitloom init dir-name - creates a new gitloom repository within dir-name directo
 		}
 
 		repo := repo.NewRepo(absPath)
+		if initGitCompatible {
+			reused, err := repo.InitGitCompatible()
+			if err != nil {
+				fmt.Println("Error initializing repository:", err)
+				return
+			}
+			if reused {
+				fmt.Println("Using existing git repository at", repo.Path)
+				return
+			}
+			fmt.Println("Initialized empty git-compatible gitloom repository at", repo.Path)
+			return
+		}
+
 		if err := repo.Init(); err != nil {
 			fmt.Println("Error initializing repository:", err)
 		}
@@ -53,4 +71,5 @@ gitloom init dir-name - creates a new gitloom repository within dir-name directo
 
 func init() {
 	rootCmd.AddCommand(initCmd)
+	initCmd.Flags().BoolVar(&initGitCompatible, "git-compatible", false, "Store the repository in a .git directory that git can also use")
 }
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/MahendraDani/gitloom.git/internal/history"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/revision"
+	"github.com/spf13/cobra"
+)
+
+var logOptions history.LogOptions
+
+var logCmd = &cobra.Command{
+	Use:   "log [<revision>...]",
+	Short: "Show commit history",
+	Long: `gitloom log walks the parents of the given revisions (HEAD by default) and
+prints each commit, newest first.
+
+Usage:
+  gitloom log                  # full history of HEAD
+  gitloom log --oneline -n 5   # last five commits, one per line
+  gitloom log --graph main topic`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+
+		if len(args) == 0 {
+			args = []string{refs.Head}
+		}
+
+		var starts []string
+		for _, rev := range args {
+			hash, err := revision.ResolveCommit(r, rev)
+			if err != nil {
+				if rev == refs.Head && errors.Is(err, revision.ErrUnknown) {
+					return fmt.Errorf("current branch does not have any commits yet")
+				}
+				return err
+			}
+			starts = append(starts, hash)
+		}
+
+		return history.Log(r, os.Stdout, starts, logOptions)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(logCmd)
+	logCmd.Flags().BoolVar(&logOptions.Oneline, "oneline", false, "Show each commit on a single line")
+	logCmd.Flags().BoolVar(&logOptions.Graph, "graph", false, "Draw the commit graph next to the log")
+	logCmd.Flags().IntVarP(&logOptions.MaxCount, "max-count", "n", 0, "Limit the number of commits shown")
+}
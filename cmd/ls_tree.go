@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/revision"
+	"github.com/MahendraDani/gitloom.git/internal/tree"
+	"github.com/spf13/cobra"
+)
+
+var lsTreeOpts tree.LsOptions
+
+var lsTreeCmd = &cobra.Command{
+	Use:   "ls-tree [-r] [-d] [-t] [-l] [--name-only] <tree-ish>",
+	Short: "List the contents of a tree object",
+	Long: `gitloom ls-tree lists the entries of a tree as
+
+  <mode> <type> <hash>	<path>
+
+<tree-ish> may name a tree, or a commit or tag whose tree is listed, for example
+HEAD or main:src. With -r subtrees are listed recursively, -t also shows the
+subtrees themselves while recursing and -d shows only subtrees. -l adds the size
+of each blob.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+
+		hash, err := revision.Resolve(r, args[0])
+		if err != nil {
+			return err
+		}
+		treeHash, err := revision.Peel(r, hash, internal.TreeType)
+		if err != nil {
+			return fmt.Errorf("not a tree object: %s", args[0])
+		}
+
+		return tree.LsTree(r, treeHash, lsTreeOpts, os.Stdout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lsTreeCmd)
+	lsTreeCmd.Flags().BoolVarP(&lsTreeOpts.Recursive, "recursive", "r", false, "Recurse into subtrees")
+	lsTreeCmd.Flags().BoolVarP(&lsTreeOpts.DirsOnly, "dirs-only", "d", false, "Show only subtrees")
+	lsTreeCmd.Flags().BoolVarP(&lsTreeOpts.ShowTrees, "show-trees", "t", false, "Show subtrees while recursing")
+	lsTreeCmd.Flags().BoolVar(&lsTreeOpts.NameOnly, "name-only", false, "Print only paths")
+	lsTreeCmd.Flags().BoolVarP(&lsTreeOpts.Long, "long", "l", false, "Show blob sizes")
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/MahendraDani/gitloom.git/internal/diff"
+	"github.com/MahendraDani/gitloom.git/internal/merge"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mergeMessage        string
+	mergeNoFF           bool
+	mergeFFOnly         bool
+	mergeAbort          bool
+	mergeAllowUnrelated bool
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge [-m <message>] [--no-ff | --ff-only] <commit>\n  gitloom merge --abort",
+	Short: "Join the history of another branch or commit into the current branch",
+	Long: `gitloom merge incorporates the changes made on <commit> since it diverged
+from the current branch. When the current branch is an ancestor of <commit>
+it is fast-forwarded, unless --no-ff is given; otherwise the files changed
+on both sides since their merge base are merged line by line and a commit
+with both as parents is created.
+
+If both sides changed the same lines, the merge stops: the conflicted files
+hold both versions between conflict markers and the index keeps the base,
+ours and theirs versions in stages 1, 2 and 3. Resolve them, stage the
+result with gitloom add and run gitloom commit, or run gitloom merge --abort
+to go back to the state before the merge.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if mergeAbort {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+		if mergeAbort {
+			return merge.Abort(r)
+		}
+		if mergeNoFF && mergeFFOnly {
+			return errors.New("--no-ff and --ff-only cannot be used together")
+		}
+
+		res, err := merge.Merge(r, args[0], merge.Options{
+			Message:        mergeMessage,
+			NoFF:           mergeNoFF,
+			FFOnly:         mergeFFOnly,
+			AllowUnrelated: mergeAllowUnrelated,
+		})
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		switch {
+		case res.UpToDate:
+			fmt.Fprintln(out, "Already up to date.")
+			return nil
+		case res.FastForward:
+			if res.Head != "" {
+				fmt.Fprintf(out, "Updating %s..%s\n", res.Head[:7], res.Commit[:7])
+			}
+			fmt.Fprintln(out, "Fast-forward")
+			return writeMergeStat(out, r, res)
+		}
+
+		for _, m := range res.Messages {
+			fmt.Fprintln(out, m)
+		}
+		if len(res.Conflicts) > 0 {
+			return errors.New("automatic merge failed; fix conflicts and then commit the result")
+		}
+		fmt.Fprintln(out, "Merge made by the 'recursive' strategy.")
+		return writeMergeStat(out, r, res)
+	},
+}
+
+// writeMergeStat prints the diffstat of what the merge changed.
+func writeMergeStat(w io.Writer, r *repo.Repo, res *merge.Result) error {
+	changes, err := diff.DiffTrees(r, res.OldTree, res.NewTree, true)
+	if err != nil {
+		return err
+	}
+	stats, err := diff.Stats(r, changes, diff.Options{})
+	if err != nil {
+		return err
+	}
+	return diff.WriteStat(w, stats)
+}
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+	f := mergeCmd.Flags()
+	f.StringVarP(&mergeMessage, "message", "m", "", "Message of the merge commit")
+	f.BoolVar(&mergeNoFF, "no-ff", false, "Create a merge commit even when the merge could be fast-forwarded")
+	f.BoolVar(&mergeFFOnly, "ff-only", false, "Refuse to merge unless the current branch can be fast-forwarded")
+	f.BoolVar(&mergeAbort, "abort", false, "Abort a conflicted merge and restore the state before it")
+	f.BoolVar(&mergeAllowUnrelated, "allow-unrelated-histories", false, "Allow merging commits that share no history")
+}
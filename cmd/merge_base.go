@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/MahendraDani/gitloom.git/internal/history"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/revision"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mergeBaseAll        bool
+	mergeBaseIsAncestor bool
+)
+
+var mergeBaseCmd = &cobra.Command{
+	Use:   "merge-base [--all] <commit> <commit>\n  gitloom merge-base --is-ancestor <commit> <commit>",
+	Short: "Find the best common ancestors of two commits",
+	Long: `gitloom merge-base prints the best common ancestor of two commits: one that
+both can reach and that is not an ancestor of another such commit. In
+criss-cross histories there can be several; --all prints all of them.
+
+With --is-ancestor nothing is printed and the exit status tells whether the
+first commit is an ancestor of the second: 0 if it is, 1 if not.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+
+		var hashes [2]string
+		for i, rev := range args {
+			if hashes[i], err = revision.ResolveCommit(r, rev); err != nil {
+				return fmt.Errorf("not a valid commit: '%s'", rev)
+			}
+		}
+
+		if mergeBaseIsAncestor {
+			ok, err := history.IsAncestor(r, hashes[0], hashes[1])
+			if err != nil {
+				return err
+			}
+			if !ok {
+				os.Exit(1)
+			}
+			return nil
+		}
+
+		bases, err := history.MergeBases(r, hashes[0], hashes[1])
+		if err != nil {
+			return err
+		}
+		if len(bases) == 0 {
+			// Unrelated histories: like git, fail without a message
+			os.Exit(1)
+		}
+		if !mergeBaseAll {
+			bases = bases[:1]
+		}
+		for _, hash := range bases {
+			fmt.Fprintln(cmd.OutOrStdout(), hash)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mergeBaseCmd)
+	mergeBaseCmd.Flags().BoolVar(&mergeBaseAll, "all", false, "Print all best common ancestors")
+	mergeBaseCmd.Flags().BoolVar(&mergeBaseIsAncestor, "is-ancestor", false, "Exit with status 0 if the first commit is an ancestor of the second, 1 if not")
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/pack"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	packObjectsWindow   int
+	packObjectsDepth    int
+	packObjectsRefDelta bool
+)
+
+var packObjectsCmd = &cobra.Command{
+	Use:   "pack-objects",
+	Short: "Write a packfile from a list of objects",
+	Long: `gitloom pack-objects reads object hashes from standard input, one per line,
+and writes them to a new pack in .gitloom/objects/pack, storing objects as
+deltas against similar ones where that is smaller. It prints the pack checksum.
+The loose objects are not removed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+
+		var hashes []string
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			// Accept "<hash> <path>" lines as produced by other commands
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 0 {
+				continue
+			}
+			if !internal.IsHash(fields[0]) {
+				return fmt.Errorf("invalid object name %q", fields[0])
+			}
+			hashes = append(hashes, fields[0])
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+
+		checksum, err := r.WritePack(hashes, pack.Options{
+			Window:   packObjectsWindow,
+			MaxDepth: packObjectsDepth,
+			RefDelta: packObjectsRefDelta,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write pack: %v", err)
+		}
+		fmt.Println(checksum)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(packObjectsCmd)
+	packObjectsCmd.Flags().IntVar(&packObjectsWindow, "window", pack.DefaultWindow, "Number of objects tried as delta bases")
+	packObjectsCmd.Flags().IntVar(&packObjectsDepth, "depth", pack.DefaultDepth, "Maximum delta chain length")
+	packObjectsCmd.Flags().BoolVar(&packObjectsRefDelta, "ref-delta", false, "Refer to delta bases by hash instead of by offset")
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MahendraDani/gitloom.git/internal/remote"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pushForce       bool
+	pushSetUpstream bool
+	pushDelete      bool
+)
+
+var pushCmd = &cobra.Command{
+	Use:   "push [<remote> [<refspec>...]]",
+	Short: "Update branches of a remote from local ones",
+	Long: `gitloom push copies the objects a remote is missing and moves its branches to
+the local commits. A branch is only moved when the update is a fast-forward,
+unless --force is given or the refspec starts with '+'. Existing tags are
+never moved without --force, and a branch checked out in the remote's working
+tree is never updated; push to a bare repository instead.
+
+Without a remote, the current branch's upstream remote is used, or origin.
+Without refspecs, the current branch is pushed to its upstream branch, or to
+the branch of the same name.
+
+Usage:
+  gitloom push                          # current branch to its upstream
+  gitloom push -u origin topic          # push topic and track origin/topic
+  gitloom push origin main:release      # push main to the remote's release
+  gitloom push origin v1.0              # push a tag
+  gitloom push origin :topic            # delete the remote's topic (or -d topic)`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+		name, err := remoteArg(r, args)
+		if err != nil {
+			return err
+		}
+		var refspecs []string
+		if len(args) > 1 {
+			refspecs = args[1:]
+		}
+		if pushDelete {
+			if len(refspecs) == 0 {
+				return errors.New("--delete requires the refs to delete")
+			}
+			for i, ref := range refspecs {
+				refspecs[i] = ":" + ref
+			}
+		}
+
+		res, err := remote.Push(r, name, refspecs, remote.PushOptions{Force: pushForce, SetUpstream: pushSetUpstream})
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		rejected, upToDate := false, true
+		for _, u := range res.Updates {
+			rejected = rejected || u.Status == remote.Rejected
+			upToDate = upToDate && u.Status == remote.UpToDate
+		}
+		if upToDate {
+			fmt.Fprintln(out, "Everything up-to-date")
+			return nil
+		}
+		printUpdates(out, "To "+res.URL, res.Updates, false)
+		if rejected {
+			return fmt.Errorf("failed to push some refs to '%s'", res.URL)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pushCmd)
+	pushCmd.Flags().BoolVarP(&pushForce, "force", "f", false, "Allow updates that are not fast-forwards")
+	pushCmd.Flags().BoolVarP(&pushSetUpstream, "set-upstream", "u", false, "Make each pushed branch track the remote branch")
+	pushCmd.Flags().BoolVarP(&pushDelete, "delete", "d", false, "Delete the named refs on the remote")
+}
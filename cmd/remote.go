@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/MahendraDani/gitloom.git/internal/remote"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var remoteVerbose bool
+
+var remoteCmd = &cobra.Command{
+	Use:   "remote [-v]",
+	Short: "Manage the repositories whose branches are tracked",
+	Long: `gitloom remote lists the configured remotes; -v adds their URLs.
+
+A remote is another gitloom or git repository on this machine or a shared file
+system, named by its path or a file:// URL. fetch copies its branches into
+refs/remotes/<name>/ and push updates its branches from local ones.
+
+Usage:
+  gitloom remote add origin /srv/repos/project.git
+  gitloom remote remove origin
+  gitloom remote -v`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+		remotes, err := remote.List(r)
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		for _, rem := range remotes {
+			if !remoteVerbose {
+				fmt.Fprintln(out, rem.Name)
+				continue
+			}
+			fmt.Fprintf(out, "%s\t%s (fetch)\n", rem.Name, rem.URL)
+			fmt.Fprintf(out, "%s\t%s (push)\n", rem.Name, rem.URL)
+		}
+		return nil
+	},
+}
+
+var remoteAddCmd = &cobra.Command{
+	Use:   "add <name> <url>",
+	Short: "Add a remote",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+		return remote.Add(r, args[0], args[1])
+	},
+}
+
+var remoteRemoveCmd = &cobra.Command{
+	Use:     "remove <name>",
+	Aliases: []string{"rm"},
+	Short:   "Remove a remote and its remote-tracking branches",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+		return remote.Remove(r, args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(remoteCmd)
+	remoteCmd.AddCommand(remoteAddCmd, remoteRemoveCmd)
+	remoteCmd.Flags().BoolVarP(&remoteVerbose, "verbose", "v", false, "Show remote URLs")
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/MahendraDani/gitloom.git/internal/gc"
+	"github.com/MahendraDani/gitloom.git/internal/pack"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	repackAll    bool
+	repackDelete bool
+	repackWindow int
+	repackDepth  int
+)
+
+var repackCmd = &cobra.Command{
+	Use:   "repack [-a] [-d]",
+	Short: "Pack loose objects",
+	Long: `gitloom repack packs all loose objects into a new pack. With -a it instead
+packs every object reachable from refs, HEAD and the index, including those in
+existing packs. With -d it then deletes the packs and loose objects the new pack
+makes redundant; with -a that drops unreachable objects stored in old packs.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+
+		checksum, err := gc.Repack(r, gc.RepackOptions{
+			All:    repackAll,
+			Delete: repackDelete,
+			Pack:   pack.Options{Window: repackWindow, MaxDepth: repackDepth},
+		})
+		if err != nil {
+			return fmt.Errorf("repack failed: %v", err)
+		}
+		if checksum == "" {
+			fmt.Println("Nothing new to pack.")
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(repackCmd)
+	repackCmd.Flags().BoolVarP(&repackAll, "all", "a", false, "Pack every reachable object into a single pack")
+	repackCmd.Flags().BoolVarP(&repackDelete, "delete", "d", false, "Remove redundant packs and loose objects")
+	repackCmd.Flags().IntVar(&repackWindow, "window", pack.DefaultWindow, "Number of objects tried as delta bases")
+	repackCmd.Flags().IntVar(&repackDepth, "depth", pack.DefaultDepth, "Maximum delta chain length")
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/revision"
+	"github.com/spf13/cobra"
+)
+
+var (
+	revParseShort     bool
+	revParseVerify    bool
+	revParseAbbrevRef bool
+)
+
+var revParseCmd = &cobra.Command{
+	Use:   "rev-parse <revision>...",
+	Short: "Resolve revisions to object hashes",
+	Long: `gitloom rev-parse prints the full hash named by each revision.
+
+Revisions can be full or unique abbreviated hashes, HEAD, branch and tag names,
+and may be followed by ~<n>, ^<n>, ^{<type>} or :<path>. For example:
+
+  gitloom rev-parse HEAD~3
+  gitloom rev-parse main^2
+  gitloom rev-parse a1b2c3:src/main.go
+  gitloom rev-parse --abbrev-ref HEAD`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+
+		if revParseVerify && len(args) != 1 {
+			return fmt.Errorf("--verify takes exactly one revision")
+		}
+
+		for _, rev := range args {
+			if revParseAbbrevRef {
+				name, err := abbrevRef(r, rev)
+				if err != nil {
+					return err
+				}
+				fmt.Println(name)
+				continue
+			}
+
+			hash, err := revision.Resolve(r, rev)
+			if err != nil {
+				return err
+			}
+			if revParseShort {
+				hash = hash[:7]
+			}
+			fmt.Println(hash)
+		}
+		return nil
+	},
+}
+
+// abbrevRef prints the short branch name a symbolic ref points to, or the
+// revision itself when it is not symbolic.
+func abbrevRef(r *repo.Repo, rev string) (string, error) {
+	if rev == "@" {
+		rev = refs.Head
+	}
+	target, err := refs.Deref(r, rev)
+	if err != nil {
+		return "", err
+	}
+	if _, err := revision.Resolve(r, rev); err != nil {
+		return "", err
+	}
+	target = strings.TrimPrefix(target, repo.HeadsDir+"/")
+	return strings.TrimPrefix(target, "refs/tags/"), nil
+}
+
+func init() {
+	rootCmd.AddCommand(revParseCmd)
+	revParseCmd.Flags().BoolVar(&revParseShort, "short", false, "Print abbreviated hashes")
+	revParseCmd.Flags().BoolVar(&revParseVerify, "verify", false, "Require exactly one revision that names an object")
+	revParseCmd.Flags().BoolVar(&revParseAbbrevRef, "abbrev-ref", false, "Print the short ref name instead of the hash")
+}
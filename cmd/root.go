@@ -3,15 +3,22 @@ package cmd
 import (
 	"os"
 
+	"github.com/MahendraDani/gitloom.git/internal/config"
 	"github.com/spf13/cobra"
 )
 
+// configParams holds the -c key=value overrides.
+var configParams []string
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "gitloom",
 	Short: "A git-like version control system",
 	Long: `An implementation of git-like version control system using Go. It supports 
 only a minimal subset of essentials commands used in git.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return config.SetCommandLine(configParams)
+	},
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
@@ -27,5 +34,6 @@ func Execute() {
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringArrayVarP(&configParams, "config", "c", nil, "Override a configuration value for this command, as key=value")
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	showRefHeads bool
+	showRefTags  bool
+	showRefHead  bool
+)
+
+var showRefCmd = &cobra.Command{
+	Use:   "show-ref [--heads] [--tags] [--head] [<pattern>...]",
+	Short: "List refs and the objects they point to",
+	Long: `gitloom show-ref lists refs under .gitloom/refs along with their hashes.
+A pattern matches a ref if it equals the end of the ref name at a "/" boundary,
+so "main" matches refs/heads/main.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+
+		all, err := refs.List(r, "")
+		if err != nil {
+			return fmt.Errorf("failed to list refs: %v", err)
+		}
+
+		if showRefHead {
+			if hash, err := refs.Resolve(r, refs.Head); err == nil {
+				all = append([]refs.Ref{{Name: refs.Head, Hash: hash}}, all...)
+			}
+		}
+
+		found := false
+		for _, ref := range all {
+			if !showRefMatches(ref.Name, args) {
+				continue
+			}
+			fmt.Printf("%s %s\n", ref.Hash, ref.Name)
+			found = true
+		}
+
+		if !found {
+			return fmt.Errorf("no matching refs")
+		}
+		return nil
+	},
+}
+
+func showRefMatches(name string, patterns []string) bool {
+	if name != refs.Head && (showRefHeads || showRefTags) {
+		inHeads := showRefHeads && strings.HasPrefix(name, "refs/heads/")
+		inTags := showRefTags && strings.HasPrefix(name, "refs/tags/")
+		if !inHeads && !inTags {
+			return false
+		}
+	}
+
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if name == p || strings.HasSuffix(name, "/"+p) {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	rootCmd.AddCommand(showRefCmd)
+	showRefCmd.Flags().BoolVar(&showRefHeads, "heads", false, "Only show branches")
+	showRefCmd.Flags().BoolVar(&showRefTags, "tags", false, "Only show tags")
+	showRefCmd.Flags().BoolVar(&showRefHead, "head", false, "Also show HEAD")
+}
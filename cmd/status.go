@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/status"
+	"github.com/spf13/cobra"
+)
+
+var statusShort bool
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show staged, unstaged and untracked changes",
+	Long: `gitloom status compares the tree of HEAD with the index (changes to be
+committed), the index with the working tree (changes not staged for commit),
+and lists files that are not tracked at all.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+
+		s, err := status.Compute(r)
+		if err != nil {
+			return fmt.Errorf("failed to compute status: %v", err)
+		}
+
+		if statusShort {
+			s.WriteShort(os.Stdout)
+		} else {
+			s.WriteLong(os.Stdout)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().BoolVarP(&statusShort, "short", "s", false, "Give the output in the short format")
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MahendraDani/gitloom.git/internal/branch"
+	"github.com/MahendraDani/gitloom.git/internal/checkout"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	switchCreate  string
+	switchDetach  bool
+	switchDiscard bool
+)
+
+var switchCmd = &cobra.Command{
+	Use:   "switch [--create <new>] [--detach] <branch>",
+	Short: "Switch branches",
+	Long: `gitloom switch is checkout limited to branches: it changes the current branch
+and updates the index and working tree to match, keeping local changes to
+files the two branches agree on. Checking out any other commit requires
+--detach.
+
+Usage:
+  gitloom switch topic
+  gitloom switch --create topic [start]
+  gitloom switch --detach v1.0`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+
+		rev := ""
+		if len(args) == 1 {
+			rev = args[0]
+		}
+		if rev == "" && switchCreate == "" {
+			return errors.New("missing branch or commit argument")
+		}
+
+		if switchCreate == "" && !switchDetach {
+			exists, err := branch.Exists(r, rev)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return fmt.Errorf("a branch is expected, got '%s'; use --detach to check out a commit", rev)
+			}
+		}
+
+		return switchTo(r, cmd.OutOrStdout(), rev, checkout.Options{
+			Force:     switchDiscard,
+			Detach:    switchDetach,
+			NewBranch: switchCreate,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(switchCmd)
+	// -c is taken by the global configuration override
+	switchCmd.Flags().StringVar(&switchCreate, "create", "", "Create a new branch and switch to it")
+	switchCmd.Flags().BoolVarP(&switchDetach, "detach", "d", false, "Detach HEAD at the named commit")
+	switchCmd.Flags().BoolVarP(&switchDiscard, "discard-changes", "f", false, "Discard local changes")
+}
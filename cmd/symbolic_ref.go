@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var symbolicRefShort bool
+
+var symbolicRefCmd = &cobra.Command{
+	Use:   "symbolic-ref <name> [<ref>]",
+	Short: "Read or modify a symbolic ref",
+	Long: `gitloom symbolic-ref prints the ref that a symbolic ref such as HEAD points to,
+or makes it point to <ref> when one is given.
+
+Usage:
+  gitloom symbolic-ref HEAD                    # print refs/heads/main
+  gitloom symbolic-ref --short HEAD            # print main
+  gitloom symbolic-ref HEAD refs/heads/topic   # switch HEAD to another branch`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+
+		if len(args) == 2 {
+			if err := refs.SetSymbolic(r, args[0], args[1]); err != nil {
+				return fmt.Errorf("failed to update symbolic ref: %v", err)
+			}
+			return nil
+		}
+
+		target, err := refs.ReadSymbolic(r, args[0])
+		if err != nil {
+			return err
+		}
+
+		if symbolicRefShort {
+			target = strings.TrimPrefix(target, repo.HeadsDir+"/")
+		}
+		fmt.Println(target)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(symbolicRefCmd)
+	symbolicRefCmd.Flags().BoolVar(&symbolicRefShort, "short", false, "Print the ref without its refs/heads/ prefix")
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/history"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/tag"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tagAnnotate bool
+	tagMessage  string
+	tagForce    bool
+	tagDelete   bool
+	tagList     bool
+	tagLines    int
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag [<name> [<object>]]",
+	Short: "Create, list or delete tags",
+	Long: `gitloom tag lists tags, or creates a tag naming a commit or any other object.
+
+A lightweight tag is just a ref under refs/tags. An annotated tag, made with
+-a or -m, points at a tag object recording the tagged object, its type, the
+tag name, the tagger and a message; rev-parse v1.0^{} peels it back to the
+commit.
+
+Usage:
+  gitloom tag v1.0                     # lightweight tag at HEAD
+  gitloom tag -m "Release 1.0" v1.0    # annotated tag at HEAD
+  gitloom tag v0.9 main~3              # tag another revision
+  gitloom tag -f v1.0 main             # move an existing tag
+  gitloom tag -d v1.0 v0.9             # delete tags
+  gitloom tag -l 'v1.*'                # list tags matching a pattern
+  gitloom tag -n                       # list with the first line of each message`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+		out := cmd.OutOrStdout()
+
+		switch {
+		case tagDelete:
+			if len(args) == 0 {
+				return errors.New("tag name required")
+			}
+			for _, name := range args {
+				hash, err := tag.Delete(r, name)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "Deleted tag '%s' (was %s)\n", name, hash[:7])
+			}
+			return nil
+
+		case tagList || len(args) == 0 || cmd.Flags().Changed("lines"):
+			return listTags(r, out, args)
+		}
+
+		if len(args) > 2 {
+			return errors.New("too many arguments to create a tag")
+		}
+		if tagAnnotate && tagMessage == "" {
+			return errors.New("an annotated tag needs a message; use -m <message>")
+		}
+		rev := refs.Head
+		if len(args) == 2 {
+			rev = args[1]
+		}
+		old, err := tag.Create(r, args[0], rev, tagMessage, tagForce)
+		if err != nil {
+			return err
+		}
+		if old != "" {
+			fmt.Fprintf(out, "Updated tag '%s' (was %s)\n", args[0], old[:7])
+		}
+		return nil
+	},
+}
+
+func listTags(r *repo.Repo, w io.Writer, patterns []string) error {
+	tags, err := tag.List(r, patterns)
+	if err != nil {
+		return err
+	}
+	for _, t := range tags {
+		if tagLines <= 0 {
+			fmt.Fprintln(w, t.Name)
+			continue
+		}
+		lines, err := tagMessageLines(r, t)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%-15s %s\n", t.Name, strings.Join(lines, "\n    "))
+	}
+	return nil
+}
+
+// tagMessageLines returns the first -n lines of a tag's message, falling
+// back to the tagged commit's message for lightweight tags as git does.
+func tagMessageLines(r *repo.Repo, t tag.Tag) ([]string, error) {
+	message := ""
+	switch {
+	case t.Annotation != nil:
+		message = t.Annotation.Message
+	case t.ObjType == internal.CommitType:
+		c, err := history.ReadCommit(r, t.Object)
+		if err != nil {
+			return nil, err
+		}
+		message = c.Message
+	}
+	lines := strings.Split(strings.TrimRight(message, "\n"), "\n")
+	return lines[:min(len(lines), tagLines)], nil
+}
+
+func init() {
+	rootCmd.AddCommand(tagCmd)
+	tagCmd.Flags().BoolVarP(&tagAnnotate, "annotate", "a", false, "Make an annotated tag object")
+	tagCmd.Flags().StringVarP(&tagMessage, "message", "m", "", "Tag message; implies -a")
+	tagCmd.Flags().BoolVarP(&tagForce, "force", "f", false, "Replace an existing tag")
+	tagCmd.Flags().BoolVarP(&tagDelete, "delete", "d", false, "Delete tags")
+	tagCmd.Flags().BoolVarP(&tagList, "list", "l", false, "List tags, optionally matching patterns")
+	tagCmd.Flags().IntVarP(&tagLines, "lines", "n", 0, "Print <n> lines of each tag message when listing")
+	tagCmd.Flags().Lookup("lines").NoOptDefVal = "1"
+}
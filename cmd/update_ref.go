@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/revision"
+	"github.com/spf13/cobra"
+)
+
+var (
+	updateRefDelete  bool
+	updateRefNoDeref bool
+)
+
+var updateRefCmd = &cobra.Command{
+	Use:   "update-ref <ref> <newvalue> [<oldvalue>] | -d <ref> [<oldvalue>]",
+	Short: "Safely update the object name stored in a ref",
+	Long: `gitloom update-ref points <ref> at <newvalue>. If <oldvalue> is given the
+update only happens if the ref currently holds <oldvalue>; use 40 zeros to
+require that the ref does not exist yet.
+
+Usage:
+  gitloom update-ref refs/heads/main <hash>             # set a ref
+  gitloom update-ref refs/heads/main <new> <old>        # compare-and-swap
+  gitloom update-ref -d refs/heads/topic                # delete a ref
+  gitloom update-ref --no-deref HEAD <hash>             # detach HEAD`,
+	Args: cobra.RangeArgs(1, 3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := repo.FindRepo(".")
+		if err != nil {
+			return fmt.Errorf("not a gitloom repository: %v", err)
+		}
+
+		name := args[0]
+
+		if updateRefDelete {
+			if len(args) > 2 {
+				return fmt.Errorf("usage: update-ref -d <ref> [<oldvalue>]")
+			}
+			oldHash := ""
+			if len(args) == 2 {
+				if oldHash, err = resolveOldValue(r, args[1]); err != nil {
+					return err
+				}
+			}
+			if err := refs.Delete(r, name, oldHash); err != nil {
+				return fmt.Errorf("failed to delete ref: %v", err)
+			}
+			return nil
+		}
+
+		if len(args) < 2 {
+			return fmt.Errorf("usage: update-ref <ref> <newvalue> [<oldvalue>]")
+		}
+		newHash, err := revision.Resolve(r, args[1])
+		if err != nil {
+			return err
+		}
+		oldHash := ""
+		if len(args) == 3 {
+			if oldHash, err = resolveOldValue(r, args[2]); err != nil {
+				return err
+			}
+		}
+
+		update := refs.Update
+		if updateRefNoDeref {
+			update = refs.UpdateNoDeref
+		}
+		if err := update(r, name, newHash, oldHash); err != nil {
+			return fmt.Errorf("failed to update ref: %v", err)
+		}
+		return nil
+	},
+}
+
+// resolveOldValue resolves the expected current value of a ref. The zero
+// hash is passed through unchanged since it names no object.
+func resolveOldValue(r *repo.Repo, rev string) (string, error) {
+	if rev == refs.ZeroHash {
+		return rev, nil
+	}
+	return revision.Resolve(r, rev)
+}
+
+func init() {
+	rootCmd.AddCommand(updateRefCmd)
+	updateRefCmd.Flags().BoolVarP(&updateRefDelete, "delete", "d", false, "Delete the ref")
+	updateRefCmd.Flags().BoolVar(&updateRefNoDeref, "no-deref", false, "Update the ref itself instead of the ref it points to")
+}
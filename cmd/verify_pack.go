@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/pack"
+	"github.com/spf13/cobra"
+)
+
+var verifyPackVerbose bool
+
+var verifyPackCmd = &cobra.Command{
+	Use:   "verify-pack [-v] <pack>...",
+	Short: "Check packfiles against their checksums",
+	Long: `gitloom verify-pack checks the checksum of each given .pack or .idx file and
+its counterpart, the CRC32 of every entry, and that every object hashes to its
+name. With -v it lists the objects as
+
+  <hash> <type> <size> <size-in-pack> <offset> [<depth> <base>]`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, path := range args {
+			path = strings.TrimSuffix(path, ".idx")
+			path = strings.TrimSuffix(path, ".pack") + ".pack"
+
+			p, err := pack.Open(path)
+			if err != nil {
+				return err
+			}
+			stats, err := p.Verify()
+			p.Close()
+			if err != nil {
+				return err
+			}
+
+			if !verifyPackVerbose {
+				continue
+			}
+			chains := make(map[int]int)
+			for _, st := range stats {
+				fmt.Printf("%s %-6s %d %d %d", st.Hash, st.Type, st.Size, st.PackedSize, st.Offset)
+				if st.Depth > 0 {
+					fmt.Printf(" %d %s", st.Depth, st.Base)
+					chains[st.Depth]++
+				}
+				fmt.Println()
+			}
+			fmt.Printf("non delta: %d objects\n", len(stats)-sumCounts(chains))
+			for depth := 1; len(chains) > 0; depth++ {
+				if n, ok := chains[depth]; ok {
+					fmt.Printf("chain length = %d: %d objects\n", depth, n)
+					delete(chains, depth)
+				}
+			}
+			fmt.Printf("%s: ok\n", path)
+		}
+		return nil
+	},
+}
+
+func sumCounts(counts map[int]int) int {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
+
+func init() {
+	rootCmd.AddCommand(verifyPackCmd)
+	verifyPackCmd.Flags().BoolVarP(&verifyPackVerbose, "verbose", "v", false, "List the objects in each pack")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/MahendraDani/gitloom.git/internal/index"
 	"github.com/MahendraDani/gitloom.git/internal/repo"
 	"github.com/MahendraDani/gitloom.git/internal/tree"
 	"github.com/spf13/cobra"
@@ -11,7 +12,7 @@ import (
 
 var writeTreeCmd = &cobra.Command{
 	Use:   "write-tree",
-	Short: "Create a tree object from the current directory",
+	Short: "Create a tree object from the current index",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Get current working directory
 		dir, err := os.Getwd()
@@ -25,8 +26,14 @@ var writeTreeCmd = &cobra.Command{
 			return fmt.Errorf("not a gitloom repository: %v", err)
 		}
 
+		// Load the staged entries
+		idx, err := index.Read(r)
+		if err != nil {
+			return fmt.Errorf("failed to read index: %v", err)
+		}
+
 		// Write the tree object
-		hash, err := tree.WriteTree(dir, r)
+		hash, err := tree.WriteIndexTree(r, idx)
 		if err != nil {
 			return fmt.Errorf("failed to write tree: %v", err)
 		}
@@ -0,0 +1,22 @@
+package internal
+
+// Blob holds the contents of a single file.
+type Blob struct {
+	Data []byte
+}
+
+func NewBlob(data []byte) *Blob {
+	return &Blob{Data: data}
+}
+
+func ParseBlob(data []byte) *Blob {
+	return &Blob{Data: data}
+}
+
+func (b *Blob) Type() string {
+	return BlobType
+}
+
+func (b *Blob) Serialize() []byte {
+	return b.Data
+}
@@ -0,0 +1,294 @@
+// Package branch creates, lists, renames and deletes local branches and
+// records which branch each one tracks.
+package branch
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/config"
+	"github.com/MahendraDani/gitloom.git/internal/history"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/revision"
+)
+
+const (
+	// RemotesDir holds remote-tracking branches, e.g. refs/remotes/origin/main.
+	RemotesDir = "refs/remotes"
+
+	headsPrefix   = repo.HeadsDir + "/"
+	remotesPrefix = RemotesDir + "/"
+)
+
+// ErrNotMerged is returned when deleting a branch would lose commits.
+var ErrNotMerged = errors.New("branch is not fully merged")
+
+// Branch describes a local branch.
+type Branch struct {
+	Name    string // short name, e.g. "main"
+	Hash    string
+	Current bool // checked out in the working tree
+
+	// Upstream is the full name of the tracked ref, e.g.
+	// refs/remotes/origin/main, or empty when nothing is tracked.
+	Upstream string
+	// Gone is set when the tracked ref does not exist.
+	Gone          bool
+	Ahead, Behind int
+}
+
+// Ref returns the full ref name of a branch.
+func Ref(name string) string {
+	return headsPrefix + name
+}
+
+// ValidateName checks that name can be used for a new branch.
+func ValidateName(name string) error {
+	if name == "" || name == refs.Head || strings.HasPrefix(name, "-") {
+		return fmt.Errorf("'%s' is not a valid branch name", name)
+	}
+	if err := refs.ValidateName(Ref(name)); err != nil {
+		return fmt.Errorf("'%s' is not a valid branch name", name)
+	}
+	return nil
+}
+
+// Exists reports whether the branch name exists.
+func Exists(r *repo.Repo, name string) (bool, error) {
+	_, err := refs.Resolve(r, Ref(name))
+	if errors.Is(err, refs.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Current returns the short name of the checked out branch, or an empty
+// string when HEAD is detached.
+func Current(r *repo.Repo) (string, error) {
+	target, detached, err := refs.CurrentBranch(r)
+	if err != nil || detached {
+		return "", err
+	}
+	return strings.TrimPrefix(target, headsPrefix), nil
+}
+
+// List returns every local branch sorted by name, with tracking
+// information filled in.
+func List(r *repo.Repo) ([]Branch, error) {
+	current, err := Current(r)
+	if err != nil {
+		return nil, err
+	}
+	heads, err := refs.List(r, headsPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	branches := make([]Branch, 0, len(heads))
+	for _, ref := range heads {
+		b := Branch{
+			Name:    strings.TrimPrefix(ref.Name, headsPrefix),
+			Hash:    ref.Hash,
+			Current: strings.TrimPrefix(ref.Name, headsPrefix) == current,
+		}
+		if err := fillTracking(r, &b); err != nil {
+			return nil, err
+		}
+		branches = append(branches, b)
+	}
+	return branches, nil
+}
+
+// ListRemote returns the remote-tracking branches, e.g. origin/main.
+func ListRemote(r *repo.Repo) ([]refs.Ref, error) {
+	return refs.List(r, remotesPrefix)
+}
+
+func fillTracking(r *repo.Repo, b *Branch) error {
+	upstream, err := Upstream(r, b.Name)
+	if err != nil || upstream == "" {
+		return err
+	}
+	b.Upstream = upstream
+
+	hash, err := refs.Resolve(r, upstream)
+	if errors.Is(err, refs.ErrNotFound) {
+		b.Gone = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	b.Ahead, b.Behind, err = history.AheadBehind(r, b.Hash, hash)
+	return err
+}
+
+// Create makes branch name point at the commit startRev resolves to.
+// An existing branch is only moved when force is set, and never when it
+// is checked out. Starting from a remote-tracking branch makes the new
+// branch track it, as git does by default.
+func Create(r *repo.Repo, name, startRev string, force bool) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	hash, err := revision.ResolveCommit(r, startRev)
+	if err != nil {
+		return fmt.Errorf("not a valid object name: '%s'", startRev)
+	}
+
+	exists, err := Exists(r, name)
+	if err != nil {
+		return err
+	}
+	oldHash := refs.ZeroHash
+	if exists {
+		if !force {
+			return fmt.Errorf("a branch named '%s' already exists", name)
+		}
+		current, err := Current(r)
+		if err != nil {
+			return err
+		}
+		if current == name {
+			return fmt.Errorf("cannot force update the current branch")
+		}
+		oldHash = ""
+	}
+
+	if err := refs.UpdateNoDeref(r, Ref(name), hash, oldHash); err != nil {
+		return err
+	}
+
+	if _, err := refs.Resolve(r, remotesPrefix+startRev); err == nil {
+		return SetUpstream(r, name, startRev)
+	}
+	return nil
+}
+
+// Delete removes branch name. Unless force is set, the branch must be
+// merged into its upstream, or into HEAD when it tracks nothing. The
+// checked out branch cannot be deleted. Its tracking configuration goes
+// with it. The hash the branch pointed to is returned.
+func Delete(r *repo.Repo, name string, force bool) (string, error) {
+	hash, err := refs.Resolve(r, Ref(name))
+	if errors.Is(err, refs.ErrNotFound) {
+		return "", fmt.Errorf("branch '%s' not found", name)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	current, err := Current(r)
+	if err != nil {
+		return "", err
+	}
+	if current == name {
+		return "", fmt.Errorf("cannot delete branch '%s' checked out at '%s'", name, r.WorkTree())
+	}
+
+	if !force {
+		merged, err := isMerged(r, name, hash)
+		if err != nil {
+			return "", err
+		}
+		if !merged {
+			return "", fmt.Errorf("the branch '%s' is not fully merged: %w", name, ErrNotMerged)
+		}
+	}
+
+	if err := refs.Delete(r, Ref(name), hash); err != nil {
+		return "", err
+	}
+	return hash, r.EditConfig(func(f *config.File) error {
+		_, err := f.RemoveSection("branch." + name)
+		return err
+	})
+}
+
+// isMerged reports whether hash is contained in the upstream of branch
+// name, or in HEAD when it has none.
+func isMerged(r *repo.Repo, name, hash string) (bool, error) {
+	target := refs.Head
+	upstream, err := Upstream(r, name)
+	if err != nil {
+		return false, err
+	}
+	if upstream != "" {
+		target = upstream
+	}
+
+	into, err := refs.Resolve(r, target)
+	if errors.Is(err, refs.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return history.IsAncestor(r, hash, into)
+}
+
+// Rename moves branch oldName to newName together with its tracking
+// configuration, and keeps HEAD on it when it is checked out. An existing
+// newName is only replaced when force is set.
+func Rename(r *repo.Repo, oldName, newName string, force bool) error {
+	if err := ValidateName(newName); err != nil {
+		return err
+	}
+	current, err := Current(r)
+	if err != nil {
+		return err
+	}
+	hash, err := refs.Resolve(r, Ref(oldName))
+	if errors.Is(err, refs.ErrNotFound) {
+		// The current branch may be renamed before its first commit
+		if current != oldName {
+			return fmt.Errorf("no branch named '%s'", oldName)
+		}
+		return refs.SetSymbolic(r, refs.Head, Ref(newName))
+	}
+	if err != nil {
+		return err
+	}
+	if oldName == newName {
+		return nil
+	}
+
+	exists, err := Exists(r, newName)
+	if err != nil {
+		return err
+	}
+	if exists && !force {
+		return fmt.Errorf("a branch named '%s' already exists", newName)
+	}
+	if exists && current == newName {
+		return fmt.Errorf("cannot force update the current branch")
+	}
+
+	oldHash := refs.ZeroHash
+	if exists {
+		oldHash = ""
+	}
+	if err := refs.UpdateNoDeref(r, Ref(newName), hash, oldHash); err != nil {
+		return err
+	}
+	if current == oldName {
+		if err := refs.SetSymbolic(r, refs.Head, Ref(newName)); err != nil {
+			return err
+		}
+	}
+	if err := refs.Delete(r, Ref(oldName), hash); err != nil {
+		return err
+	}
+
+	return r.EditConfig(func(f *config.File) error {
+		if exists {
+			if _, err := f.RemoveSection("branch." + newName); err != nil {
+				return err
+			}
+		}
+		_, err := f.RenameSection("branch."+oldName, "branch."+newName)
+		return err
+	})
+}
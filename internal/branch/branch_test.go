@@ -0,0 +1,162 @@
+package branch_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/MahendraDani/gitloom.git/internal/branch"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/testrepo"
+)
+
+// setupRepo makes two commits on main and returns their hashes.
+func setupRepo(t *testing.T) (*repo.Repo, string, string) {
+	t.Helper()
+	r := testrepo.New(t)
+
+	var hashes []string
+	for _, content := range []string{"one\n", "two\n"} {
+		testrepo.WriteFile(t, r.WorkTree(), "file.txt", content)
+		hashes = append(hashes, testrepo.CommitAll(t, r, content))
+	}
+	return r, hashes[0], hashes[1]
+}
+
+func TestCreateAndList(t *testing.T) {
+	r, first, second := setupRepo(t)
+
+	if err := branch.Create(r, "topic", "HEAD~1", false); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if err := branch.Create(r, "topic", "HEAD", false); err == nil {
+		t.Fatalf("expected an error creating an existing branch")
+	}
+	if err := branch.Create(r, "main", "HEAD~1", true); err == nil {
+		t.Fatalf("expected an error force updating the current branch")
+	}
+	for _, name := range []string{"HEAD", "-x", "a..b", "has space"} {
+		if err := branch.Create(r, name, "HEAD", false); err == nil {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+
+	if err := branch.SetUpstream(r, "topic", "main"); err != nil {
+		t.Fatalf("SetUpstream returned error: %v", err)
+	}
+
+	branches, err := branch.List(r)
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if len(branches) != 2 {
+		t.Fatalf("expected two branches, got %+v", branches)
+	}
+	main, topic := branches[0], branches[1]
+	if main.Name != "main" || !main.Current || main.Hash != second || main.Upstream != "" {
+		t.Errorf("unexpected main branch %+v", main)
+	}
+	if topic.Name != "topic" || topic.Current || topic.Hash != first {
+		t.Errorf("unexpected topic branch %+v", topic)
+	}
+	if topic.Upstream != "refs/heads/main" || topic.Ahead != 0 || topic.Behind != 1 {
+		t.Errorf("unexpected tracking for topic: %+v", topic)
+	}
+
+	if err := branch.UnsetUpstream(r, "topic"); err != nil {
+		t.Fatalf("UnsetUpstream returned error: %v", err)
+	}
+	if upstream, err := branch.Upstream(r, "topic"); err != nil || upstream != "" {
+		t.Fatalf("expected no upstream, got %q (%v)", upstream, err)
+	}
+}
+
+func TestCreate_TracksRemoteBranch(t *testing.T) {
+	r, first, _ := setupRepo(t)
+
+	if err := refs.UpdateNoDeref(r, "refs/remotes/origin/main", first, ""); err != nil {
+		t.Fatalf("UpdateNoDeref returned error: %v", err)
+	}
+	if err := branch.Create(r, "local", "origin/main", false); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	cfg, err := r.Config()
+	if err != nil {
+		t.Fatalf("Config returned error: %v", err)
+	}
+	if remote, _ := cfg.Get("branch.local.remote"); remote != "origin" {
+		t.Errorf("expected branch.local.remote origin, got %q", remote)
+	}
+	if merge, _ := cfg.Get("branch.local.merge"); merge != "refs/heads/main" {
+		t.Errorf("expected branch.local.merge refs/heads/main, got %q", merge)
+	}
+	if upstream, err := branch.Upstream(r, "local"); err != nil || upstream != "refs/remotes/origin/main" {
+		t.Errorf("expected upstream refs/remotes/origin/main, got %q (%v)", upstream, err)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	r, first, second := setupRepo(t)
+
+	if err := branch.Create(r, "merged", first, false); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if _, err := branch.Delete(r, "main", false); err == nil {
+		t.Fatalf("expected an error deleting the current branch")
+	}
+
+	hash, err := branch.Delete(r, "merged", false)
+	if err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if hash != first {
+		t.Fatalf("expected Delete to return %s, got %s", first, hash)
+	}
+
+	// A branch ahead of HEAD is not merged
+	if err := refs.SetSymbolic(r, refs.Head, "refs/heads/old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := refs.UpdateNoDeref(r, "refs/heads/old", first, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := branch.Delete(r, "main", false); !errors.Is(err, branch.ErrNotMerged) {
+		t.Fatalf("expected ErrNotMerged, got %v", err)
+	}
+	if hash, err := branch.Delete(r, "main", true); err != nil || hash != second {
+		t.Fatalf("expected forced delete to succeed, got %s (%v)", hash, err)
+	}
+}
+
+func TestRename(t *testing.T) {
+	r, first, second := setupRepo(t)
+
+	if err := branch.Create(r, "topic", first, false); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if err := branch.SetUpstream(r, "main", "topic"); err != nil {
+		t.Fatalf("SetUpstream returned error: %v", err)
+	}
+
+	if err := branch.Rename(r, "main", "topic", false); err == nil {
+		t.Fatalf("expected an error renaming onto an existing branch")
+	}
+	if err := branch.Rename(r, "main", "trunk", false); err != nil {
+		t.Fatalf("Rename returned error: %v", err)
+	}
+
+	current, err := branch.Current(r)
+	if err != nil || current != "trunk" {
+		t.Fatalf("expected HEAD to follow the rename, got %q (%v)", current, err)
+	}
+	if hash, err := refs.Resolve(r, refs.Head); err != nil || hash != second {
+		t.Fatalf("expected HEAD at %s, got %s (%v)", second, hash, err)
+	}
+	if exists, _ := branch.Exists(r, "main"); exists {
+		t.Fatalf("expected main to be gone")
+	}
+	if upstream, err := branch.Upstream(r, "trunk"); err != nil || upstream != "refs/heads/topic" {
+		t.Fatalf("expected the tracking configuration to move, got %q (%v)", upstream, err)
+	}
+}
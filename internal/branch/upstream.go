@@ -0,0 +1,122 @@
+package branch
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/config"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// Upstream returns the full name of the ref branch name tracks, from
+// branch.<name>.remote and branch.<name>.merge, or an empty string when it
+// tracks nothing. A remote of "." tracks a local branch; otherwise the
+// merge ref is mapped through the remote's fetch refspecs, defaulting to
+// refs/remotes/<remote>/<branch>.
+func Upstream(r *repo.Repo, name string) (string, error) {
+	cfg, err := r.Config()
+	if err != nil {
+		return "", err
+	}
+	remote, hasRemote := cfg.Get("branch." + name + ".remote")
+	merge, hasMerge := cfg.Get("branch." + name + ".merge")
+	if !hasRemote || !hasMerge {
+		return "", nil
+	}
+	if remote == "." {
+		return merge, nil
+	}
+
+	for _, spec := range cfg.GetAll("remote." + remote + ".fetch") {
+		if dst, ok := MapRefspec(spec, merge); ok {
+			return dst, nil
+		}
+	}
+	return remotesPrefix + remote + "/" + strings.TrimPrefix(merge, headsPrefix), nil
+}
+
+// MapRefspec maps ref through a fetch refspec such as
+// "+refs/heads/*:refs/remotes/origin/*" and reports whether the refspec
+// matched it.
+func MapRefspec(spec, ref string) (string, bool) {
+	src, dst, ok := strings.Cut(strings.TrimPrefix(spec, "+"), ":")
+	if !ok {
+		return "", false
+	}
+	srcPrefix, srcSuffix, srcGlob := strings.Cut(src, "*")
+	if !srcGlob {
+		return dst, src == ref
+	}
+	if !strings.HasPrefix(ref, srcPrefix) || !strings.HasSuffix(ref, srcSuffix) || len(ref) < len(srcPrefix)+len(srcSuffix) {
+		return "", false
+	}
+	match := ref[len(srcPrefix) : len(ref)-len(srcSuffix)]
+	return strings.Replace(dst, "*", match, 1), true
+}
+
+// SetUpstream makes branch name track upstream, which names either a
+// remote-tracking branch such as "origin/main" or a local branch.
+func SetUpstream(r *repo.Repo, name, upstream string) error {
+	if exists, err := Exists(r, name); err != nil {
+		return err
+	} else if !exists {
+		return fmt.Errorf("branch '%s' does not exist", name)
+	}
+
+	remote, merge := "", ""
+	if _, err := refs.Resolve(r, remotesPrefix+upstream); err == nil {
+		var branch string
+		var found bool
+		remote, branch, found = strings.Cut(upstream, "/")
+		if !found {
+			return fmt.Errorf("cannot set up tracking for '%s'", upstream)
+		}
+		merge = headsPrefix + branch
+	} else if !errors.Is(err, refs.ErrNotFound) {
+		return err
+	} else if exists, err := Exists(r, upstream); err != nil {
+		return err
+	} else if exists {
+		remote, merge = ".", Ref(upstream)
+	} else {
+		return fmt.Errorf("the requested upstream branch '%s' does not exist", upstream)
+	}
+
+	return r.EditConfig(func(f *config.File) error {
+		if err := f.Set("branch."+name+".remote", remote); err != nil {
+			return err
+		}
+		return f.Set("branch."+name+".merge", merge)
+	})
+}
+
+// UnsetUpstream stops branch name from tracking anything.
+func UnsetUpstream(r *repo.Repo, name string) error {
+	upstream, err := Upstream(r, name)
+	if err != nil {
+		return err
+	}
+	if upstream == "" {
+		return fmt.Errorf("branch '%s' has no upstream information", name)
+	}
+	return r.EditConfig(func(f *config.File) error {
+		if _, err := f.Unset("branch." + name + ".remote"); err != nil {
+			return err
+		}
+		_, err := f.Unset("branch." + name + ".merge")
+		return err
+	})
+}
+
+// ShortName abbreviates a full ref name the way branch listings show it:
+// refs/heads/main becomes main and refs/remotes/origin/main origin/main.
+func ShortName(ref string) string {
+	for _, prefix := range []string{headsPrefix, remotesPrefix, "refs/tags/", "refs/"} {
+		if strings.HasPrefix(ref, prefix) {
+			return strings.TrimPrefix(ref, prefix)
+		}
+	}
+	return ref
+}
@@ -0,0 +1,352 @@
+// Package checkout moves the index and working tree from one commit's tree
+// to another's, and restores individual paths from the index or a tree.
+package checkout
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/ignore"
+	"github.com/MahendraDani/gitloom.git/internal/index"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/tree"
+	"github.com/MahendraDani/gitloom.git/internal/worktree"
+)
+
+// ConflictError lists the paths a checkout refused to overwrite.
+type ConflictError struct {
+	Modified  []string // tracked files with uncommitted changes
+	Untracked []string // untracked files standing where the target has a file
+
+	// Op names the command that moved the working tree in messages,
+	// "checkout" unless set.
+	Op string
+}
+
+func (e *ConflictError) Error() string {
+	op, action := "checkout", "switch branches"
+	if e.Op != "" {
+		op, action = e.Op, e.Op
+	}
+
+	var b strings.Builder
+	if len(e.Modified) > 0 {
+		fmt.Fprintf(&b, "your local changes to the following files would be overwritten by %s:\n", op)
+		for _, p := range e.Modified {
+			b.WriteString("\t" + p + "\n")
+		}
+		fmt.Fprintf(&b, "Please commit your changes before you %s.", action)
+	}
+	if len(e.Untracked) > 0 {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "the following untracked working tree files would be overwritten by %s:\n", op)
+		for _, p := range e.Untracked {
+			b.WriteString("\t" + p + "\n")
+		}
+		fmt.Fprintf(&b, "Please move or remove them before you %s.", action)
+	}
+	return b.String()
+}
+
+// Tree moves the index and working tree from tree from, the tree of the
+// commit currently checked out ("" for none), to tree to. Paths that are
+// the same in both trees are left alone, so uncommitted changes to them
+// carry over. Any other path must be unchanged in the index and working
+// tree, or a *ConflictError is returned and nothing is touched. With
+// force set, the index and working tree are reset to to and all local
+// changes, including unresolved merge conflicts, are discarded.
+func Tree(r *repo.Repo, from, to string, force bool) error {
+	fromFiles, err := tree.Flatten(r, from)
+	if err != nil {
+		return err
+	}
+	toFiles, err := tree.Flatten(r, to)
+	if err != nil {
+		return err
+	}
+	idx, err := index.Read(r)
+	if err != nil {
+		return err
+	}
+	if !force && len(idx.Unmerged()) > 0 {
+		return errors.New("you need to resolve your current index first")
+	}
+
+	paths := make(map[string]bool)
+	for p := range fromFiles {
+		paths[p] = true
+	}
+	for p := range toFiles {
+		paths[p] = true
+	}
+	if force {
+		for _, e := range idx.Entries {
+			paths[e.Path] = true
+		}
+	}
+
+	c := &checker{r: r, idx: idx, from: fromFiles, to: toFiles}
+	var writes, removes []string
+	for p := range paths {
+		f, inFrom := fromFiles[p]
+		t, inTo := toFiles[p]
+		e, inIndex := idx.Entry(p)
+
+		if !force {
+			if inFrom && inTo && sameEntry(f, t) {
+				continue
+			}
+			// The index already holds what the target wants
+			if (inTo && inIndex && entryMatches(e, t)) || (!inTo && !inIndex) {
+				continue
+			}
+			if err := c.check(p, f, inFrom, t, inTo, e, inIndex); err != nil {
+				return err
+			}
+		}
+
+		if inTo {
+			writes = append(writes, p)
+		} else {
+			removes = append(removes, p)
+		}
+	}
+
+	if len(c.conflict.Modified) > 0 || len(c.conflict.Untracked) > 0 {
+		sort.Strings(c.conflict.Modified)
+		sort.Strings(c.conflict.Untracked)
+		return &c.conflict
+	}
+
+	root := r.WorkTree()
+	// Deepest paths first, so emptied directories can be pruned on the way
+	sort.Sort(sort.Reverse(sort.StringSlice(removes)))
+	for _, p := range removes {
+		if err := removeFile(root, p); err != nil {
+			return err
+		}
+		idx.Remove(p)
+	}
+
+	sort.Strings(writes)
+	for _, p := range writes {
+		if err := writeFile(r, idx, p, toFiles[p]); err != nil {
+			return err
+		}
+	}
+	return idx.Write(r)
+}
+
+// checker collects the conflicts Tree finds.
+type checker struct {
+	r        *repo.Repo
+	idx      *index.Index
+	from, to map[string]internal.TreeEntry
+	matcher  *ignore.Matcher
+	conflict ConflictError
+}
+
+// check records a conflict if moving path p from f to t would lose a
+// staged change, an unstaged change or an untracked file.
+func (c *checker) check(p string, f internal.TreeEntry, inFrom bool, t internal.TreeEntry, inTo bool, e index.Entry, inIndex bool) error {
+	staged := inIndex != inFrom || (inIndex && !entryMatches(e, f))
+	if staged {
+		c.conflict.Modified = append(c.conflict.Modified, p)
+		return nil
+	}
+
+	if inIndex {
+		changed, err := c.changed(e)
+		if err != nil {
+			return err
+		}
+		if changed {
+			c.conflict.Modified = append(c.conflict.Modified, p)
+		}
+		return nil
+	}
+
+	// p is new: nothing untracked may stand in its way
+	blocked, err := c.blocked(p)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		c.conflict.Untracked = append(c.conflict.Untracked, p)
+	}
+	return nil
+}
+
+// changed reports whether the working tree copy of e differs from it in a
+// way that writing over it would lose. A missing file loses nothing.
+func (c *checker) changed(e index.Entry) (bool, error) {
+	full := filepath.Join(c.r.WorkTree(), filepath.FromSlash(e.Path))
+	fi, err := os.Lstat(full)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	mode, ok := worktree.Mode(fi)
+	if !ok || mode != fmt.Sprintf("%o", e.Mode) {
+		return true, nil
+	}
+	if e.StatMatches(fi) && !c.idx.IsRacy(e) {
+		return false, nil
+	}
+	hash, err := worktree.HashFile(c.r, full, fi, false)
+	if err != nil {
+		return false, err
+	}
+	return hash != e.Hash, nil
+}
+
+// blocked reports whether an untracked, unignored file occupies p or one
+// of its parent directories, or a directory at p holds one.
+func (c *checker) blocked(p string) (bool, error) {
+	if c.matcher == nil {
+		m, err := ignore.New(c.r)
+		if err != nil {
+			return false, err
+		}
+		c.matcher = m
+	}
+	root := c.r.WorkTree()
+
+	parts := strings.Split(p, "/")
+	for i := 1; i < len(parts); i++ {
+		dir := strings.Join(parts[:i], "/")
+		fi, err := os.Lstat(filepath.Join(root, filepath.FromSlash(dir)))
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if fi.IsDir() {
+			continue
+		}
+		return c.untracked(dir, false)
+	}
+
+	fi, err := os.Lstat(filepath.Join(root, filepath.FromSlash(p)))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !fi.IsDir() {
+		return c.untracked(p, false)
+	}
+
+	// The directory may only hold tracked files the checkout removes
+	blocked := false
+	err = worktree.Walk(root, p, c.matcher, func(rel string, d fs.DirEntry) error {
+		if d.IsDir() {
+			return nil
+		}
+		_, tracked := c.idx.Entry(rel)
+		_, removed := c.from[rel]
+		if _, kept := c.to[rel]; !tracked || !removed || kept {
+			blocked = true
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err == fs.SkipAll {
+		err = nil
+	}
+	return blocked, err
+}
+
+// untracked reports whether rel is neither in the index nor ignored.
+// Ignored files are expendable and may be overwritten.
+func (c *checker) untracked(rel string, isDir bool) (bool, error) {
+	if _, tracked := c.idx.Entry(rel); tracked {
+		return false, nil
+	}
+	ignored, _, err := c.matcher.Ignored(rel, isDir)
+	return !ignored, err
+}
+
+func sameEntry(a, b internal.TreeEntry) bool {
+	return a.Hash == b.Hash && a.Mode == b.Mode
+}
+
+func entryMatches(e index.Entry, t internal.TreeEntry) bool {
+	return e.Hash == t.Hash && fmt.Sprintf("%o", e.Mode) == t.Mode
+}
+
+// writeFile writes t to path p in the working tree and stages it.
+func writeFile(r *repo.Repo, idx *index.Index, p string, t internal.TreeEntry) error {
+	full := filepath.Join(r.WorkTree(), filepath.FromSlash(p))
+	mode, err := index.ParseMode(t.Mode)
+	if err != nil {
+		return fmt.Errorf("%s: %w", p, err)
+	}
+
+	if t.Mode == internal.ModeGitlink {
+		// Submodule contents live in another repository; only the
+		// directory is created
+		if err := os.MkdirAll(full, repo.DirPerm); err != nil {
+			return err
+		}
+		idx.Set(index.Entry{Mode: mode, Hash: t.Hash, Path: p})
+		return nil
+	}
+
+	// A directory may stand where a file now goes, e.g. when a tracked
+	// dir/ was replaced by a file in the target
+	if fi, err := os.Lstat(full); err == nil && fi.IsDir() {
+		if err := os.RemoveAll(full); err != nil {
+			return err
+		}
+	}
+	if err := worktree.WriteFile(r, full, t.Mode, t.Hash); err != nil {
+		return fmt.Errorf("failed to write %s: %w", p, err)
+	}
+	fi, err := os.Lstat(full)
+	if err != nil {
+		return err
+	}
+	e, err := index.NewEntry(p, t.Mode, t.Hash, fi)
+	if err != nil {
+		return err
+	}
+	idx.Set(e)
+	return nil
+}
+
+// removeFile deletes p from the working tree along with any parent
+// directories that become empty.
+func removeFile(root, p string) error {
+	full := filepath.Join(root, filepath.FromSlash(p))
+	if fi, err := os.Lstat(full); err == nil && fi.IsDir() {
+		// Something else already replaced the tracked file
+		return nil
+	}
+	if err := os.Remove(full); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for dir := filepath.Dir(full); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			// Not empty: nothing further up can be empty either
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package checkout_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/MahendraDani/gitloom.git/internal/branch"
+	"github.com/MahendraDani/gitloom.git/internal/checkout"
+	"github.com/MahendraDani/gitloom.git/internal/history"
+	"github.com/MahendraDani/gitloom.git/internal/index"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/status"
+	"github.com/MahendraDani/gitloom.git/internal/testrepo"
+)
+
+// setupRepo commits shared.txt, changed.txt and dir/old.txt on main, then
+// a topic commit that edits changed.txt, adds new.txt and removes dir, and
+// checks main out again.
+func setupRepo(t *testing.T) (*repo.Repo, string, string) {
+	t.Helper()
+	r := testrepo.New(t)
+	dir := r.WorkTree()
+	testrepo.WriteFile(t, dir, "shared.txt", "shared\n")
+	testrepo.WriteFile(t, dir, "changed.txt", "main\n")
+	testrepo.WriteFile(t, dir, "dir/old.txt", "old\n")
+	mainHash := testrepo.CommitAll(t, r, "main")
+
+	if _, err := checkout.Switch(r, "", checkout.Options{NewBranch: "topic"}); err != nil {
+		t.Fatalf("Switch -b returned error: %v", err)
+	}
+	if err := os.RemoveAll(filepath.Join(dir, "dir")); err != nil {
+		t.Fatal(err)
+	}
+	testrepo.WriteFile(t, dir, "changed.txt", "topic\n")
+	testrepo.WriteFile(t, dir, "new.txt", "new\n")
+	topicHash := testrepo.CommitAll(t, r, "topic")
+
+	if _, err := checkout.Switch(r, "main", checkout.Options{}); err != nil {
+		t.Fatalf("Switch returned error: %v", err)
+	}
+	return r, mainHash, topicHash
+}
+
+func assertClean(t *testing.T, r *repo.Repo) {
+	t.Helper()
+	s, err := status.Compute(r)
+	if err != nil {
+		t.Fatalf("Compute returned error: %v", err)
+	}
+	if !s.Clean() || len(s.Untracked) > 0 {
+		t.Fatalf("expected a clean tree, got %+v", s)
+	}
+}
+
+func TestSwitch_UpdatesWorkTreeAndIndex(t *testing.T) {
+	r, _, topic := setupRepo(t)
+	dir := r.WorkTree()
+
+	if got := testrepo.ReadFile(t, dir, "changed.txt"); got != "main\n" {
+		t.Fatalf("changed.txt on main = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected new.txt to be removed on main, got %v", err)
+	}
+	assertClean(t, r)
+
+	res, err := checkout.Switch(r, "topic", checkout.Options{})
+	if err != nil {
+		t.Fatalf("Switch returned error: %v", err)
+	}
+	if res.Branch != "topic" || res.Hash != topic || res.Already {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if got := testrepo.ReadFile(t, dir, "new.txt"); got != "new\n" {
+		t.Fatalf("new.txt = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "dir")); !os.IsNotExist(err) {
+		t.Fatalf("expected the emptied dir to be removed, got %v", err)
+	}
+	target, err := refs.ReadSymbolic(r, refs.Head)
+	if err != nil || target != "refs/heads/topic" {
+		t.Fatalf("HEAD = %q, %v", target, err)
+	}
+	assertClean(t, r)
+
+	res, err = checkout.Switch(r, "topic", checkout.Options{})
+	if err != nil || !res.Already {
+		t.Fatalf("expected to already be on topic, got %+v, %v", res, err)
+	}
+}
+
+func TestSwitch_Detach(t *testing.T) {
+	r, main, _ := setupRepo(t)
+
+	res, err := checkout.Switch(r, "topic~1", checkout.Options{})
+	if err != nil {
+		t.Fatalf("Switch returned error: %v", err)
+	}
+	if res.Branch != "" || res.Hash != main {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	_, detached, err := refs.CurrentBranch(r)
+	if err != nil || !detached {
+		t.Fatalf("expected a detached HEAD, got %v, %v", detached, err)
+	}
+
+	res, err = checkout.Switch(r, "main", checkout.Options{})
+	if err != nil {
+		t.Fatalf("Switch returned error: %v", err)
+	}
+	if res.PreviousDetached != main || res.Branch != "main" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+}
+
+func TestSwitch_NewBranchTracksRemoteBranch(t *testing.T) {
+	r, _, topic := setupRepo(t)
+	if err := refs.UpdateNoDeref(r, "refs/remotes/origin/topic", topic, ""); err != nil {
+		t.Fatalf("UpdateNoDeref returned error: %v", err)
+	}
+
+	res, err := checkout.Switch(r, "origin/topic", checkout.Options{NewBranch: "t2"})
+	if err != nil {
+		t.Fatalf("Switch -b returned error: %v", err)
+	}
+	if res.Branch != "t2" || res.Hash != topic || !res.Created {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if upstream, err := branch.Upstream(r, "t2"); err != nil || upstream != "refs/remotes/origin/topic" {
+		t.Fatalf("expected upstream refs/remotes/origin/topic, got %q (%v)", upstream, err)
+	}
+}
+
+func TestSwitch_KeepsUnrelatedLocalChanges(t *testing.T) {
+	r, _, _ := setupRepo(t)
+	dir := r.WorkTree()
+
+	testrepo.WriteFile(t, dir, "shared.txt", "edited\n")
+	if _, err := checkout.Switch(r, "topic", checkout.Options{}); err != nil {
+		t.Fatalf("Switch returned error: %v", err)
+	}
+	if got := testrepo.ReadFile(t, dir, "shared.txt"); got != "edited\n" {
+		t.Fatalf("local change was lost: %q", got)
+	}
+}
+
+func TestSwitch_RefusesToOverwrite(t *testing.T) {
+	r, _, _ := setupRepo(t)
+	dir := r.WorkTree()
+
+	testrepo.WriteFile(t, dir, "changed.txt", "edited\n")
+	testrepo.WriteFile(t, dir, "new.txt", "untracked\n")
+
+	_, err := checkout.Switch(r, "topic", checkout.Options{})
+	var conflict *checkout.ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected a ConflictError, got %v", err)
+	}
+	if !reflect.DeepEqual(conflict.Modified, []string{"changed.txt"}) || !reflect.DeepEqual(conflict.Untracked, []string{"new.txt"}) {
+		t.Fatalf("unexpected conflict %+v", conflict)
+	}
+	if got := testrepo.ReadFile(t, dir, "changed.txt"); got != "edited\n" {
+		t.Fatalf("refused checkout changed the working tree: %q", got)
+	}
+	if branch, _, _ := refs.CurrentBranch(r); branch != "refs/heads/main" {
+		t.Fatalf("refused checkout moved HEAD to %s", branch)
+	}
+
+	if _, err := checkout.Switch(r, "topic", checkout.Options{Force: true}); err != nil {
+		t.Fatalf("forced Switch returned error: %v", err)
+	}
+	if got := testrepo.ReadFile(t, dir, "changed.txt"); got != "topic\n" {
+		t.Fatalf("changed.txt = %q after a forced checkout", got)
+	}
+	assertClean(t, r)
+}
+
+func TestRestorePaths(t *testing.T) {
+	r, main, _ := setupRepo(t)
+	dir := r.WorkTree()
+
+	testrepo.WriteFile(t, dir, "changed.txt", "edited\n")
+	if err := checkout.RestorePaths(r, "", []string{filepath.Join(dir, "changed.txt")}); err != nil {
+		t.Fatalf("RestorePaths returned error: %v", err)
+	}
+	if got := testrepo.ReadFile(t, dir, "changed.txt"); got != "main\n" {
+		t.Fatalf("changed.txt = %q", got)
+	}
+	assertClean(t, r)
+
+	if _, err := checkout.Switch(r, "topic", checkout.Options{}); err != nil {
+		t.Fatalf("Switch returned error: %v", err)
+	}
+	if err := checkout.RestorePaths(r, "", []string{filepath.Join(dir, "missing")}); err == nil {
+		t.Fatalf("expected an error for an unknown path")
+	}
+
+	c, err := history.ReadCommit(r, main)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkout.RestorePaths(r, c.Tree, []string{filepath.Join(dir, "dir")}); err != nil {
+		t.Fatalf("RestorePaths returned error: %v", err)
+	}
+	if got := testrepo.ReadFile(t, dir, "dir/old.txt"); got != "old\n" {
+		t.Fatalf("dir/old.txt = %q", got)
+	}
+	idx, err := index.Read(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := idx.Entry("dir/old.txt"); !ok {
+		t.Fatalf("expected dir/old.txt to be staged")
+	}
+}
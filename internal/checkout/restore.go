@@ -0,0 +1,93 @@
+package checkout
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/index"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/tree"
+	"github.com/MahendraDani/gitloom.git/internal/worktree"
+)
+
+// RestorePaths overwrites the working tree copies of paths, which may be
+// files or directories, with their staged versions. When treeHash is not
+// empty the versions in that tree are used instead, and staged as well.
+// Paths are relative to the current directory. Every path must match
+// something, or nothing is restored.
+func RestorePaths(r *repo.Repo, treeHash string, paths []string) error {
+	idx, err := index.Read(r)
+	if err != nil {
+		return err
+	}
+
+	source := make(map[string]internal.TreeEntry)
+	if treeHash != "" {
+		if source, err = tree.Flatten(r, treeHash); err != nil {
+			return err
+		}
+	} else {
+		for _, e := range idx.Entries {
+			if e.Stage != 0 {
+				// Unmerged paths have no single version to restore
+				continue
+			}
+			source[e.Path] = internal.TreeEntry{Mode: fmt.Sprintf("%o", e.Mode), Name: e.Path, Hash: e.Hash}
+		}
+	}
+
+	root := r.WorkTree()
+	selected := make(map[string]bool)
+	for _, p := range paths {
+		rel, err := index.RelPath(root, p)
+		if err != nil {
+			return err
+		}
+		matched := false
+		for path := range source {
+			if rel == "" || path == rel || strings.HasPrefix(path, rel+"/") {
+				selected[path] = true
+				matched = true
+			}
+		}
+		if !matched {
+			return fmt.Errorf("pathspec '%s' did not match any file(s) known to gitloom", p)
+		}
+	}
+
+	sorted := make([]string, 0, len(selected))
+	for path := range selected {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	for _, path := range sorted {
+		e := source[path]
+		if e.Mode == internal.ModeGitlink {
+			continue
+		}
+		full := filepath.Join(root, filepath.FromSlash(path))
+		if err := worktree.WriteFile(r, full, e.Mode, e.Hash); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fi, err := os.Lstat(full)
+		if err != nil {
+			return err
+		}
+		// The file now matches its staged version, or becomes it
+		if treeHash == "" {
+			idx.RefreshStat(path, fi)
+		} else {
+			entry, err := index.NewEntry(path, e.Mode, e.Hash, fi)
+			if err != nil {
+				return err
+			}
+			idx.Set(entry)
+		}
+	}
+	return idx.Write(r)
+}
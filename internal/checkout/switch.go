@@ -0,0 +1,120 @@
+package checkout
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MahendraDani/gitloom.git/internal/branch"
+	"github.com/MahendraDani/gitloom.git/internal/history"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/revision"
+)
+
+// Options controls Switch.
+type Options struct {
+	// Force discards local changes instead of refusing to overwrite them.
+	Force bool
+	// Detach checks out the commit rev resolves to even when rev names a
+	// branch, leaving HEAD detached.
+	Detach bool
+	// NewBranch, when set, is created at rev and checked out.
+	NewBranch string
+}
+
+// Result describes what Switch did.
+type Result struct {
+	Branch  string // branch now checked out, empty when HEAD is detached
+	Hash    string // commit now checked out
+	Created bool   // Branch was created by this checkout
+	Already bool   // Branch was already checked out
+
+	// PreviousDetached is the commit HEAD was detached at before, if any.
+	PreviousDetached string
+}
+
+// Switch checks out rev: a branch name makes HEAD point to that branch,
+// anything else that resolves to a commit detaches HEAD there. The index
+// and working tree are updated with Tree.
+func Switch(r *repo.Repo, rev string, opts Options) (*Result, error) {
+	res := &Result{}
+
+	current, err := branch.Current(r)
+	if err != nil {
+		return nil, err
+	}
+	headHash, err := refs.Resolve(r, refs.Head)
+	if err != nil && !errors.Is(err, refs.ErrNotFound) {
+		return nil, err
+	}
+	if current == "" {
+		res.PreviousDetached = headHash
+	}
+
+	switch {
+	case opts.NewBranch != "":
+		if err := branch.ValidateName(opts.NewBranch); err != nil {
+			return nil, err
+		}
+		if exists, err := branch.Exists(r, opts.NewBranch); err != nil {
+			return nil, err
+		} else if exists {
+			return nil, fmt.Errorf("a branch named '%s' already exists", opts.NewBranch)
+		}
+		if rev == "" {
+			rev = refs.Head
+		}
+		res.Branch, res.Created = opts.NewBranch, true
+
+	case !opts.Detach:
+		exists, err := branch.Exists(r, rev)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			res.Branch = rev
+		}
+	}
+
+	res.Hash, err = revision.ResolveCommit(r, rev)
+	if err != nil {
+		if headHash == "" {
+			// Before the first commit only the name of the branch to be
+			// born can change
+			if res.Created && rev == refs.Head {
+				return res, refs.SetSymbolic(r, refs.Head, branch.Ref(res.Branch))
+			}
+			if res.Branch == "" && rev == current {
+				return &Result{Branch: current, Already: true}, nil
+			}
+		}
+		return nil, fmt.Errorf("'%s' did not match any branch or commit known to gitloom", rev)
+	}
+
+	from := ""
+	if headHash != "" {
+		if from, err = history.CommitTree(r, headHash); err != nil {
+			return nil, err
+		}
+	}
+	to, err := history.CommitTree(r, res.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if err := Tree(r, from, to, opts.Force); err != nil {
+		return nil, err
+	}
+
+	if res.Created {
+		// Create is given rev itself so that starting from a
+		// remote-tracking branch sets it as the upstream
+		if err := branch.Create(r, res.Branch, rev, false); err != nil {
+			return nil, err
+		}
+	}
+	if res.Branch == "" {
+		return res, refs.UpdateNoDeref(r, refs.Head, res.Hash, "")
+	}
+	res.Already = res.Branch == current && !res.Created
+	return res, refs.SetSymbolic(r, refs.Head, branch.Ref(res.Branch))
+}
@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Commit points to a tree snapshot, the commits that directly preceded it,
+// and records who made it and why.
+type Commit struct {
+	Tree      string
+	Parents   []string
+	Author    Signature
+	Committer Signature
+
+	// Extra holds the headers after the committer that gitloom does not
+	// interpret, such as encoding, mergetag and gpgsig, in their original
+	// order, so that commits made by git serialize to the same bytes.
+	Extra []ExtraHeader
+
+	Message string
+}
+
+// ExtraHeader is a commit header kept verbatim. Value may span several
+// lines, which are stored as continuation lines starting with a space.
+type ExtraHeader struct {
+	Key   string
+	Value string
+}
+
+func ParseCommit(data []byte) (*Commit, error) {
+	headers, message, err := splitHeaders(data)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Commit{Message: message}
+	extra := false // whether the last header was an extra one
+	for _, line := range headers {
+		if strings.HasPrefix(line, " ") {
+			// Continues a multi-line value such as a signature
+			if !extra {
+				return nil, fmt.Errorf("invalid commit header %q", line)
+			}
+			c.Extra[len(c.Extra)-1].Value += "\n" + line[1:]
+			continue
+		}
+		key, value, found := strings.Cut(line, " ")
+		if !found {
+			return nil, fmt.Errorf("invalid commit header %q", line)
+		}
+
+		extra = false
+		switch key {
+		case "tree":
+			c.Tree = value
+		case "parent":
+			c.Parents = append(c.Parents, value)
+		case "author":
+			if c.Author, err = ParseSignature(value); err != nil {
+				return nil, err
+			}
+		case "committer":
+			if c.Committer, err = ParseSignature(value); err != nil {
+				return nil, err
+			}
+		default:
+			c.Extra = append(c.Extra, ExtraHeader{Key: key, Value: value})
+			extra = true
+		}
+	}
+
+	if c.Tree == "" {
+		return nil, errors.New("invalid commit: missing tree")
+	}
+	return c, nil
+}
+
+func (c *Commit) Type() string {
+	return CommitType
+}
+
+func (c *Commit) Serialize() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "tree %s\n", c.Tree)
+	for _, p := range c.Parents {
+		fmt.Fprintf(&buf, "parent %s\n", p)
+	}
+	fmt.Fprintf(&buf, "author %s\n", c.Author)
+	fmt.Fprintf(&buf, "committer %s\n", c.Committer)
+	for _, h := range c.Extra {
+		fmt.Fprintf(&buf, "%s %s\n", h.Key, strings.ReplaceAll(h.Value, "\n", "\n "))
+	}
+	buf.WriteString("\n")
+	buf.WriteString(c.Message)
+	return buf.Bytes()
+}
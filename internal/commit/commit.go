@@ -0,0 +1,129 @@
+package commit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/object"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+const (
+	AuthorNameEnv     = "GITLOOM_AUTHOR_NAME"
+	AuthorEmailEnv    = "GITLOOM_AUTHOR_EMAIL"
+	CommitterNameEnv  = "GITLOOM_COMMITTER_NAME"
+	CommitterEmailEnv = "GITLOOM_COMMITTER_EMAIL"
+)
+
+// CommitTree writes a commit object pointing at treeHash with the given
+// parents and message, and returns the hash of the new commit.
+func CommitTree(r *repo.Repo, treeHash string, parents []string, message string) (string, error) {
+	if r == nil {
+		return "", errors.New("gitloom repository not found")
+	}
+
+	if err := expectType(r, treeHash, internal.TreeType); err != nil {
+		return "", err
+	}
+	for _, p := range parents {
+		if err := expectType(r, p, internal.CommitType); err != nil {
+			return "", err
+		}
+	}
+
+	if strings.TrimSpace(message) == "" {
+		return "", errors.New("empty commit message")
+	}
+	if !strings.HasSuffix(message, "\n") {
+		message += "\n"
+	}
+
+	now := time.Now()
+	author, err := Signature(r, "author", now)
+	if err != nil {
+		return "", err
+	}
+	committer, err := Signature(r, "committer", now)
+	if err != nil {
+		return "", err
+	}
+	c := &internal.Commit{
+		Tree:      treeHash,
+		Parents:   parents,
+		Author:    author,
+		Committer: committer,
+		Message:   message,
+	}
+
+	return object.HashRawObject(c.Serialize(), internal.CommitType, r, true)
+}
+
+func expectType(r *repo.Repo, hash string, objType string) error {
+	objectType, _, err := r.ReadRawObject(hash)
+	if err != nil {
+		return fmt.Errorf("not a valid object name %s: %w", hash, err)
+	}
+	if objectType != objType {
+		return fmt.Errorf("%s is a %s, not a %s", hash, objectType, objType)
+	}
+	return nil
+}
+
+// Signature returns the identity of the given role, "author" or
+// "committer", at when. Like git, it is taken from the role's environment
+// variables, then <role>.name and <role>.email, then user.name and
+// user.email, and finally from the current user and host. Values that
+// would break the signature line are rejected.
+func Signature(r *repo.Repo, role string, when time.Time) (internal.Signature, error) {
+	nameEnv, emailEnv := AuthorNameEnv, AuthorEmailEnv
+	if role == "committer" {
+		nameEnv, emailEnv = CommitterNameEnv, CommitterEmailEnv
+	}
+
+	cfg, err := r.Config()
+	if err != nil {
+		return internal.Signature{}, err
+	}
+	userName, err := r.UserName()
+	if err != nil {
+		return internal.Signature{}, err
+	}
+	userEmail, err := r.UserEmail()
+	if err != nil {
+		return internal.Signature{}, err
+	}
+
+	name := firstNonEmpty(os.Getenv(nameEnv), cfg.String(role+".name", ""), userName, os.Getenv("USER"), "unknown")
+	email := firstNonEmpty(os.Getenv(emailEnv), cfg.String(role+".email", ""), userEmail)
+	if email == "" {
+		host, err := os.Hostname()
+		if err != nil || host == "" {
+			host = "localhost"
+		}
+		email = name + "@" + host
+	}
+
+	// These characters delimit the identity in the header, so allowing them
+	// would produce a malformed or forged commit
+	if strings.ContainsAny(name, "<>\n\x00") {
+		return internal.Signature{}, fmt.Errorf("invalid %s name %q: must not contain '<', '>' or a newline", role, name)
+	}
+	if strings.ContainsAny(email, "<>\n\x00") {
+		return internal.Signature{}, fmt.Errorf("invalid %s email %q: must not contain '<', '>' or a newline", role, email)
+	}
+
+	return internal.Signature{Name: name, Email: email, When: when}, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,212 @@
+package commit_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/commit"
+	"github.com/MahendraDani/gitloom.git/internal/config"
+	"github.com/MahendraDani/gitloom.git/internal/index"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/testrepo"
+	"github.com/MahendraDani/gitloom.git/internal/tree"
+)
+
+func setupRepoWithTree(t *testing.T) (*repo.Repo, string) {
+	t.Helper()
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
+
+	filePath := filepath.Join(tempDir, "hello.txt")
+	if err := os.WriteFile(filePath, []byte("hello world\n"), repo.FilePerm); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	treeHash, err := tree.WriteTree(tempDir, r)
+	if err != nil {
+		t.Fatalf("WriteTree returned error: %v", err)
+	}
+	return r, treeHash
+}
+
+func TestCommitTree_WithParent(t *testing.T) {
+	r, treeHash := setupRepoWithTree(t)
+	t.Setenv(commit.CommitterNameEnv, "John Roe")
+	t.Setenv(commit.CommitterEmailEnv, "john@example.com")
+
+	first, err := commit.CommitTree(r, treeHash, nil, "first")
+	if err != nil {
+		t.Fatalf("CommitTree returned error: %v", err)
+	}
+
+	second, err := commit.CommitTree(r, treeHash, []string{first}, "second")
+	if err != nil {
+		t.Fatalf("CommitTree returned error: %v", err)
+	}
+
+	obj, err := r.ReadObject(second)
+	if err != nil {
+		t.Fatalf("ReadObject returned error: %v", err)
+	}
+	c, ok := obj.(*internal.Commit)
+	if !ok {
+		t.Fatalf("expected *internal.Commit, got %T", obj)
+	}
+
+	if c.Tree != treeHash {
+		t.Errorf("expected tree %s, got %s", treeHash, c.Tree)
+	}
+	if len(c.Parents) != 1 || c.Parents[0] != first {
+		t.Errorf("expected parents [%s], got %v", first, c.Parents)
+	}
+	if c.Author.Name != "Jane Doe" || c.Author.Email != "jane@example.com" {
+		t.Errorf("unexpected author: %s", c.Author)
+	}
+	if c.Committer.Name != "John Roe" || c.Committer.Email != "john@example.com" {
+		t.Errorf("unexpected committer: %s", c.Committer)
+	}
+	if c.Message != "second\n" {
+		t.Errorf("expected message %q, got %q", "second\n", c.Message)
+	}
+}
+
+func TestCommitTree_RejectsNonTree(t *testing.T) {
+	r, treeHash := setupRepoWithTree(t)
+
+	first, err := commit.CommitTree(r, treeHash, nil, "first")
+	if err != nil {
+		t.Fatalf("CommitTree returned error: %v", err)
+	}
+
+	if _, err := commit.CommitTree(r, first, nil, "bad"); err == nil {
+		t.Fatalf("expected error when committing a commit as tree, got nil")
+	}
+
+	if _, err := commit.CommitTree(r, treeHash, []string{treeHash}, "bad"); err == nil {
+		t.Fatalf("expected error when using a tree as parent, got nil")
+	}
+}
+
+func TestCommit_AdvancesBranch(t *testing.T) {
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
+
+	filePath := filepath.Join(tempDir, "hello.txt")
+	if err := os.WriteFile(filePath, []byte("hello world\n"), repo.FilePerm); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	if err := index.Add(r, []string{filePath}); err != nil {
+		t.Fatalf("index.Add returned error: %v", err)
+	}
+
+	first, err := commit.Commit(r, "first")
+	if err != nil {
+		t.Fatalf("Commit returned error: %v", err)
+	}
+	if !first.Root || first.Branch != "refs/heads/main" {
+		t.Fatalf("unexpected first commit result: %+v", first)
+	}
+
+	branchHash, err := os.ReadFile(filepath.Join(r.Path, repo.HeadsDir, "main"))
+	if err != nil {
+		t.Fatalf("failed to read branch ref: %v", err)
+	}
+	if string(branchHash) != first.Hash+"\n" {
+		t.Fatalf("expected refs/heads/main to be %s, got %q", first.Hash, branchHash)
+	}
+
+	// Committing the same index again has nothing to record
+	if _, err := commit.Commit(r, "again"); err == nil {
+		t.Fatalf("expected error committing unchanged index, got nil")
+	}
+
+	if err := os.WriteFile(filePath, []byte("hello again\n"), repo.FilePerm); err != nil {
+		t.Fatalf("failed to update test file: %v", err)
+	}
+	if err := index.Add(r, []string{filePath}); err != nil {
+		t.Fatalf("index.Add returned error: %v", err)
+	}
+
+	second, err := commit.Commit(r, "second")
+	if err != nil {
+		t.Fatalf("Commit returned error: %v", err)
+	}
+	if second.Root {
+		t.Fatalf("expected second commit to have a parent")
+	}
+
+	obj, err := r.ReadObject(second.Hash)
+	if err != nil {
+		t.Fatalf("ReadObject returned error: %v", err)
+	}
+	c := obj.(*internal.Commit)
+	if len(c.Parents) != 1 || c.Parents[0] != first.Hash {
+		t.Fatalf("expected parent %s, got %v", first.Hash, c.Parents)
+	}
+}
+
+func TestCommit_EmptyIndex(t *testing.T) {
+	r := testrepo.New(t)
+
+	if _, err := commit.Commit(r, "empty"); err == nil {
+		t.Fatalf("expected error committing empty index, got nil")
+	}
+}
+
+func TestSignature_FromConfig(t *testing.T) {
+	r, _ := setupRepoWithTree(t)
+	t.Setenv(commit.AuthorNameEnv, "")
+	t.Setenv(commit.AuthorEmailEnv, "")
+	t.Setenv(commit.CommitterNameEnv, "Env Committer")
+	t.Setenv(commit.CommitterEmailEnv, "")
+
+	cfg, err := config.Load(r.ConfigPath())
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	cfg.Set("user.name", "Jane Doe")
+	cfg.Set("user.email", "jane@example.com")
+	cfg.Set("author.email", "author@example.com")
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	r.ReloadConfig()
+
+	when := time.Unix(1700000000, 0)
+	author, err := commit.Signature(r, "author", when)
+	if err != nil {
+		t.Fatalf("Signature returned error: %v", err)
+	}
+	if author.Name != "Jane Doe" || author.Email != "author@example.com" {
+		t.Errorf("unexpected author %s", author)
+	}
+
+	// Environment variables take precedence over the configuration
+	committer, err := commit.Signature(r, "committer", when)
+	if err != nil {
+		t.Fatalf("Signature returned error: %v", err)
+	}
+	if committer.Name != "Env Committer" || committer.Email != "jane@example.com" {
+		t.Errorf("unexpected committer %s", committer)
+	}
+}
+
+func TestSignature_RejectsMalformedIdentity(t *testing.T) {
+	r, _ := setupRepoWithTree(t)
+
+	for _, tc := range []struct{ name, email string }{
+		{"Jane <jane@example.com>", "jane@example.com"},
+		{"Jane\nparent 0000000000000000000000000000000000000000", "jane@example.com"},
+		{"Jane Doe", "jane@example.com> 0 +0000\nauthor Eve <eve@example.com"},
+		{"Jane Doe", "<jane@example.com"},
+	} {
+		t.Setenv(commit.AuthorNameEnv, tc.name)
+		t.Setenv(commit.AuthorEmailEnv, tc.email)
+		if _, err := commit.Signature(r, "author", time.Unix(1700000000, 0)); err == nil {
+			t.Errorf("expected error for name %q and email %q", tc.name, tc.email)
+		}
+	}
+}
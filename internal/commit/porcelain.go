@@ -0,0 +1,127 @@
+package commit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/index"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/tree"
+)
+
+// Result describes a commit created by Commit.
+type Result struct {
+	Hash   string
+	Branch string // ref updated by the commit, e.g. refs/heads/main
+	Root   bool   // true when the commit has no parent
+}
+
+// MergeMsgFile holds the message prepared for the commit that concludes
+// a merge stopped by conflicts.
+const MergeMsgFile = "MERGE_MSG"
+
+// Commit records the staged index as a new commit on top of HEAD and
+// advances the ref HEAD points to, creating it on the first commit. While
+// a merge is in progress the commit concludes it: MERGE_HEAD becomes its
+// second parent and the merge state is cleared.
+func Commit(r *repo.Repo, message string) (*Result, error) {
+	if r == nil {
+		return nil, errors.New("gitloom repository not found")
+	}
+
+	idx, err := index.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(idx.Unmerged()) > 0 {
+		return nil, errors.New("committing is not possible because you have unmerged files")
+	}
+
+	mergeHead, err := refs.Resolve(r, refs.MergeHead)
+	merging := err == nil
+	if err != nil && !errors.Is(err, refs.ErrNotFound) {
+		return nil, err
+	}
+
+	treeHash, err := tree.WriteIndexTree(r, idx)
+	if err != nil {
+		return nil, err
+	}
+
+	branch, err := refs.Deref(r, refs.Head)
+	if err != nil {
+		return nil, err
+	}
+
+	var parents []string
+	oldHash := refs.ZeroHash
+
+	parent, err := refs.Resolve(r, refs.Head)
+	switch {
+	case errors.Is(err, refs.ErrNotFound):
+		if len(idx.Entries) == 0 {
+			return nil, errors.New("nothing to commit")
+		}
+	case err != nil:
+		return nil, err
+	default:
+		obj, err := r.ReadObject(parent)
+		if err != nil {
+			return nil, err
+		}
+		parentCommit, ok := obj.(*internal.Commit)
+		if !ok {
+			return nil, errors.New("HEAD does not point to a commit")
+		}
+		if parentCommit.Tree == treeHash && !merging {
+			return nil, errors.New("nothing to commit")
+		}
+		parents = []string{parent}
+		oldHash = parent
+	}
+	if merging {
+		parents = append(parents, mergeHead)
+	}
+
+	hash, err := CommitTree(r, treeHash, parents, message)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only move the branch if nobody else moved it since we read HEAD
+	if err := refs.Update(r, refs.Head, hash, oldHash); err != nil {
+		return nil, err
+	}
+
+	if merging {
+		if err := ClearMerge(r); err != nil {
+			return nil, err
+		}
+	}
+	return &Result{Hash: hash, Branch: branch, Root: len(parents) == 0}, nil
+}
+
+// MergeMessage returns the message prepared for concluding a merge, or ""
+// when no merge is in progress.
+func MergeMessage(r *repo.Repo) (string, error) {
+	data, err := os.ReadFile(filepath.Join(r.Path, MergeMsgFile))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	return string(data), err
+}
+
+// ClearMerge forgets a merge in progress by removing MERGE_HEAD and
+// MERGE_MSG.
+func ClearMerge(r *repo.Repo) error {
+	if err := refs.Delete(r, refs.MergeHead, ""); err != nil && !errors.Is(err, refs.ErrNotFound) {
+		return err
+	}
+	if err := os.Remove(filepath.Join(r.Path, MergeMsgFile)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
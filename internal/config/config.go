@@ -0,0 +1,173 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// GlobalFile is the user-wide configuration, relative to the home directory.
+	GlobalFile = ".gitloomconfig"
+	// GlobalEnv overrides the location of the user-wide configuration.
+	GlobalEnv = "GITLOOM_CONFIG_GLOBAL"
+)
+
+// commandLine holds the values given with -c for this invocation.
+var commandLine = &File{}
+
+// Config is a stack of configuration files. Values in later files take
+// precedence over earlier ones.
+type Config struct {
+	Files []*File
+}
+
+// New layers files from lowest to highest precedence.
+func New(files ...*File) *Config {
+	return &Config{Files: files}
+}
+
+// GlobalPath returns the location of the user-wide configuration file.
+func GlobalPath() (string, error) {
+	if path := os.Getenv(GlobalEnv); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, GlobalFile), nil
+}
+
+// LoadGlobal reads the user-wide configuration file.
+func LoadGlobal() (*File, error) {
+	path, err := GlobalPath()
+	if err != nil {
+		return nil, err
+	}
+	return Load(path)
+}
+
+// SetCommandLine records "key=value" pairs that override every
+// configuration file for the rest of the process. A pair without "="
+// sets the key to true.
+func SetCommandLine(pairs []string) error {
+	f := &File{}
+	for _, pair := range pairs {
+		key, value, found := strings.Cut(pair, "=")
+		if !found {
+			value = "true"
+		}
+		if err := f.Set(key, value); err != nil {
+			return fmt.Errorf("bogus config parameter %q: %w", pair, err)
+		}
+	}
+	commandLine = f
+	return nil
+}
+
+// CommandLine returns the values given with SetCommandLine.
+func CommandLine() *File {
+	return commandLine
+}
+
+// Get returns the value of key from the file with the highest precedence
+// that sets it.
+func (c *Config) Get(key string) (string, bool) {
+	for i := len(c.Files) - 1; i >= 0; i-- {
+		if value, ok := c.Files[i].Get(key); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// GetAll returns every value of key across all files, lowest precedence first.
+func (c *Config) GetAll(key string) []string {
+	var values []string
+	for _, f := range c.Files {
+		values = append(values, f.GetAll(key)...)
+	}
+	return values
+}
+
+// Entries returns the variables of every file, lowest precedence first.
+func (c *Config) Entries() []Entry {
+	var entries []Entry
+	for _, f := range c.Files {
+		entries = append(entries, f.Entries()...)
+	}
+	return entries
+}
+
+// String returns the value of key, or def when it is not set.
+func (c *Config) String(key, def string) string {
+	if value, ok := c.Get(key); ok {
+		return value
+	}
+	return def
+}
+
+// Bool returns key as a boolean, or def when it is not set.
+func (c *Config) Bool(key string, def bool) (bool, error) {
+	value, ok := c.Get(key)
+	if !ok {
+		return def, nil
+	}
+	b, err := ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("bad boolean config value '%s' for '%s'", value, key)
+	}
+	return b, nil
+}
+
+// Int returns key as an integer, or def when it is not set.
+func (c *Config) Int(key string, def int64) (int64, error) {
+	value, ok := c.Get(key)
+	if !ok {
+		return def, nil
+	}
+	n, err := ParseInt(value)
+	if err != nil {
+		return 0, fmt.Errorf("bad numeric config value '%s' for '%s'", value, key)
+	}
+	return n, nil
+}
+
+// ParseBool accepts the spellings git does: true, yes, on and 1, or
+// false, no, off, 0 and the empty string, in any case.
+func ParseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", s)
+}
+
+// ParseInt parses a decimal integer with an optional k, m or g suffix
+// multiplying it by 1024, 1024² or 1024³.
+func ParseInt(s string) (int64, error) {
+	multiplier := int64(1)
+	if s != "" {
+		switch s[len(s)-1] {
+		case 'k', 'K':
+			multiplier = 1 << 10
+		case 'm', 'M':
+			multiplier = 1 << 20
+		case 'g', 'G':
+			multiplier = 1 << 30
+		}
+		if multiplier != 1 {
+			s = s[:len(s)-1]
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return n * multiplier, nil
+}
@@ -0,0 +1,173 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MahendraDani/gitloom.git/internal/config"
+)
+
+const sample = `# user settings
+[user]
+	name = Jane Doe ; trailing comment
+	email = "jane@example.com"
+[core]
+	bare
+	compression = 9
+[remote "Origin"]
+	url = /srv/repo.git
+	fetch = +refs/heads/*:refs/remotes/origin/*
+	fetch = +refs/tags/*:refs/tags/*
+[alias]
+	quoted = "a \"b\" \\ #c"
+	long = first \
+second
+`
+
+func TestParse(t *testing.T) {
+	f, err := config.Parse([]byte(sample))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	cases := map[string]string{
+		"user.name":         "Jane Doe",
+		"USER.Email":        "jane@example.com",
+		"core.bare":         "true",
+		"remote.Origin.url": "/srv/repo.git",
+		"alias.quoted":      `a "b" \ #c`,
+		"alias.long":        "first second",
+	}
+	for key, want := range cases {
+		got, ok := f.Get(key)
+		if !ok || got != want {
+			t.Errorf("Get(%q) = %q, %v; want %q", key, got, ok, want)
+		}
+	}
+
+	// Subsections are case sensitive
+	if _, ok := f.Get("remote.origin.url"); ok {
+		t.Errorf("expected remote.origin.url to be unset")
+	}
+	if fetch := f.GetAll("remote.Origin.fetch"); len(fetch) != 2 {
+		t.Errorf("expected two fetch values, got %q", fetch)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	invalid := []string{
+		"name = value\n",
+		"[core\n",
+		"[core]\n\t1name = x\n",
+		"[core]\n\tname = \"unterminated\n",
+		"[remote \"a\"] x = 1\n",
+	}
+	for _, data := range invalid {
+		if _, err := config.Parse([]byte(data)); err == nil {
+			t.Errorf("expected an error for %q", data)
+		}
+	}
+}
+
+func TestSetAndUnset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte("# keep me\n[user]\n\tname = Old\n\n[core]\n\tbare = false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	for key, value := range map[string]string{
+		"user.name":         "New Name",
+		"user.email":        "new@example.com",
+		"remote.origin.url": " padded; value ",
+	} {
+		if err := f.Set(key, value); err != nil {
+			t.Fatalf("Set(%q) returned error: %v", key, err)
+		}
+	}
+	if removed, err := f.Unset("core.bare"); err != nil || !removed {
+		t.Fatalf("Unset returned %v, %v", removed, err)
+	}
+	if err := f.Set("nosection", "x"); err == nil {
+		t.Fatalf("expected an error for a key without a section")
+	}
+	if err := f.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# keep me\n[user]\n\tname = New Name\n\temail = new@example.com\n\n[core]\n" +
+		"[remote \"origin\"]\n\turl = \" padded; value \"\n"
+	if string(data) != want {
+		t.Fatalf("unexpected file:\n got: %q\nwant: %q", data, want)
+	}
+
+	reloaded, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if got, _ := reloaded.Get("remote.origin.url"); got != " padded; value " {
+		t.Fatalf("value did not survive a round trip: %q", got)
+	}
+}
+
+func TestLayers(t *testing.T) {
+	global, _ := config.Parse([]byte("[user]\n\tname = Global\n\temail = g@example.com\n[core]\n\tcompression = 1k\n"))
+	local, _ := config.Parse([]byte("[user]\n\tname = Local\n[core]\n\tfilemode = off\n"))
+	if err := config.SetCommandLine([]string{"user.email=cli@example.com", "core.bare"}); err != nil {
+		t.Fatalf("SetCommandLine returned error: %v", err)
+	}
+	t.Cleanup(func() { config.SetCommandLine(nil) })
+
+	cfg := config.New(global, local, config.CommandLine())
+	if got := cfg.String("user.name", ""); got != "Local" {
+		t.Errorf("expected the local name, got %q", got)
+	}
+	if got := cfg.String("user.email", ""); got != "cli@example.com" {
+		t.Errorf("expected the command line email, got %q", got)
+	}
+	if b, err := cfg.Bool("core.bare", false); err != nil || !b {
+		t.Errorf("expected core.bare to be true, got %v, %v", b, err)
+	}
+	if b, err := cfg.Bool("core.filemode", true); err != nil || b {
+		t.Errorf("expected core.filemode to be false, got %v, %v", b, err)
+	}
+	if n, err := cfg.Int("core.compression", -1); err != nil || n != 1024 {
+		t.Errorf("expected core.compression to be 1024, got %v, %v", n, err)
+	}
+	if _, err := cfg.Bool("user.name", false); err == nil {
+		t.Errorf("expected an error reading a name as a boolean")
+	}
+	if err := config.SetCommandLine([]string{"=x"}); err == nil {
+		t.Errorf("expected an error for a parameter without a key")
+	}
+}
+
+func TestRenameAndRemoveSection(t *testing.T) {
+	f, err := config.Parse([]byte("[branch \"old\"]\n\tremote = origin\n\tmerge = refs/heads/old\n[core]\n\tbare = false\n"))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	if found, err := f.RenameSection("branch.old", "branch.new"); err != nil || !found {
+		t.Fatalf("RenameSection returned %v, %v", found, err)
+	}
+	if got, _ := f.Get("branch.new.merge"); got != "refs/heads/old" {
+		t.Fatalf("expected the variables to move, got %q", got)
+	}
+	if removed, err := f.RemoveSection("core"); err != nil || !removed {
+		t.Fatalf("RemoveSection returned %v, %v", removed, err)
+	}
+
+	want := "[branch \"new\"]\n\tremote = origin\n\tmerge = refs/heads/old\n"
+	if string(f.Bytes()) != want {
+		t.Fatalf("unexpected file:\n got: %q\nwant: %q", f.Bytes(), want)
+	}
+}
@@ -0,0 +1,538 @@
+// Package config reads and edits INI-style configuration files in the
+// format of git-config, and layers several of them into one view.
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Entry is one variable of a configuration file. Key is canonical: the
+// section and variable name are lower case, a subsection keeps its case,
+// e.g. "remote.Origin.url".
+type Entry struct {
+	Key   string
+	Value string
+}
+
+// File is a parsed configuration file. Edits keep comments, layout and
+// unrelated lines intact.
+type File struct {
+	Path  string // empty for values that do not come from a file
+	lines []line
+}
+
+type line struct {
+	text    string // as written, including continuation lines
+	section string // canonical section the line belongs to, e.g. "remote.Origin"
+	key     string // canonical key of a variable line, empty otherwise
+	value   string
+}
+
+// Load reads the file at path. A missing file is an empty configuration.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &File{Path: path}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	f, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	f.Path = path
+	return f, nil
+}
+
+// Parse decodes the contents of a configuration file.
+func Parse(data []byte) (*File, error) {
+	f := &File{}
+	section := ""
+	raw := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(data) == 0 {
+		raw = nil
+	}
+
+	for i := 0; i < len(raw); i++ {
+		lineNo := i + 1
+		text := strings.TrimSuffix(raw[i], "\r")
+		// A trailing backslash continues the value on the next line
+		for continued(text) && i+1 < len(raw) {
+			i++
+			text += "\n" + strings.TrimSuffix(raw[i], "\r")
+		}
+
+		trimmed := strings.TrimSpace(text)
+		switch {
+		case trimmed == "" || trimmed[0] == '#' || trimmed[0] == ';':
+			f.lines = append(f.lines, line{text: text, section: section})
+
+		case trimmed[0] == '[':
+			name, rest, err := parseHeader(trimmed)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			section = name
+			f.lines = append(f.lines, line{text: text, section: section})
+			// Only a comment may follow the header on the same line
+			if rest != "" && rest[0] != '#' && rest[0] != ';' {
+				return nil, fmt.Errorf("line %d: unexpected %q after section header", lineNo, rest)
+			}
+
+		default:
+			if section == "" {
+				return nil, fmt.Errorf("line %d: variable outside of a section", lineNo)
+			}
+			name, value, err := parseVariable(trimmed)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			f.lines = append(f.lines, line{text: text, section: section, key: section + "." + name, value: value})
+		}
+	}
+	return f, nil
+}
+
+// Get returns the last value of key.
+func (f *File) Get(key string) (string, bool) {
+	key, err := canonicalKey(key)
+	if err != nil {
+		return "", false
+	}
+	value, found := "", false
+	for _, l := range f.lines {
+		if l.key == key {
+			value, found = l.value, true
+		}
+	}
+	return value, found
+}
+
+// GetAll returns every value of key in file order.
+func (f *File) GetAll(key string) []string {
+	key, err := canonicalKey(key)
+	if err != nil {
+		return nil
+	}
+	var values []string
+	for _, l := range f.lines {
+		if l.key == key {
+			values = append(values, l.value)
+		}
+	}
+	return values
+}
+
+// Entries returns every variable in file order.
+func (f *File) Entries() []Entry {
+	var entries []Entry
+	for _, l := range f.lines {
+		if l.key != "" {
+			entries = append(entries, Entry{Key: l.key, Value: l.value})
+		}
+	}
+	return entries
+}
+
+// Set replaces the last value of key, or adds key to the end of its
+// section, creating the section if needed. Other values of a multi-valued
+// key are left alone.
+func (f *File) Set(key, value string) error {
+	section, name, err := splitKey(key)
+	if err != nil {
+		return err
+	}
+	canonical := section + "." + name
+	text := "\t" + name + " = " + quote(value)
+	l := line{text: text, section: section, key: canonical, value: value}
+
+	last := -1
+	for i := range f.lines {
+		if f.lines[i].key == canonical {
+			last = i
+		}
+	}
+	if last >= 0 {
+		f.lines[last] = l
+		return nil
+	}
+
+	end := -1
+	for i := range f.lines {
+		if f.lines[i].section == section && strings.TrimSpace(f.lines[i].text) != "" {
+			end = i
+		}
+	}
+	if end < 0 {
+		f.lines = append(f.lines, line{text: header(section), section: section}, l)
+		return nil
+	}
+	f.lines = append(f.lines, line{})
+	copy(f.lines[end+2:], f.lines[end+1:])
+	f.lines[end+1] = l
+	return nil
+}
+
+// Unset removes every value of key and reports whether there was one.
+func (f *File) Unset(key string) (bool, error) {
+	key, err := canonicalKey(key)
+	if err != nil {
+		return false, err
+	}
+	kept := f.lines[:0]
+	for _, l := range f.lines {
+		if l.key != key {
+			kept = append(kept, l)
+		}
+	}
+	removed := len(kept) != len(f.lines)
+	f.lines = kept
+	return removed, nil
+}
+
+// RenameSection moves every variable of section old, such as
+// "branch.topic", to section new and reports whether there were any.
+func (f *File) RenameSection(old, new string) (bool, error) {
+	old, err := canonicalSection(old)
+	if err != nil {
+		return false, err
+	}
+	new, err = canonicalSection(new)
+	if err != nil {
+		return false, err
+	}
+
+	found := false
+	for i, l := range f.lines {
+		if l.section != old {
+			continue
+		}
+		found = true
+		f.lines[i].section = new
+		if l.key != "" {
+			f.lines[i].key = new + strings.TrimPrefix(l.key, old)
+		} else if strings.HasPrefix(strings.TrimSpace(l.text), "[") {
+			f.lines[i].text = header(new)
+		}
+	}
+	return found, nil
+}
+
+// RemoveSection deletes section, its header and everything in it, and
+// reports whether it existed.
+func (f *File) RemoveSection(section string) (bool, error) {
+	section, err := canonicalSection(section)
+	if err != nil {
+		return false, err
+	}
+	kept := f.lines[:0]
+	for _, l := range f.lines {
+		if l.section != section {
+			kept = append(kept, l)
+		}
+	}
+	removed := len(kept) != len(f.lines)
+	f.lines = kept
+	return removed, nil
+}
+
+// Bytes encodes the file.
+func (f *File) Bytes() []byte {
+	var buf bytes.Buffer
+	for _, l := range f.lines {
+		buf.WriteString(l.text)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// Save writes the file back to f.Path through a lock file, so concurrent
+// writers fail instead of losing each other's changes.
+func (f *File) Save() error {
+	if f.Path == "" {
+		return fmt.Errorf("configuration has no file to save to")
+	}
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+		return err
+	}
+
+	lockPath := f.Path + ".lock"
+	lock, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("could not lock config file %s: %s exists", f.Path, lockPath)
+		}
+		return err
+	}
+	if _, err := lock.Write(f.Bytes()); err != nil {
+		lock.Close()
+		os.Remove(lockPath)
+		return err
+	}
+	if err := lock.Close(); err != nil {
+		os.Remove(lockPath)
+		return err
+	}
+	return os.Rename(lockPath, f.Path)
+}
+
+// continued reports whether text ends in an unescaped backslash.
+func continued(text string) bool {
+	n := 0
+	for i := len(text) - 1; i >= 0 && text[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// parseHeader parses `[section]`, `[section "subsection"]` or the legacy
+// `[section.subsection]`, returning the canonical section and whatever
+// follows the closing bracket.
+func parseHeader(text string) (string, string, error) {
+	end := strings.IndexByte(text, ']')
+	if quote := strings.IndexByte(text, '"'); quote >= 0 && quote < end {
+		// The subsection may itself contain ']'
+		end = -1
+		for i := quote + 1; i < len(text); i++ {
+			if text[i] == '\\' {
+				i++
+				continue
+			}
+			if text[i] == '"' {
+				end = strings.IndexByte(text[i:], ']')
+				if end >= 0 {
+					end += i
+				}
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return "", "", fmt.Errorf("unterminated section header %q", text)
+	}
+	inner, rest := text[1:end], strings.TrimSpace(text[end+1:])
+
+	name, sub, hasSub := strings.Cut(inner, " ")
+	if !hasSub {
+		if !validSection(name) {
+			return "", "", fmt.Errorf("invalid section name %q", name)
+		}
+		return strings.ToLower(name), rest, nil
+	}
+
+	if !validSection(name) || strings.Contains(name, ".") {
+		return "", "", fmt.Errorf("invalid section name %q", name)
+	}
+	sub = strings.TrimSpace(sub)
+	if len(sub) < 2 || sub[0] != '"' || sub[len(sub)-1] != '"' {
+		return "", "", fmt.Errorf("invalid subsection %s", sub)
+	}
+	var unquoted strings.Builder
+	for i := 1; i < len(sub)-1; i++ {
+		if sub[i] == '\\' && i+1 < len(sub)-1 {
+			i++
+		}
+		unquoted.WriteByte(sub[i])
+	}
+	return strings.ToLower(name) + "." + unquoted.String(), rest, nil
+}
+
+// parseVariable parses `name = value` or a bare `name`, which is true.
+func parseVariable(text string) (string, string, error) {
+	name, raw, hasValue := strings.Cut(text, "=")
+	name = strings.TrimSpace(name)
+	if !hasValue {
+		// A bare name may still be followed by a comment
+		if i := strings.IndexAny(name, "#;"); i >= 0 {
+			name = strings.TrimSpace(name[:i])
+		}
+	}
+	if !validName(name) {
+		return "", "", fmt.Errorf("invalid variable name %q", name)
+	}
+	if !hasValue {
+		return strings.ToLower(name), "true", nil
+	}
+
+	value, err := parseValue(raw)
+	if err != nil {
+		return "", "", err
+	}
+	return strings.ToLower(name), value, nil
+}
+
+// parseValue unquotes a raw value: surrounding whitespace is dropped, text
+// in double quotes is kept as is, backslash escapes are decoded and an
+// unquoted # or ; starts a comment.
+func parseValue(raw string) (string, error) {
+	var buf strings.Builder
+	inQuote := false
+	pendingSpace := ""
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == '\\':
+			if i+1 >= len(raw) {
+				return "", fmt.Errorf("value ends with a lone backslash")
+			}
+			i++
+			buf.WriteString(pendingSpace)
+			pendingSpace = ""
+			switch raw[i] {
+			case '\n':
+				// line continuation
+			case 'n':
+				buf.WriteByte('\n')
+			case 't':
+				buf.WriteByte('\t')
+			case 'b':
+				buf.WriteByte('\b')
+			case '\\', '"':
+				buf.WriteByte(raw[i])
+			default:
+				return "", fmt.Errorf("invalid escape \\%c in value", raw[i])
+			}
+		case c == '"':
+			buf.WriteString(pendingSpace)
+			pendingSpace = ""
+			inQuote = !inQuote
+		case inQuote:
+			buf.WriteByte(c)
+		case c == '#' || c == ';':
+			i = len(raw)
+		case c == ' ' || c == '\t':
+			if buf.Len() > 0 {
+				pendingSpace += string(c)
+			}
+		default:
+			buf.WriteString(pendingSpace)
+			pendingSpace = ""
+			buf.WriteByte(c)
+		}
+	}
+	if inQuote {
+		return "", fmt.Errorf("unterminated quote in value")
+	}
+	return buf.String(), nil
+}
+
+// quote encodes value so that parseValue returns it unchanged.
+func quote(value string) string {
+	needQuotes := value != strings.TrimSpace(value) || strings.ContainsAny(value, "#;")
+	var buf strings.Builder
+	if needQuotes {
+		buf.WriteByte('"')
+	}
+	for _, c := range value {
+		switch c {
+		case '\\', '"':
+			buf.WriteByte('\\')
+			buf.WriteRune(c)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\b':
+			buf.WriteString(`\b`)
+		default:
+			buf.WriteRune(c)
+		}
+	}
+	if needQuotes {
+		buf.WriteByte('"')
+	}
+	return buf.String()
+}
+
+// header returns the section header line for a canonical section.
+func header(section string) string {
+	name, sub, hasSub := strings.Cut(section, ".")
+	if !hasSub {
+		return "[" + name + "]"
+	}
+	sub = strings.ReplaceAll(sub, `\`, `\\`)
+	sub = strings.ReplaceAll(sub, `"`, `\"`)
+	return "[" + name + ` "` + sub + `"]`
+}
+
+// splitKey splits key into its canonical section (with any subsection)
+// and variable name.
+func splitKey(key string) (string, string, error) {
+	first, last := strings.IndexByte(key, '.'), strings.LastIndexByte(key, '.')
+	if first <= 0 || last == len(key)-1 {
+		return "", "", fmt.Errorf("key does not contain a section: %s", key)
+	}
+	section, name := key[:first], key[last+1:]
+	if !validSection(section) || strings.Contains(section, ".") {
+		return "", "", fmt.Errorf("invalid key: %s", key)
+	}
+	if !validName(name) {
+		return "", "", fmt.Errorf("invalid key: %s", key)
+	}
+	if first == last {
+		return strings.ToLower(section), strings.ToLower(name), nil
+	}
+	sub := key[first+1 : last]
+	if strings.ContainsAny(sub, "\n\x00") {
+		return "", "", fmt.Errorf("invalid key: %s", key)
+	}
+	return strings.ToLower(section) + "." + sub, strings.ToLower(name), nil
+}
+
+func canonicalKey(key string) (string, error) {
+	section, name, err := splitKey(key)
+	if err != nil {
+		return "", err
+	}
+	return section + "." + name, nil
+}
+
+// canonicalSection lower cases the section part of "section" or
+// "section.subsection".
+func canonicalSection(section string) (string, error) {
+	name, sub, hasSub := strings.Cut(section, ".")
+	if !validSection(name) {
+		return "", fmt.Errorf("invalid section name %q", section)
+	}
+	if !hasSub {
+		return strings.ToLower(name), nil
+	}
+	return strings.ToLower(name) + "." + sub, nil
+}
+
+func validSection(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if !isAlnum(c) && c != '-' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+func validName(name string) bool {
+	if name == "" || !isAlpha(rune(name[0])) {
+		return false
+	}
+	for _, c := range name {
+		if !isAlnum(c) && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlpha(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isAlnum(c rune) bool {
+	return isAlpha(c) || c >= '0' && c <= '9'
+}
@@ -1,6 +1,91 @@
 package internal
 
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+const (
+	BlobType   = "blob"
+	TreeType   = "tree"
+	CommitType = "commit"
+	TagType    = "tag"
+)
+
+// Object is implemented by every value that can be stored in the
+// gitloom object database. Serialize returns the object body without
+// the "<type> <size>\x00" header.
 type Object interface {
 	Type() string
 	Serialize() []byte
 }
+
+// Header returns the "<type> <size>\x00" prefix stored ahead of every object body.
+func Header(objType string, size int) []byte {
+	return []byte(fmt.Sprintf("%s %d\x00", objType, size))
+}
+
+// Hash returns the hex encoded SHA-1 of an object with the given type and body.
+func Hash(objType string, data []byte) string {
+	h := sha1.New()
+	h.Write(Header(objType, len(data)))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// HashStream returns the hex encoded SHA-1 of an object whose body of
+// exactly size bytes is read from body, without holding it in memory.
+func HashStream(objType string, size int64, body io.Reader) (string, error) {
+	h := sha1.New()
+	h.Write(Header(objType, int(size)))
+	n, err := io.Copy(h, body)
+	if err != nil {
+		return "", err
+	}
+	if n != size {
+		return "", fmt.Errorf("object body is %d bytes, expected %d", n, size)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashOf returns the hex encoded SHA-1 of a typed object.
+func HashOf(o Object) string {
+	return Hash(o.Type(), o.Serialize())
+}
+
+// IsHash reports whether s is a full 40 character lowercase hex object name.
+func IsHash(s string) bool {
+	return len(s) == 40 && IsHashPrefix(s)
+}
+
+// IsHashPrefix reports whether s could start an object name: it is made of
+// lowercase hex digits and no longer than a full name.
+func IsHashPrefix(s string) bool {
+	if len(s) > 40 {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseObject decodes an object body into the concrete type named by objType.
+func ParseObject(objType string, data []byte) (Object, error) {
+	switch objType {
+	case BlobType:
+		return ParseBlob(data), nil
+	case TreeType:
+		return ParseTree(data)
+	case CommitType:
+		return ParseCommit(data)
+	case TagType:
+		return ParseTag(data)
+	default:
+		return nil, fmt.Errorf("unknown object type %q", objType)
+	}
+}
@@ -0,0 +1,171 @@
+package internal_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+)
+
+func TestHash_Blob(t *testing.T) {
+	// Same value as `git hash-object` for "hello world\n"
+	expected := "3b18e512dba79e4c8300dd08aeb37f8e728b8dad"
+
+	hash := internal.HashOf(internal.NewBlob([]byte("hello world\n")))
+	if hash != expected {
+		t.Fatalf("unexpected blob hash:\n got: %s\nwant: %s", hash, expected)
+	}
+}
+
+func TestParseTree_RoundTrip(t *testing.T) {
+	tree, err := internal.NewTree([]internal.TreeEntry{
+		{Mode: "100644", Name: "hello.txt", Hash: "3b18e512dba79e4c8300dd08aeb37f8e728b8dad"},
+		{Mode: "40000", Name: "lib", Hash: "4b825dc642cb6eb9a060e54bf8d69288fbee4904"},
+	})
+	if err != nil {
+		t.Fatalf("NewTree returned error: %v", err)
+	}
+
+	parsed, err := internal.ParseTree(tree.Serialize())
+	if err != nil {
+		t.Fatalf("ParseTree returned error: %v", err)
+	}
+
+	if len(parsed.Entries) != len(tree.Entries) {
+		t.Fatalf("expected %d entries, got %d", len(tree.Entries), len(parsed.Entries))
+	}
+	for i, e := range tree.Entries {
+		if parsed.Entries[i] != e {
+			t.Errorf("entry %d mismatch:\n got: %+v\nwant: %+v", i, parsed.Entries[i], e)
+		}
+	}
+}
+
+func TestNewTree_RejectsInvalidEntries(t *testing.T) {
+	const hash = "3b18e512dba79e4c8300dd08aeb37f8e728b8dad"
+	for _, e := range []internal.TreeEntry{
+		{Mode: internal.ModeBlob, Name: "a.txt", Hash: "3b18e512"},
+		{Mode: internal.ModeBlob, Name: "a.txt", Hash: ""},
+		{Mode: internal.ModeBlob, Name: "", Hash: hash},
+		{Mode: internal.ModeBlob, Name: "dir/a.txt", Hash: hash},
+	} {
+		if _, err := internal.NewTree([]internal.TreeEntry{e}); err == nil {
+			t.Errorf("expected an error for entry %+v", e)
+		}
+	}
+}
+
+func TestSortTreeEntries_GitOrder(t *testing.T) {
+	entries := []internal.TreeEntry{
+		{Mode: internal.ModeTree, Name: "foo"},
+		{Mode: internal.ModeBlob, Name: "foo.txt"},
+		{Mode: internal.ModeBlob, Name: "bar"},
+		{Mode: internal.ModeBlob, Name: "foo-bar"},
+	}
+	internal.SortTreeEntries(entries)
+
+	// The directory sorts as "foo/", after "foo-bar" and "foo.txt"
+	want := []string{"bar", "foo-bar", "foo.txt", "foo"}
+	for i, e := range entries {
+		if e.Name != want[i] {
+			t.Fatalf("entry %d = %q, want %q", i, e.Name, want[i])
+		}
+	}
+}
+
+func TestParseCommit_RoundTrip(t *testing.T) {
+	when := time.Unix(1700000000, 0).In(time.FixedZone("", 5*3600+30*60))
+	sig := internal.Signature{Name: "Jane Doe", Email: "jane@example.com", When: when}
+
+	commit := &internal.Commit{
+		Tree:      "4b825dc642cb6eb9a060e54bf8d69288fbee4904",
+		Parents:   []string{"3b18e512dba79e4c8300dd08aeb37f8e728b8dad"},
+		Author:    sig,
+		Committer: sig,
+		Message:   "initial commit\n",
+	}
+
+	data := commit.Serialize()
+	expected := "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n" +
+		"parent 3b18e512dba79e4c8300dd08aeb37f8e728b8dad\n" +
+		"author Jane Doe <jane@example.com> 1700000000 +0530\n" +
+		"committer Jane Doe <jane@example.com> 1700000000 +0530\n" +
+		"\n" +
+		"initial commit\n"
+	if string(data) != expected {
+		t.Fatalf("unexpected commit body:\n got: %q\nwant: %q", data, expected)
+	}
+
+	parsed, err := internal.ParseCommit(data)
+	if err != nil {
+		t.Fatalf("ParseCommit returned error: %v", err)
+	}
+	if !bytes.Equal(parsed.Serialize(), data) {
+		t.Fatalf("commit did not round trip:\n got: %q\nwant: %q", parsed.Serialize(), data)
+	}
+	if !parsed.Author.When.Equal(when) {
+		t.Errorf("expected author time %v, got %v", when, parsed.Author.When)
+	}
+}
+
+func TestParseTag_RoundTrip(t *testing.T) {
+	tag := &internal.Tag{
+		Object:  "3b18e512dba79e4c8300dd08aeb37f8e728b8dad",
+		ObjType: internal.CommitType,
+		Name:    "v1.0",
+		Tagger:  internal.Signature{Name: "Jane Doe", Email: "jane@example.com", When: time.Unix(1700000000, 0).UTC()},
+		Message: "release\n",
+	}
+
+	parsed, err := internal.ParseTag(tag.Serialize())
+	if err != nil {
+		t.Fatalf("ParseTag returned error: %v", err)
+	}
+	if !bytes.Equal(parsed.Serialize(), tag.Serialize()) {
+		t.Fatalf("tag did not round trip:\n got: %q\nwant: %q", parsed.Serialize(), tag.Serialize())
+	}
+}
+
+func TestParseObject_UnknownType(t *testing.T) {
+	if _, err := internal.ParseObject("bogus", nil); err == nil {
+		t.Fatalf("expected error for unknown object type, got nil")
+	}
+}
+
+func TestParseCommit_KeepsExtraHeaders(t *testing.T) {
+	data := "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n" +
+		"author Jane Doe <jane@example.com> 1700000000 +0000\n" +
+		"committer Jane Doe <jane@example.com> 1700000000 +0000\n" +
+		"encoding ISO-8859-1\n" +
+		"gpgsig -----BEGIN PGP SIGNATURE-----\n" +
+		" \n" +
+		" iQEzBAABCAAdFiEE\n" +
+		" -----END PGP SIGNATURE-----\n" +
+		"\n" +
+		"signed commit\n"
+
+	parsed, err := internal.ParseCommit([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseCommit returned error: %v", err)
+	}
+	want := []internal.ExtraHeader{
+		{Key: "encoding", Value: "ISO-8859-1"},
+		{Key: "gpgsig", Value: "-----BEGIN PGP SIGNATURE-----\n\niQEzBAABCAAdFiEE\n-----END PGP SIGNATURE-----"},
+	}
+	if len(parsed.Extra) != len(want) {
+		t.Fatalf("expected %d extra headers, got %+v", len(want), parsed.Extra)
+	}
+	for i := range want {
+		if parsed.Extra[i] != want[i] {
+			t.Errorf("extra header %d = %+v, want %+v", i, parsed.Extra[i], want[i])
+		}
+	}
+	if string(parsed.Serialize()) != data {
+		t.Fatalf("commit did not round trip:\n got: %q\nwant: %q", parsed.Serialize(), data)
+	}
+
+	if _, err := internal.ParseCommit([]byte("tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n continued\n\nmsg\n")); err == nil {
+		t.Fatalf("expected an error for a continuation of a known header")
+	}
+}
@@ -0,0 +1,162 @@
+package diff
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/index"
+	"github.com/MahendraDani/gitloom.git/internal/object"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/tree"
+	"github.com/MahendraDani/gitloom.git/internal/worktree"
+)
+
+// Side is one version of a file.
+type Side struct {
+	Mode string // tree entry mode, e.g. 100644
+	Hash string // blob hash of the content
+
+	// File is the working tree file holding the content, for versions
+	// that are not stored as objects.
+	File string
+}
+
+// Content returns the file's data: a symlink's target, a submodule's
+// commit, or the blob the object store or working tree holds.
+func (s Side) Content(r *repo.Repo) ([]byte, error) {
+	switch {
+	case s.Mode == internal.ModeGitlink:
+		return []byte(fmt.Sprintf("Subproject commit %s\n", s.Hash)), nil
+	case s.File == "":
+		return object.ReadBlob(r, s.Hash)
+	case s.Mode == internal.ModeSymlink:
+		target, err := os.Readlink(s.File)
+		return []byte(filepath.ToSlash(target)), err
+	}
+	return os.ReadFile(s.File)
+}
+
+// Snapshot maps the slash separated path of every file in a tree, the
+// index or the working tree to its version.
+type Snapshot map[string]Side
+
+// TreeSnapshot returns the files of the tree treeHash. An empty hash
+// stands for the empty tree.
+func TreeSnapshot(r *repo.Repo, treeHash string) (Snapshot, error) {
+	s := make(Snapshot)
+	if treeHash == "" {
+		return s, nil
+	}
+	files, err := tree.Flatten(r, treeHash)
+	if err != nil {
+		return nil, err
+	}
+	for path, e := range files {
+		s[path] = Side{Mode: e.Mode, Hash: e.Hash}
+	}
+	return s, nil
+}
+
+// IndexSnapshot returns the staged files. Paths with merge conflicts are
+// left out.
+func IndexSnapshot(idx *index.Index) Snapshot {
+	s := make(Snapshot, len(idx.Entries))
+	for _, e := range idx.Entries {
+		if e.Stage != 0 {
+			continue
+		}
+		s[e.Path] = Side{Mode: fmt.Sprintf("%o", e.Mode), Hash: e.Hash}
+	}
+	return s
+}
+
+// WorkTreeSnapshot returns the working tree copies of the files in idx.
+// Untracked files are left out, as are tracked ones that were deleted and
+// ones with merge conflicts. Files whose stat data still matches the
+// index are not rehashed.
+func WorkTreeSnapshot(r *repo.Repo, idx *index.Index) (Snapshot, error) {
+	s := make(Snapshot, len(idx.Entries))
+	root := r.WorkTree()
+	for _, e := range idx.Entries {
+		if e.Stage != 0 {
+			continue
+		}
+		staged := fmt.Sprintf("%o", e.Mode)
+		if staged == internal.ModeGitlink {
+			// Submodules are compared by the commit recorded for them
+			s[e.Path] = Side{Mode: staged, Hash: e.Hash}
+			continue
+		}
+
+		full := filepath.Join(root, filepath.FromSlash(e.Path))
+		fi, err := os.Lstat(full)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		mode, ok := worktree.Mode(fi)
+		if !ok {
+			continue
+		}
+
+		hash := e.Hash
+		if mode != staged || !e.StatMatches(fi) || idx.IsRacy(e) {
+			if hash, err = worktree.HashFile(r, full, fi, false); err != nil {
+				return nil, err
+			}
+		}
+		s[e.Path] = Side{Mode: mode, Hash: hash, File: full}
+	}
+	return s, nil
+}
+
+// FileChange is a file that differs between two snapshots. Status is
+// 'A' (added), 'D' (deleted), 'M' (modified content or mode), 'T'
+// (changed between a file, a symlink and a submodule), or 'R' and 'C'
+// for a file renamed or copied from OldPath. Old is unset for added
+// files and New for deleted ones.
+type FileChange struct {
+	Path     string
+	Status   byte
+	Old, New Side
+
+	OldPath string // source of a rename or copy
+	Score   int    // similarity to OldPath in percent
+}
+
+// Compare lists the files that differ from old to new, sorted by path.
+func Compare(old, new Snapshot) []FileChange {
+	var changes []FileChange
+	for path, o := range old {
+		n, ok := new[path]
+		switch {
+		case !ok:
+			changes = append(changes, FileChange{Path: path, Status: 'D', Old: o})
+		case kind(o.Mode) != kind(n.Mode):
+			changes = append(changes, FileChange{Path: path, Status: 'T', Old: o, New: n})
+		case o.Hash != n.Hash || o.Mode != n.Mode:
+			changes = append(changes, FileChange{Path: path, Status: 'M', Old: o, New: n})
+		}
+	}
+	for path, n := range new {
+		if _, ok := old[path]; !ok {
+			changes = append(changes, FileChange{Path: path, Status: 'A', New: n})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// kind groups modes whose files can be compared line by line: regular
+// files, executable or not, are one kind.
+func kind(mode string) string {
+	if mode == internal.ModeExecutable {
+		return internal.ModeBlob
+	}
+	return mode
+}
@@ -0,0 +1,165 @@
+// Package diff compares files line by line and formats the differences
+// between snapshots of a repository as unified diffs, as git does.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Op is the kind of an Edit.
+type Op int
+
+const (
+	Equal Op = iota
+	Delete
+	Insert
+)
+
+// Edit is one line of an edit script. A indexes the old lines for Equal
+// and Delete, B the new lines for Equal and Insert.
+type Edit struct {
+	Op   Op
+	A, B int
+}
+
+// Algorithm selects how the lines two files share are matched up.
+type Algorithm int
+
+const (
+	// Myers finds a shortest edit script.
+	Myers Algorithm = iota
+	// Patience anchors on lines that occur once in each file, which
+	// keeps moved blocks and braces readable.
+	Patience
+	// Histogram extends patience to anchor on the least frequent lines.
+	Histogram
+)
+
+func (a Algorithm) String() string {
+	switch a {
+	case Patience:
+		return "patience"
+	case Histogram:
+		return "histogram"
+	}
+	return "myers"
+}
+
+// ParseAlgorithm returns the algorithm called name, as accepted by git's
+// --diff-algorithm and diff.algorithm. "default" and "minimal" select Myers.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	switch name {
+	case "myers", "default", "minimal":
+		return Myers, nil
+	case "patience":
+		return Patience, nil
+	case "histogram":
+		return Histogram, nil
+	}
+	return 0, fmt.Errorf("unknown diff algorithm %q: expected myers, patience or histogram", name)
+}
+
+// Lines splits data after each newline. Every line keeps its newline, so
+// a missing one at the end of the data makes the last line differ.
+func Lines(data []byte) []string {
+	var lines []string
+	for len(data) > 0 {
+		n := bytes.IndexByte(data, '\n') + 1
+		if n == 0 {
+			n = len(data)
+		}
+		lines = append(lines, string(data[:n]))
+		data = data[n:]
+	}
+	return lines
+}
+
+// IsBinary reports whether data looks like a binary file: like git, it
+// checks the first 8000 bytes for a NUL.
+func IsBinary(data []byte) bool {
+	const sniffLen = 8000
+	if len(data) > sniffLen {
+		data = data[:sniffLen]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// match pairs line a of the old file with the equal line b of the new one.
+type match struct{ a, b int }
+
+// Diff returns the edit script turning a into b.
+func Diff(a, b []string, alg Algorithm) []Edit {
+	var matches []match
+	switch alg {
+	case Patience:
+		matches = patience(a, b, 0, len(a), 0, len(b), nil)
+	case Histogram:
+		matches = histogram(a, b, 0, len(a), 0, len(b), nil)
+	default:
+		matches = myers(a, b, 0, len(a), 0, len(b), nil)
+	}
+
+	changedA := make([]bool, len(a))
+	changedB := make([]bool, len(b))
+	for i := range changedA {
+		changedA[i] = true
+	}
+	for j := range changedB {
+		changedB[j] = true
+	}
+	for _, m := range matches {
+		changedA[m.a] = false
+		changedB[m.b] = false
+	}
+	compact(a, changedA)
+	compact(b, changedB)
+	return editScript(changedA, changedB)
+}
+
+// compact slides every group of changed lines down past unchanged lines
+// equal to its first line, as git does, so that an ambiguous insertion
+// such as a repeated closing brace is shown after the lines it follows.
+// Groups that meet are merged. The equal lines stay paired in order, so
+// the result is still a valid diff.
+func compact(lines []string, changed []bool) {
+	for start := 0; start < len(lines); start++ {
+		if !changed[start] {
+			continue
+		}
+		end := start
+		for end < len(lines) && changed[end] {
+			end++
+		}
+		for end < len(lines) && lines[start] == lines[end] {
+			changed[start], changed[end] = false, true
+			start++
+			end++
+			for end < len(lines) && changed[end] {
+				end++
+			}
+		}
+		start = end
+	}
+}
+
+// editScript turns per-line change flags into an edit script, putting
+// deletions before insertions between two unchanged lines.
+func editScript(changedA, changedB []bool) []Edit {
+	var edits []Edit
+	i, j := 0, 0
+	for i < len(changedA) || j < len(changedB) {
+		for ; i < len(changedA) && changedA[i]; i++ {
+			edits = append(edits, Edit{Op: Delete, A: i, B: j})
+		}
+		for ; j < len(changedB) && changedB[j]; j++ {
+			edits = append(edits, Edit{Op: Insert, A: i, B: j})
+		}
+		if i < len(changedA) && j < len(changedB) {
+			edits = append(edits, Edit{Op: Equal, A: i, B: j})
+			i++
+			j++
+		}
+	}
+	return edits
+}
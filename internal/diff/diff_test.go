@@ -0,0 +1,317 @@
+package diff_test
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MahendraDani/gitloom.git/internal/diff"
+	"github.com/MahendraDani/gitloom.git/internal/index"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/testrepo"
+	"github.com/MahendraDani/gitloom.git/internal/tree"
+)
+
+// apply rebuilds the new lines from the old ones and an edit script.
+func apply(a, b []string, edits []diff.Edit) []string {
+	var out []string
+	for _, e := range edits {
+		switch e.Op {
+		case diff.Equal:
+			out = append(out, a[e.A])
+		case diff.Insert:
+			out = append(out, b[e.B])
+		}
+	}
+	return out
+}
+
+// lcsLength is the textbook dynamic programming solution, used to check
+// that Myers finds a shortest edit script.
+func lcsLength(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+	return dp[0][0]
+}
+
+func randomLines(rng *rand.Rand) []string {
+	lines := make([]string, rng.Intn(30))
+	for i := range lines {
+		lines[i] = string(rune('a'+rng.Intn(5))) + "\n"
+	}
+	return lines
+}
+
+func TestDiff_EditScripts(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for n := 0; n < 500; n++ {
+		a, b := randomLines(rng), randomLines(rng)
+		for _, alg := range []diff.Algorithm{diff.Myers, diff.Patience, diff.Histogram} {
+			edits := diff.Diff(a, b, alg)
+			if got := apply(a, b, edits); strings.Join(got, "") != strings.Join(b, "") {
+				t.Fatalf("%s script does not rebuild the new lines\na=%q\nb=%q", alg, a, b)
+			}
+			if alg != diff.Myers {
+				continue
+			}
+			equal := 0
+			for _, e := range edits {
+				if e.Op == diff.Equal {
+					equal++
+				}
+			}
+			if want := lcsLength(a, b); equal != want {
+				t.Fatalf("Myers matched %d lines, want %d\na=%q\nb=%q", equal, want, a, b)
+			}
+		}
+	}
+}
+
+func TestDiff_PatienceAnchorsOnUniqueLines(t *testing.T) {
+	a := diff.Lines([]byte("func a() {\n}\n\nfunc b() {\n}\n"))
+	b := diff.Lines([]byte("func b() {\n}\n\nfunc c() {\n}\n\nfunc a() {\n}\n"))
+
+	var out bytes.Buffer
+	if err := diff.WriteUnified(&out, a, b, diff.Diff(a, b, diff.Patience), 0); err != nil {
+		t.Fatal(err)
+	}
+	// "func b() {" is the unique line kept in place; func a moves
+	want := "@@ -1,3 +0,0 @@\n-func a() {\n-}\n-\n" +
+		"@@ -5,0 +3,6 @@\n+\n+func c() {\n+}\n+\n+func a() {\n+}\n"
+	if out.String() != want {
+		t.Fatalf("unexpected patience diff:\n%s", out.String())
+	}
+}
+
+func TestWriteUnified(t *testing.T) {
+	var oldText, newText strings.Builder
+	for i := 1; i <= 20; i++ {
+		line := strings.Repeat("x", i) + "\n"
+		oldText.WriteString(line)
+		if i == 3 || i == 17 {
+			line = "changed\n"
+		}
+		newText.WriteString(line)
+	}
+	newText.WriteString("tail")
+
+	a, b := diff.Lines([]byte(oldText.String())), diff.Lines([]byte(newText.String()))
+	var out bytes.Buffer
+	if err := diff.WriteUnified(&out, a, b, diff.Diff(a, b, diff.Myers), 2); err != nil {
+		t.Fatal(err)
+	}
+	want := "@@ -1,5 +1,5 @@\n x\n xx\n-xxx\n+changed\n xxxx\n xxxxx\n" +
+		"@@ -15,6 +15,7 @@\n " + strings.Repeat("x", 15) + "\n " + strings.Repeat("x", 16) + "\n-" + strings.Repeat("x", 17) +
+		"\n+changed\n " + strings.Repeat("x", 18) + "\n " + strings.Repeat("x", 19) + "\n " + strings.Repeat("x", 20) +
+		"\n+tail\n\\ No newline at end of file\n"
+	if out.String() != want {
+		t.Fatalf("unexpected unified diff:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestWritePatch_TreesAndWorkTree(t *testing.T) {
+	r := testrepo.New(t)
+	dir := r.WorkTree()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), repo.FilePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("keep.txt", "same\n")
+	write("edit.txt", "one\ntwo\n")
+	write("gone.txt", "bye\n")
+	write("data.bin", "a\x00b")
+	if err := index.Add(r, []string{dir}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	oldTree, err := tree.WriteTree(dir, r)
+	if err != nil {
+		t.Fatalf("WriteTree failed: %v", err)
+	}
+
+	write("edit.txt", "one\n2\n")
+	write("data.bin", "a\x00c")
+	write("new.txt", "hi\n")
+	if err := os.Remove(filepath.Join(dir, "gone.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := index.Add(r, []string{dir}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	newTree, err := tree.WriteTree(dir, r)
+	if err != nil {
+		t.Fatalf("WriteTree failed: %v", err)
+	}
+
+	old, err := diff.TreeSnapshot(r, oldTree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	new, err := diff.TreeSnapshot(r, newTree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := diff.Compare(old, new)
+
+	var names bytes.Buffer
+	if err := diff.WriteNameStatus(&names, changes); err != nil {
+		t.Fatal(err)
+	}
+	if want := "M\tdata.bin\nM\tedit.txt\nD\tgone.txt\nA\tnew.txt\n"; names.String() != want {
+		t.Fatalf("name-status = %q, want %q", names.String(), want)
+	}
+
+	var patch bytes.Buffer
+	if err := diff.WritePatch(&patch, r, changes, diff.Options{Context: diff.DefaultContext}); err != nil {
+		t.Fatalf("WritePatch failed: %v", err)
+	}
+	for _, want := range []string{
+		"Binary files a/data.bin and b/data.bin differ\n",
+		"--- a/edit.txt\n+++ b/edit.txt\n@@ -1,2 +1,2 @@\n one\n-two\n+2\n",
+		"deleted file mode 100644\nindex b023018..0000000\n--- a/gone.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-bye\n",
+		"new file mode 100644\n",
+		"--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1 @@\n+hi\n",
+	} {
+		if !strings.Contains(patch.String(), want) {
+			t.Errorf("patch is missing %q:\n%s", want, patch.String())
+		}
+	}
+
+	// The working tree side is read from disk without storing blobs
+	write("edit.txt", "one\nthree\n")
+	idx, err := index.Read(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := diff.WorkTreeSnapshot(r, idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := diff.Stats(r, diff.Compare(diff.IndexSnapshot(idx), wt), diff.Options{})
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	var stat bytes.Buffer
+	if err := diff.WriteStat(&stat, stats); err != nil {
+		t.Fatal(err)
+	}
+	if want := " edit.txt | 2 +-\n 1 file changed, 1 insertion(+), 1 deletion(-)\n"; stat.String() != want {
+		t.Fatalf("stat = %q, want %q", stat.String(), want)
+	}
+}
+
+func TestDiffTrees_RenamesAndCopies(t *testing.T) {
+	r := testrepo.New(t)
+	dir := r.WorkTree()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), repo.DirPerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), repo.FilePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lines := func(prefix string) string {
+		var b strings.Builder
+		for i := 0; i < 20; i++ {
+			fmt.Fprintf(&b, "%s %d\n", prefix, i)
+		}
+		return b.String()
+	}
+	snapshot := func() string {
+		t.Helper()
+		if err := index.Add(r, []string{dir}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		hash, err := tree.WriteTree(dir, r)
+		if err != nil {
+			t.Fatalf("WriteTree failed: %v", err)
+		}
+		return hash
+	}
+
+	write("src/a.txt", lines("moved"))
+	write("lib/keep.txt", lines("kept"))
+	write("README", "read me\n")
+	oldTree := snapshot()
+
+	if err := os.RemoveAll(filepath.Join(dir, "src")); err != nil {
+		t.Fatal(err)
+	}
+	write("lib/a.txt", lines("moved")+"one more\n")
+	write("copy.txt", lines("kept")+"extra\n")
+	newTree := snapshot()
+
+	nameStatus := func(changes []diff.FileChange) string {
+		t.Helper()
+		var b bytes.Buffer
+		if err := diff.WriteNameStatus(&b, changes); err != nil {
+			t.Fatal(err)
+		}
+		return b.String()
+	}
+
+	top, err := diff.DiffTrees(r, oldTree, newTree, false)
+	if err != nil {
+		t.Fatalf("DiffTrees failed: %v", err)
+	}
+	if got, want := nameStatus(top), "A\tcopy.txt\nM\tlib\nD\tsrc\n"; got != want {
+		t.Fatalf("non-recursive = %q, want %q", got, want)
+	}
+
+	changes, err := diff.DiffTrees(r, oldTree, newTree, true)
+	if err != nil {
+		t.Fatalf("DiffTrees failed: %v", err)
+	}
+	if got, want := nameStatus(changes), "A\tcopy.txt\nA\tlib/a.txt\nD\tsrc/a.txt\n"; got != want {
+		t.Fatalf("recursive = %q, want %q", got, want)
+	}
+
+	renamed, err := diff.FindRenames(r, changes, diff.RenameOptions{Threshold: diff.DefaultSimilarity})
+	if err != nil {
+		t.Fatalf("FindRenames failed: %v", err)
+	}
+	if got, want := nameStatus(renamed), "A\tcopy.txt\nR094\tsrc/a.txt\tlib/a.txt\n"; got != want {
+		t.Fatalf("renames = %q, want %q", got, want)
+	}
+
+	// Unchanged files are only copy sources when asked for
+	sources, err := diff.TreeSnapshot(r, oldTree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied, err := diff.FindRenames(r, changes, diff.RenameOptions{Threshold: diff.DefaultSimilarity, Copies: true, CopySources: sources})
+	if err != nil {
+		t.Fatalf("FindRenames failed: %v", err)
+	}
+	if got, want := nameStatus(copied), "C096\tlib/keep.txt\tcopy.txt\nR094\tsrc/a.txt\tlib/a.txt\n"; got != want {
+		t.Fatalf("copies = %q, want %q", got, want)
+	}
+
+	var raw bytes.Buffer
+	if err := diff.WriteRaw(&raw, top[:1]); err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf(":000000 100644 %s %s A\tcopy.txt\n", strings.Repeat("0", 40), top[0].New.Hash)
+	if raw.String() != want {
+		t.Fatalf("raw = %q, want %q", raw.String(), want)
+	}
+}
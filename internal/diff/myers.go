@@ -0,0 +1,132 @@
+package diff
+
+// myers appends the matches of a shortest edit script between a[aLo:aHi]
+// and b[bLo:bHi], using the linear space refinement of Eugene Myers' "An
+// O(ND) Difference Algorithm and Its Variations": the middle of the edit
+// path is found by searching from both ends at once, and each half is
+// diffed in turn.
+func myers(a, b []string, aLo, aHi, bLo, bHi int, matches []match) []match {
+	return matchEnds(a, b, aLo, aHi, bLo, bHi, matches, func(aLo, aHi, bLo, bHi int, matches []match) []match {
+		// A split at either end would not make progress
+		x, y, ok := bisect(a[aLo:aHi], b[bLo:bHi])
+		if !ok || (x == 0 && y == 0) || (x == aHi-aLo && y == bHi-bLo) {
+			return matches
+		}
+		matches = myers(a, b, aLo, aLo+x, bLo, bLo+y, matches)
+		return myers(a, b, aLo+x, aHi, bLo+y, bHi, matches)
+	})
+}
+
+// matchEnds matches the lines a[aLo:aHi] and b[bLo:bHi] have in common at
+// either end, and leaves whatever differs in between to middle when
+// neither side of it is empty.
+func matchEnds(a, b []string, aLo, aHi, bLo, bHi int, matches []match, middle func(aLo, aHi, bLo, bHi int, matches []match) []match) []match {
+	for aLo < aHi && bLo < bHi && a[aLo] == b[bLo] {
+		matches = append(matches, match{aLo, bLo})
+		aLo++
+		bLo++
+	}
+	var suffix []match
+	for aLo < aHi && bLo < bHi && a[aHi-1] == b[bHi-1] {
+		aHi--
+		bHi--
+		suffix = append(suffix, match{aHi, bHi})
+	}
+
+	if aLo < aHi && bLo < bHi {
+		matches = middle(aLo, aHi, bLo, bHi, matches)
+	}
+	for i := len(suffix) - 1; i >= 0; i-- {
+		matches = append(matches, suffix[i])
+	}
+	return matches
+}
+
+// bisect returns a point (x, y) on a shortest edit path from the start of
+// a and b to their ends, found where the forward and reverse searches
+// meet. It reports false when a and b have nothing in common.
+func bisect(a, b []string) (x, y int, ok bool) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	size := 2*maxD + 2
+	forward := make([]int, size)
+	reverse := make([]int, size)
+	for i := range forward {
+		forward[i] = -1
+		reverse[i] = -1
+	}
+	forward[offset+1] = 0
+	reverse[offset+1] = 0
+
+	// With an odd difference in length the paths meet during a forward
+	// step, otherwise during a reverse one
+	delta := n - m
+	checkForward := delta%2 != 0
+
+	// Diagonals that ran off the edges are not explored again
+	fStart, fEnd, rStart, rEnd := 0, 0, 0, 0
+
+	for d := 0; d < maxD; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			i := offset + k
+			var x1 int
+			if k == -d || (k != d && forward[i-1] < forward[i+1]) {
+				x1 = forward[i+1]
+			} else {
+				x1 = forward[i-1] + 1
+			}
+			y1 := x1 - k
+			for x1 < n && y1 < m && a[x1] == b[y1] {
+				x1++
+				y1++
+			}
+			forward[i] = x1
+
+			switch {
+			case x1 > n:
+				fEnd += 2
+			case y1 > m:
+				fStart += 2
+			case checkForward:
+				j := offset + delta - k
+				if j >= 0 && j < size && reverse[j] != -1 && x1 >= n-reverse[j] {
+					return x1, y1, true
+				}
+			}
+		}
+
+		for k := -d + rStart; k <= d-rEnd; k += 2 {
+			i := offset + k
+			var x2 int
+			if k == -d || (k != d && reverse[i-1] < reverse[i+1]) {
+				x2 = reverse[i+1]
+			} else {
+				x2 = reverse[i-1] + 1
+			}
+			y2 := x2 - k
+			for x2 < n && y2 < m && a[n-x2-1] == b[m-y2-1] {
+				x2++
+				y2++
+			}
+			reverse[i] = x2
+
+			switch {
+			case x2 > n:
+				rEnd += 2
+			case y2 > m:
+				rStart += 2
+			case !checkForward:
+				j := offset + delta - k
+				if j >= 0 && j < size && forward[j] != -1 {
+					x1 := forward[j]
+					y1 := x1 - (j - offset)
+					if x1 >= n-x2 {
+						return x1, y1, true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,282 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// statWidth is the terminal width --stat output is fitted to.
+const statWidth = 80
+
+// Options controls how file contents are compared.
+type Options struct {
+	Context   int // unchanged lines around each change, usually DefaultContext
+	Algorithm Algorithm
+}
+
+// WritePatch writes changes as a git style patch: a "diff --git" header
+// for every file, then its hunks, or a note that binary files differ.
+// A type change is shown as the old file's deletion and the new one's
+// addition.
+func WritePatch(w io.Writer, r *repo.Repo, changes []FileChange, opts Options) error {
+	for _, c := range changes {
+		parts := []FileChange{c}
+		if c.Status == 'T' {
+			parts = []FileChange{
+				{Path: c.Path, Status: 'D', Old: c.Old},
+				{Path: c.Path, Status: 'A', New: c.New},
+			}
+		}
+		for _, p := range parts {
+			if err := writeFilePatch(w, r, p, opts); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeFilePatch(w io.Writer, r *repo.Repo, c FileChange, opts Options) error {
+	oldPath := c.Path
+	if c.OldPath != "" {
+		oldPath = c.OldPath
+	}
+	var header strings.Builder
+	fmt.Fprintf(&header, "diff --git a/%s b/%s\n", oldPath, c.Path)
+	oldName, newName := "a/"+oldPath, "b/"+c.Path
+	switch c.Status {
+	case 'A':
+		oldName = "/dev/null"
+		fmt.Fprintf(&header, "new file mode %s\n", c.New.Mode)
+		fmt.Fprintf(&header, "index %s..%s\n", abbrev(""), abbrev(c.New.Hash))
+	case 'D':
+		newName = "/dev/null"
+		fmt.Fprintf(&header, "deleted file mode %s\n", c.Old.Mode)
+		fmt.Fprintf(&header, "index %s..%s\n", abbrev(c.Old.Hash), abbrev(""))
+	default:
+		if c.Status == 'R' || c.Status == 'C' {
+			verb := "rename"
+			if c.Status == 'C' {
+				verb = "copy"
+			}
+			fmt.Fprintf(&header, "similarity index %d%%\n", c.Score)
+			fmt.Fprintf(&header, "%s from %s\n%s to %s\n", verb, c.OldPath, verb, c.Path)
+		}
+		if c.Old.Mode != c.New.Mode {
+			fmt.Fprintf(&header, "old mode %s\nnew mode %s\n", c.Old.Mode, c.New.Mode)
+		}
+		if c.Old.Hash != c.New.Hash {
+			fmt.Fprintf(&header, "index %s..%s", abbrev(c.Old.Hash), abbrev(c.New.Hash))
+			if c.Old.Mode == c.New.Mode {
+				fmt.Fprintf(&header, " %s", c.New.Mode)
+			}
+			header.WriteString("\n")
+		}
+	}
+	if _, err := io.WriteString(w, header.String()); err != nil {
+		return err
+	}
+	if c.Status != 'A' && c.Status != 'D' && c.Old.Hash == c.New.Hash {
+		return nil
+	}
+
+	oldData, newData, err := contents(r, c)
+	if err != nil {
+		return err
+	}
+	if IsBinary(oldData) || IsBinary(newData) {
+		_, err := fmt.Fprintf(w, "Binary files %s and %s differ\n", oldName, newName)
+		return err
+	}
+
+	a, b := Lines(oldData), Lines(newData)
+	edits := Diff(a, b, opts.Algorithm)
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "--- %s\n+++ %s\n", oldName, newName); err != nil {
+		return err
+	}
+	return WriteUnified(w, a, b, edits, opts.Context)
+}
+
+// contents reads both versions of a changed file; a missing side is empty.
+func contents(r *repo.Repo, c FileChange) ([]byte, []byte, error) {
+	var oldData, newData []byte
+	var err error
+	if c.Status != 'A' {
+		if oldData, err = c.Old.Content(r); err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", c.Path, err)
+		}
+	}
+	if c.Status != 'D' {
+		if newData, err = c.New.Content(r); err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", c.Path, err)
+		}
+	}
+	return oldData, newData, nil
+}
+
+// abbrev shortens a hash for index lines; a missing side is all zeros.
+func abbrev(hash string) string {
+	if hash == "" {
+		return "0000000"
+	}
+	return hash[:7]
+}
+
+// FileStat counts the lines a change adds and removes. Binary files
+// record their sizes instead.
+type FileStat struct {
+	Path             string
+	Added, Deleted   int
+	Binary           bool
+	OldSize, NewSize int
+}
+
+// Stats counts the added and deleted lines of every change.
+func Stats(r *repo.Repo, changes []FileChange, opts Options) ([]FileStat, error) {
+	stats := make([]FileStat, 0, len(changes))
+	for _, c := range changes {
+		oldData, newData, err := contents(r, c)
+		if err != nil {
+			return nil, err
+		}
+		s := FileStat{Path: c.Path}
+		if IsBinary(oldData) || IsBinary(newData) {
+			s.Binary, s.OldSize, s.NewSize = true, len(oldData), len(newData)
+		} else {
+			for _, e := range Diff(Lines(oldData), Lines(newData), opts.Algorithm) {
+				switch e.Op {
+				case Insert:
+					s.Added++
+				case Delete:
+					s.Deleted++
+				}
+			}
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+// WriteStat writes a diffstat like git diff --stat: a histogram of the
+// changed lines per file followed by a summary.
+func WriteStat(w io.Writer, stats []FileStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	nameWidth, maxChanges, binary := 0, 0, false
+	for _, s := range stats {
+		nameWidth = max(nameWidth, len(s.Path))
+		maxChanges = max(maxChanges, s.Added+s.Deleted)
+		binary = binary || s.Binary
+	}
+	numberWidth := len(fmt.Sprint(maxChanges))
+	if binary {
+		numberWidth = max(numberWidth, len("Bin"))
+	}
+	graphWidth := max(statWidth-nameWidth-numberWidth-6, 10)
+
+	added, deleted := 0, 0
+	for _, s := range stats {
+		if s.Binary {
+			fmt.Fprintf(w, " %-*s | %-*s %d -> %d bytes\n", nameWidth, s.Path, numberWidth, "Bin", s.OldSize, s.NewSize)
+			continue
+		}
+		added += s.Added
+		deleted += s.Deleted
+
+		plus, minus := s.Added, s.Deleted
+		if maxChanges > graphWidth {
+			total := scale(s.Added+s.Deleted, maxChanges, graphWidth)
+			plus = scale(s.Added, maxChanges, graphWidth)
+			minus = max(total-plus, 0)
+		}
+		graph := strings.Repeat("+", plus) + strings.Repeat("-", minus)
+		line := fmt.Sprintf(" %-*s | %*d %s", nameWidth, s.Path, numberWidth, s.Added+s.Deleted, graph)
+		if _, err := fmt.Fprintln(w, strings.TrimRight(line, " ")); err != nil {
+			return err
+		}
+	}
+
+	summary := fmt.Sprintf(" %d %s changed", len(stats), plural(len(stats), "file", "files"))
+	if added > 0 || deleted == 0 {
+		summary += fmt.Sprintf(", %d %s(+)", added, plural(added, "insertion", "insertions"))
+	}
+	if deleted > 0 || added == 0 {
+		summary += fmt.Sprintf(", %d %s(-)", deleted, plural(deleted, "deletion", "deletions"))
+	}
+	_, err := fmt.Fprintln(w, summary)
+	return err
+}
+
+// scale fits n of at most total into width columns, keeping any change
+// visible.
+func scale(n, total, width int) int {
+	if n == 0 {
+		return 0
+	}
+	return 1 + n*(width-1)/total
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// WriteNameStatus writes one "<status>\t<path>" line per change. Renames
+// and copies show their score and both paths, e.g. "R086\told\tnew".
+func WriteNameStatus(w io.Writer, changes []FileChange) error {
+	for _, c := range changes {
+		if _, err := fmt.Fprintf(w, "%s\t%s\n", statusField(c), pathsField(c)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteRaw writes changes in git's raw format, one line per change:
+//
+//	:<old mode> <new mode> <old hash> <new hash> <status>\t<path>
+//
+// A missing side has mode 000000 and an all zero hash.
+func WriteRaw(w io.Writer, changes []FileChange) error {
+	for _, c := range changes {
+		_, err := fmt.Fprintf(w, ":%06s %06s %s %s %s\t%s\n",
+			orZero(c.Old.Mode, 1), orZero(c.New.Mode, 1),
+			orZero(c.Old.Hash, 40), orZero(c.New.Hash, 40),
+			statusField(c), pathsField(c))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func statusField(c FileChange) string {
+	if c.Status == 'R' || c.Status == 'C' {
+		return fmt.Sprintf("%c%03d", c.Status, c.Score)
+	}
+	return string(c.Status)
+}
+
+func pathsField(c FileChange) string {
+	if c.OldPath != "" {
+		return c.OldPath + "\t" + c.Path
+	}
+	return c.Path
+}
+
+func orZero(s string, width int) string {
+	if s == "" {
+		return strings.Repeat("0", width)
+	}
+	return s
+}
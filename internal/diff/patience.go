@@ -0,0 +1,131 @@
+package diff
+
+import "sort"
+
+// maxChain bounds how often a line may occur in the old file and still
+// anchor a histogram diff; more common lines are left to Myers.
+const maxChain = 64
+
+// patience appends the matches between a[aLo:aHi] and b[bLo:bHi] found by
+// anchoring on the longest increasing run of lines that occur exactly
+// once in each, and diffing the gaps between anchors the same way.
+// Regions without such lines fall back to Myers.
+func patience(a, b []string, aLo, aHi, bLo, bHi int, matches []match) []match {
+	return matchEnds(a, b, aLo, aHi, bLo, bHi, matches, func(aLo, aHi, bLo, bHi int, matches []match) []match {
+		anchors := uniqueAnchors(a, b, aLo, aHi, bLo, bHi)
+		if len(anchors) == 0 {
+			return myers(a, b, aLo, aHi, bLo, bHi, matches)
+		}
+
+		for _, anchor := range anchors {
+			matches = patience(a, b, aLo, anchor.a, bLo, anchor.b, matches)
+			matches = append(matches, anchor)
+			aLo, bLo = anchor.a+1, anchor.b+1
+		}
+		return patience(a, b, aLo, aHi, bLo, bHi, matches)
+	})
+}
+
+// uniqueAnchors returns the longest sequence of lines unique to both
+// ranges whose positions increase in a and b alike.
+func uniqueAnchors(a, b []string, aLo, aHi, bLo, bHi int) []match {
+	type occurrence struct{ countA, countB, posA, posB int }
+	seen := make(map[string]*occurrence)
+	for i := aLo; i < aHi; i++ {
+		o := seen[a[i]]
+		if o == nil {
+			o = &occurrence{}
+			seen[a[i]] = o
+		}
+		o.countA++
+		o.posA = i
+	}
+	for j := bLo; j < bHi; j++ {
+		if o := seen[b[j]]; o != nil {
+			o.countB++
+			o.posB = j
+		}
+	}
+
+	var unique []match
+	for _, o := range seen {
+		if o.countA == 1 && o.countB == 1 {
+			unique = append(unique, match{o.posA, o.posB})
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool { return unique[i].a < unique[j].a })
+
+	// Patience sorting: piles[i] ends the best run of length i+1, and
+	// prev links each entry to the one before it in its run
+	var piles []int
+	prev := make([]int, len(unique))
+	for i, u := range unique {
+		p := sort.Search(len(piles), func(k int) bool { return unique[piles[k]].b > u.b })
+		prev[i] = -1
+		if p > 0 {
+			prev[i] = piles[p-1]
+		}
+		if p == len(piles) {
+			piles = append(piles, i)
+		} else {
+			piles[p] = i
+		}
+	}
+	if len(piles) == 0 {
+		return nil
+	}
+
+	run := make([]match, len(piles))
+	for i, k := len(piles)-1, piles[len(piles)-1]; i >= 0; i, k = i-1, prev[k] {
+		run[i] = unique[k]
+	}
+	return run
+}
+
+// histogram appends the matches between a[aLo:aHi] and b[bLo:bHi] found by
+// anchoring on the longest common region around the line that occurs
+// least often in a, then diffing either side of it the same way.
+func histogram(a, b []string, aLo, aHi, bLo, bHi int, matches []match) []match {
+	return matchEnds(a, b, aLo, aHi, bLo, bHi, matches, func(aLo, aHi, bLo, bHi int, matches []match) []match {
+		positions := make(map[string][]int)
+		for i := aLo; i < aHi; i++ {
+			positions[a[i]] = append(positions[a[i]], i)
+		}
+
+		bestCount, bestLen := maxChain+1, 0
+		var bestA, bestB int
+		for j := bLo; j < bHi; j++ {
+			occurrences := positions[b[j]]
+			if len(occurrences) == 0 || len(occurrences) > bestCount {
+				continue
+			}
+			for _, i := range occurrences {
+				// Grow the region around the pair, tracking the rarest
+				// line in it
+				start, end := 0, 1
+				count := len(occurrences)
+				for i-start > aLo && j-start > bLo && a[i-start-1] == b[j-start-1] {
+					start++
+					count = min(count, len(positions[a[i-start]]))
+				}
+				for i+end < aHi && j+end < bHi && a[i+end] == b[j+end] {
+					count = min(count, len(positions[a[i+end]]))
+					end++
+				}
+				if length := start + end; count < bestCount || (count == bestCount && length > bestLen) {
+					bestCount, bestLen = count, length
+					bestA, bestB = i-start, j-start
+				}
+			}
+		}
+		if bestLen == 0 {
+			return myers(a, b, aLo, aHi, bLo, bHi, matches)
+		}
+
+		matches = histogram(a, b, aLo, bestA, bLo, bestB, matches)
+		for k := 0; k < bestLen; k++ {
+			matches = append(matches, match{bestA + k, bestB + k})
+		}
+		return histogram(a, b, bestA+bestLen, aHi, bestB+bestLen, bHi, matches)
+	})
+}
@@ -0,0 +1,243 @@
+package diff
+
+import (
+	"sort"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// DefaultSimilarity is the similarity in percent -M and -C ask for when
+// no score is given.
+const DefaultSimilarity = 50
+
+// renameLimit bounds how many source and destination pairs are compared
+// by content; beyond it only exact renames are found, as with git's
+// diff.renameLimit of 1000 files.
+const renameLimit = 1000 * 1000
+
+// RenameOptions controls FindRenames.
+type RenameOptions struct {
+	// Threshold is the similarity in percent a pair of files needs to be
+	// reported as a rename or copy.
+	Threshold int
+	// Copies also pairs added files with modified ones, which are then
+	// reported as copied.
+	Copies bool
+	// CopySources are further files, usually unchanged ones, that added
+	// files may have been copied from.
+	CopySources Snapshot
+}
+
+// FindRenames pairs the added files in changes with deleted files whose
+// content is similar enough, replacing each pair with one 'R' change.
+// Exact matches are found first; the rest are paired best score first.
+// With opts.Copies, added files left over are paired the same way with
+// modified, deleted and opts.CopySources files as 'C' changes, which
+// keep their source. The result is sorted by path.
+func FindRenames(r *repo.Repo, changes []FileChange, opts RenameOptions) ([]FileChange, error) {
+	var added, deleted []int
+	for i, c := range changes {
+		switch {
+		case c.Status == 'A' && renamable(c.New.Mode):
+			added = append(added, i)
+		case c.Status == 'D' && renamable(c.Old.Mode):
+			deleted = append(deleted, i)
+		}
+	}
+	if len(added) == 0 {
+		return changes, nil
+	}
+
+	s := &scorer{r: r, threshold: opts.Threshold, data: make(map[string][]byte)}
+	result := append([]FileChange(nil), changes...)
+	removed := make(map[int]bool)
+
+	sources := make([]source, len(deleted))
+	for k, i := range deleted {
+		sources[k] = source{path: changes[i].Path, side: changes[i].Old}
+	}
+	pairs, err := s.pair(changes, added, sources)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pairs {
+		dst := &result[added[p.dst]]
+		dst.Status, dst.OldPath, dst.Old, dst.Score = 'R', sources[p.src].path, sources[p.src].side, p.score
+		removed[deleted[p.src]] = true
+	}
+
+	if opts.Copies {
+		var remaining []int
+		for _, i := range added {
+			if result[i].Status == 'A' {
+				remaining = append(remaining, i)
+			}
+		}
+		copySources := sources
+		for _, c := range changes {
+			if c.Status == 'M' && renamable(c.Old.Mode) {
+				copySources = append(copySources, source{path: c.Path, side: c.Old})
+			}
+		}
+		paths := make([]string, 0, len(opts.CopySources))
+		for path := range opts.CopySources {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			if side := opts.CopySources[path]; renamable(side.Mode) {
+				copySources = append(copySources, source{path: path, side: side})
+			}
+		}
+
+		// A source may be copied any number of times
+		s.reuse = true
+		pairs, err := s.pair(changes, remaining, copySources)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range pairs {
+			dst := &result[remaining[p.dst]]
+			dst.Status, dst.OldPath, dst.Old, dst.Score = 'C', copySources[p.src].path, copySources[p.src].side, p.score
+		}
+	}
+
+	kept := result[:0]
+	for i, c := range result {
+		if !removed[i] {
+			kept = append(kept, c)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].Path < kept[j].Path })
+	return kept, nil
+}
+
+// renamable reports whether files with mode can be renamed or copied:
+// regular files and symlinks, but not submodules.
+func renamable(mode string) bool {
+	return mode == internal.ModeBlob || mode == internal.ModeExecutable || mode == internal.ModeSymlink
+}
+
+// source is a file an added one may have been renamed or copied from.
+type source struct {
+	path string
+	side Side
+}
+
+// pairing matches added file dst with source src.
+type pairing struct {
+	dst, src, score int
+}
+
+// scorer measures similarity, caching file contents.
+type scorer struct {
+	r         *repo.Repo
+	threshold int
+	reuse     bool // sources may be paired more than once
+	data      map[string][]byte
+}
+
+// pair matches each of the added changes to at most one source, exact
+// matches first and then by descending similarity.
+func (s *scorer) pair(changes []FileChange, added []int, sources []source) ([]pairing, error) {
+	var pairs []pairing
+	dstUsed := make(map[int]bool)
+	srcUsed := make(map[int]bool)
+
+	bySource := make(map[string][]int)
+	for k, src := range sources {
+		bySource[src.side.Hash] = append(bySource[src.side.Hash], k)
+	}
+	for d, i := range added {
+		for _, k := range bySource[changes[i].New.Hash] {
+			if !srcUsed[k] {
+				pairs = append(pairs, pairing{dst: d, src: k, score: 100})
+				dstUsed[d] = true
+				srcUsed[k] = !s.reuse
+				break
+			}
+		}
+	}
+
+	if len(added)*len(sources) > renameLimit {
+		return pairs, nil
+	}
+	var candidates []pairing
+	for d, i := range added {
+		if dstUsed[d] {
+			continue
+		}
+		for k, src := range sources {
+			if srcUsed[k] {
+				continue
+			}
+			score, err := s.similarity(src.side, changes[i].New)
+			if err != nil {
+				return nil, err
+			}
+			if score >= s.threshold {
+				candidates = append(candidates, pairing{dst: d, src: k, score: score})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(a, b int) bool { return candidates[a].score > candidates[b].score })
+	for _, c := range candidates {
+		if dstUsed[c.dst] || srcUsed[c.src] {
+			continue
+		}
+		pairs = append(pairs, c)
+		dstUsed[c.dst] = true
+		srcUsed[c.src] = !s.reuse
+	}
+	return pairs, nil
+}
+
+// similarity scores how much of the larger of two files is made of lines
+// both share, from 0 to 100. Binary files only match exactly, and pairs
+// whose sizes alone rule out the threshold are not diffed.
+func (s *scorer) similarity(a, b Side) (int, error) {
+	if a.Hash == b.Hash {
+		return 100, nil
+	}
+	if kind(a.Mode) != kind(b.Mode) {
+		return 0, nil
+	}
+	oldData, err := s.content(a)
+	if err != nil {
+		return 0, err
+	}
+	newData, err := s.content(b)
+	if err != nil {
+		return 0, err
+	}
+
+	larger := max(len(oldData), len(newData))
+	if larger == 0 || min(len(oldData), len(newData))*100/larger < s.threshold {
+		return 0, nil
+	}
+	if IsBinary(oldData) || IsBinary(newData) {
+		return 0, nil
+	}
+
+	oldLines, newLines := Lines(oldData), Lines(newData)
+	common := 0
+	for _, e := range Diff(oldLines, newLines, Myers) {
+		if e.Op == Equal {
+			common += len(oldLines[e.A])
+		}
+	}
+	return common * 100 / larger, nil
+}
+
+func (s *scorer) content(side Side) ([]byte, error) {
+	if data, ok := s.data[side.Hash]; ok {
+		return data, nil
+	}
+	data, err := side.Content(s.r)
+	if err != nil {
+		return nil, err
+	}
+	s.data[side.Hash] = data
+	return data, nil
+}
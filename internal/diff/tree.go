@@ -0,0 +1,115 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// DiffTrees compares the trees oldTree and newTree, either of which may be
+// empty to stand for the empty tree. Subtrees with the same hash on both
+// sides are skipped without being read. When recursive is set, changed
+// subtrees are descended into and only files are reported; otherwise a
+// changed subtree is reported as a single entry with mode 40000. A file
+// replaced by a directory of the same name, or the other way round, is
+// reported as a deletion and an addition. Changes come in tree order.
+func DiffTrees(r *repo.Repo, oldTree, newTree string, recursive bool) ([]FileChange, error) {
+	var changes []FileChange
+	err := diffTrees(r, oldTree, newTree, "", recursive, &changes)
+	return changes, err
+}
+
+func diffTrees(r *repo.Repo, oldTree, newTree, prefix string, recursive bool, changes *[]FileChange) error {
+	if oldTree == newTree {
+		return nil
+	}
+	oldEntries, err := readTreeEntries(r, oldTree)
+	if err != nil {
+		return err
+	}
+	newEntries, err := readTreeEntries(r, newTree)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(oldEntries)+len(newEntries))
+	for key := range oldEntries {
+		keys = append(keys, key)
+	}
+	for key := range newEntries {
+		if _, ok := oldEntries[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		o, inOld := oldEntries[key]
+		n, inNew := newEntries[key]
+		if inOld && inNew && o.Hash == n.Hash && o.Mode == n.Mode {
+			continue
+		}
+
+		name := o.Name
+		if !inOld {
+			name = n.Name
+		}
+		path := prefix + name
+
+		// Keys end in "/" for subtrees, so both sides are the same kind
+		isTree := (inOld && o.Mode == internal.ModeTree) || (inNew && n.Mode == internal.ModeTree)
+		if isTree && recursive {
+			if err := diffTrees(r, o.Hash, n.Hash, path+"/", recursive, changes); err != nil {
+				return err
+			}
+			continue
+		}
+
+		c := FileChange{Path: path}
+		if inOld {
+			c.Old = Side{Mode: o.Mode, Hash: o.Hash}
+		}
+		if inNew {
+			c.New = Side{Mode: n.Mode, Hash: n.Hash}
+		}
+		switch {
+		case !inNew:
+			c.Status = 'D'
+		case !inOld:
+			c.Status = 'A'
+		case kind(o.Mode) != kind(n.Mode):
+			c.Status = 'T'
+		default:
+			c.Status = 'M'
+		}
+		*changes = append(*changes, c)
+	}
+	return nil
+}
+
+// readTreeEntries returns the entries of treeHash keyed the way git sorts
+// them, with "/" after the names of subtrees.
+func readTreeEntries(r *repo.Repo, treeHash string) (map[string]internal.TreeEntry, error) {
+	entries := make(map[string]internal.TreeEntry)
+	if treeHash == "" {
+		return entries, nil
+	}
+	obj, err := r.ReadObject(treeHash)
+	if err != nil {
+		return nil, err
+	}
+	t, ok := obj.(*internal.Tree)
+	if !ok {
+		return nil, fmt.Errorf("object %s is a %s, not a tree", treeHash, obj.Type())
+	}
+	for _, e := range t.Entries {
+		key := e.Name
+		if e.Mode == internal.ModeTree {
+			key += "/"
+		}
+		entries[key] = e
+	}
+	return entries, nil
+}
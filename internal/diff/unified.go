@@ -0,0 +1,115 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around changes.
+const DefaultContext = 3
+
+// Hunk is a run of changes together with their context lines, as one
+// "@@ -a,n +b,m @@" block of a unified diff.
+type Hunk struct {
+	AStart, ALines int // 1-based first old line and number of old lines
+	BStart, BLines int
+	Edits          []Edit
+}
+
+// Hunks groups the changes of an edit script with context unchanged lines
+// on either side. Changes separated by no more than twice that many
+// unchanged lines share a hunk.
+func Hunks(edits []Edit, context int) []Hunk {
+	context = max(context, 0)
+
+	var hunks []Hunk
+	for i := 0; i < len(edits); {
+		if edits[i].Op == Equal {
+			i++
+			continue
+		}
+
+		// i is the first change of a new hunk; find its last change
+		start := max(i-context, 0)
+		end := i
+		for j := i; j < len(edits); j++ {
+			if edits[j].Op == Equal {
+				continue
+			}
+			if j-end-1 > 2*context {
+				break
+			}
+			end = j
+		}
+		stop := min(end+context+1, len(edits))
+		hunks = append(hunks, newHunk(edits[start:stop]))
+		i = stop
+	}
+	return hunks
+}
+
+func newHunk(edits []Edit) Hunk {
+	h := Hunk{AStart: edits[0].A + 1, BStart: edits[0].B + 1, Edits: edits}
+	for _, e := range edits {
+		if e.Op != Insert {
+			h.ALines++
+		}
+		if e.Op != Delete {
+			h.BLines++
+		}
+	}
+	// An empty range names the line before it, as in "@@ -0,0 +1 @@"
+	if h.ALines == 0 {
+		h.AStart--
+	}
+	if h.BLines == 0 {
+		h.BStart--
+	}
+	return h
+}
+
+// Header formats the hunk's "@@ -a,n +b,m @@" line without a newline.
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.AStart, h.ALines), hunkRange(h.BStart, h.BLines))
+}
+
+func hunkRange(start, lines int) string {
+	if lines == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
+
+// WriteUnified writes the hunks of the edit script from a to b.
+func WriteUnified(w io.Writer, a, b []string, edits []Edit, context int) error {
+	for _, h := range Hunks(edits, context) {
+		if _, err := fmt.Fprintln(w, h.Header()); err != nil {
+			return err
+		}
+		for _, e := range h.Edits {
+			var err error
+			switch e.Op {
+			case Equal:
+				err = writeLine(w, ' ', a[e.A])
+			case Delete:
+				err = writeLine(w, '-', a[e.A])
+			case Insert:
+				err = writeLine(w, '+', b[e.B])
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeLine(w io.Writer, prefix byte, line string) error {
+	if strings.HasSuffix(line, "\n") {
+		_, err := fmt.Fprintf(w, "%c%s", prefix, line)
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%c%s\n\\ No newline at end of file\n", prefix, line)
+	return err
+}
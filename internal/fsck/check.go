@@ -0,0 +1,216 @@
+package fsck
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+)
+
+// validModes are the tree entry modes git accepts.
+var validModes = map[string]bool{
+	"100644":             true,
+	"100755":             true,
+	"120000":             true,
+	internal.ModeTree:    true,
+	internal.ModeGitlink: true,
+}
+
+// link is a reference from one object to another of an expected type.
+type link struct {
+	hash    string
+	objType string // "" when any type is allowed, as for tag targets
+}
+
+// checkTree validates tree syntax and returns the entries it points to.
+func checkTree(data []byte) ([]link, []string) {
+	var links []link
+	var problems []string
+	var entries []internal.TreeEntry
+	names := make(map[string]bool)
+
+	for i := 0; i < len(data); {
+		sp := bytes.IndexByte(data[i:], ' ')
+		if sp < 0 {
+			return links, append(problems, "truncated entry: missing mode")
+		}
+		mode := string(data[i : i+sp])
+		i += sp + 1
+
+		nul := bytes.IndexByte(data[i:], 0)
+		if nul < 0 {
+			return links, append(problems, "truncated entry: missing name terminator")
+		}
+		name := string(data[i : i+nul])
+		i += nul + 1
+
+		if i+20 > len(data) {
+			return links, append(problems, fmt.Sprintf("truncated entry %q: incomplete hash", name))
+		}
+		hash := hex.EncodeToString(data[i : i+20])
+		i += 20
+
+		if !validModes[mode] {
+			problems = append(problems, fmt.Sprintf("entry %q has invalid mode %s", name, mode))
+		}
+		switch {
+		case name == "":
+			problems = append(problems, "entry with empty name")
+		case name == "." || name == ".." || strings.Contains(name, "/"):
+			problems = append(problems, fmt.Sprintf("entry has invalid name %q", name))
+		case names[name]:
+			problems = append(problems, fmt.Sprintf("duplicate entry %q", name))
+		}
+		names[name] = true
+
+		entries = append(entries, internal.TreeEntry{Mode: mode, Name: name, Hash: hash})
+		switch mode {
+		case internal.ModeTree:
+			links = append(links, link{hash, internal.TreeType})
+		case internal.ModeGitlink:
+			// Submodule commits live in another repository
+		default:
+			links = append(links, link{hash, internal.BlobType})
+		}
+	}
+
+	sorted := append([]internal.TreeEntry(nil), entries...)
+	internal.SortTreeEntries(sorted)
+	for i := range entries {
+		if entries[i].Name != sorted[i].Name {
+			problems = append(problems, "entries are not properly sorted")
+			break
+		}
+	}
+	return links, problems
+}
+
+// checkCommit validates that a commit has its headers in order: a tree,
+// any parents, then an author and committer.
+func checkCommit(data []byte) ([]link, []string) {
+	headers, problem := headerLines(data)
+	if problem != "" {
+		return nil, []string{problem}
+	}
+
+	var links []link
+	var problems []string
+	next := func(key string) (string, bool) {
+		if len(headers) == 0 || !strings.HasPrefix(headers[0], key+" ") {
+			return "", false
+		}
+		value := strings.TrimPrefix(headers[0], key+" ")
+		headers = headers[1:]
+		return value, true
+	}
+
+	tree, ok := next("tree")
+	if !ok {
+		return nil, []string{"missing tree header"}
+	}
+	if !internal.IsHash(tree) {
+		problems = append(problems, fmt.Sprintf("invalid tree hash %q", tree))
+	} else {
+		links = append(links, link{tree, internal.TreeType})
+	}
+
+	for {
+		parent, ok := next("parent")
+		if !ok {
+			break
+		}
+		if !internal.IsHash(parent) {
+			problems = append(problems, fmt.Sprintf("invalid parent hash %q", parent))
+			continue
+		}
+		links = append(links, link{parent, internal.CommitType})
+	}
+
+	for _, key := range []string{"author", "committer"} {
+		value, ok := next(key)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("missing %s header", key))
+			continue
+		}
+		if _, err := internal.ParseSignature(value); err != nil {
+			problems = append(problems, fmt.Sprintf("bad %s line: %v", key, err))
+		}
+	}
+	return links, problems
+}
+
+// checkTag validates the object, type, tag and optional tagger headers.
+func checkTag(data []byte) ([]link, []string) {
+	headers, problem := headerLines(data)
+	if problem != "" {
+		return nil, []string{problem}
+	}
+
+	values := make(map[string]string)
+	var order []string
+	for _, line := range headers {
+		key, value, _ := strings.Cut(line, " ")
+		if _, dup := values[key]; !dup {
+			order = append(order, key)
+		}
+		values[key] = value
+	}
+
+	var problems []string
+	want := []string{"object", "type", "tag"}
+	for i, key := range want {
+		if _, ok := values[key]; !ok {
+			problems = append(problems, fmt.Sprintf("missing %s header", key))
+		} else if i >= len(order) || order[i] != key {
+			problems = append(problems, fmt.Sprintf("%s header out of order", key))
+		}
+	}
+
+	var links []link
+	object, objType := values["object"], values["type"]
+	if object != "" && !internal.IsHash(object) {
+		problems = append(problems, fmt.Sprintf("invalid object hash %q", object))
+	}
+	switch objType {
+	case "", internal.BlobType, internal.TreeType, internal.CommitType, internal.TagType:
+	default:
+		problems = append(problems, fmt.Sprintf("invalid target type %q", objType))
+		objType = ""
+	}
+	if internal.IsHash(object) {
+		links = append(links, link{object, objType})
+	}
+	if name, ok := values["tag"]; ok && name == "" {
+		problems = append(problems, "empty tag name")
+	}
+	if tagger, ok := values["tagger"]; ok {
+		if _, err := internal.ParseSignature(tagger); err != nil {
+			problems = append(problems, fmt.Sprintf("bad tagger line: %v", err))
+		}
+	}
+	return links, problems
+}
+
+// headerLines returns the header lines of a commit or tag, dropping the
+// continuation lines of multi-line headers such as signatures.
+func headerLines(data []byte) ([]string, string) {
+	text := string(data)
+	head, _, found := strings.Cut(text, "\n\n")
+	if !found {
+		if !strings.HasSuffix(text, "\n") {
+			return nil, "missing blank line after headers"
+		}
+		head = strings.TrimSuffix(text, "\n")
+	}
+
+	var lines []string
+	for _, line := range strings.Split(head, "\n") {
+		if strings.HasPrefix(line, " ") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, ""
+}
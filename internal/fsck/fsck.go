@@ -0,0 +1,231 @@
+// Package fsck verifies the integrity of the object database: that every
+// object hashes to its name and is well formed, and that everything
+// reachable from the refs is present.
+package fsck
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/gc"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// Object names an object and its type.
+type Object struct {
+	Type string
+	Hash string
+}
+
+// Issue is a problem found in a single object, or in a pack when Hash is empty.
+type Issue struct {
+	Type    string
+	Hash    string
+	Message string
+}
+
+func (i Issue) String() string {
+	if i.Hash == "" {
+		return "error: " + i.Message
+	}
+	return fmt.Sprintf("error in %s %s: %s", i.Type, i.Hash, i.Message)
+}
+
+// BrokenLink is a reference from an existing object to a missing one.
+type BrokenLink struct {
+	From Object
+	To   Object
+}
+
+// Report collects everything Check finds. Missing, Dangling and
+// Unreachable are sorted by hash.
+type Report struct {
+	Checked     int
+	Issues      []Issue
+	BrokenLinks []BrokenLink
+	Missing     []Object // referenced from the refs, HEAD or index but absent
+	Unreachable []Object // present but not reachable from any root
+	Dangling    []Object // unreachable and not referenced by any other object
+}
+
+// OK reports whether the repository is free of corruption and missing
+// objects. Unreachable objects are not errors.
+func (rep *Report) OK() bool {
+	return len(rep.Issues) == 0 && len(rep.BrokenLinks) == 0 && len(rep.Missing) == 0
+}
+
+// Check reads every loose and packed object in r, then checks
+// connectivity from the refs, HEAD and the index.
+func Check(r *repo.Repo) (*Report, error) {
+	rep := &Report{}
+	types := make(map[string]string)
+	links := make(map[string][]link)
+
+	check := func(hash, objType string, data []byte) {
+		types[hash] = objType
+		rep.Checked++
+		if got := internal.Hash(objType, data); got != hash {
+			rep.Issues = append(rep.Issues, Issue{objType, hash, "hash mismatch, content hashes to " + got})
+		}
+
+		var problems []string
+		switch objType {
+		case internal.BlobType:
+		case internal.TreeType:
+			links[hash], problems = checkTree(data)
+		case internal.CommitType:
+			links[hash], problems = checkCommit(data)
+		case internal.TagType:
+			links[hash], problems = checkTag(data)
+		default:
+			problems = []string{fmt.Sprintf("unknown object type %q", objType)}
+		}
+		for _, p := range problems {
+			rep.Issues = append(rep.Issues, Issue{objType, hash, p})
+		}
+	}
+
+	loose, err := r.LooseObjects()
+	if err != nil {
+		return nil, err
+	}
+	for _, hash := range loose {
+		objType, data, err := readLoose(r, hash)
+		if err != nil {
+			types[hash] = objType
+			rep.Checked++
+			rep.Issues = append(rep.Issues, Issue{"object", hash, err.Error()})
+			continue
+		}
+		check(hash, objType, data)
+	}
+
+	packs, err := r.Packs()
+	if err != nil {
+		return nil, err
+	}
+	bad, err := r.BadPacks()
+	if err != nil {
+		return nil, err
+	}
+	for _, err := range bad {
+		rep.Issues = append(rep.Issues, Issue{Message: err.Error()})
+	}
+	for _, p := range packs {
+		if _, err := p.Verify(); err != nil {
+			rep.Issues = append(rep.Issues, Issue{Message: err.Error()})
+		}
+		for _, e := range p.Index.Entries {
+			if _, ok := types[e.Hash]; ok {
+				continue
+			}
+			objType, data, err := p.Read(e.Hash)
+			if err != nil {
+				types[e.Hash] = objType
+				rep.Checked++
+				rep.Issues = append(rep.Issues, Issue{"object", e.Hash, err.Error()})
+				continue
+			}
+			check(e.Hash, objType, data)
+		}
+	}
+
+	if err := checkConnectivity(r, rep, types, links); err != nil {
+		return nil, err
+	}
+	sort.Slice(rep.Issues, func(i, j int) bool { return rep.Issues[i].Hash < rep.Issues[j].Hash })
+	return rep, nil
+}
+
+// readLoose reads an object that has a loose copy. OpenObject prefers the
+// loose copy, so a packed copy of the same object is not what gets checked.
+func readLoose(r *repo.Repo, hash string) (string, []byte, error) {
+	obj, err := r.OpenObject(hash)
+	if err != nil {
+		return "", nil, err
+	}
+	defer obj.Close()
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, obj); err != nil {
+		return obj.Type, nil, err
+	}
+	return obj.Type, buf.Bytes(), nil
+}
+
+func checkConnectivity(r *repo.Repo, rep *Report, types map[string]string, links map[string][]link) error {
+	roots, err := gc.Roots(r)
+	if err != nil {
+		return err
+	}
+
+	// Broken links and type mismatches are reported for every object
+	referenced := make(map[string]bool)
+	froms := make([]string, 0, len(links))
+	for from := range links {
+		froms = append(froms, from)
+	}
+	sort.Strings(froms)
+	for _, from := range froms {
+		for _, l := range links[from] {
+			referenced[l.hash] = true
+			got, ok := types[l.hash]
+			if !ok {
+				rep.BrokenLinks = append(rep.BrokenLinks, BrokenLink{
+					From: Object{types[from], from},
+					To:   Object{l.objType, l.hash},
+				})
+				continue
+			}
+			if l.objType != "" && got != l.objType {
+				rep.Issues = append(rep.Issues, Issue{types[from], from,
+					fmt.Sprintf("%s is a %s, expected a %s", l.hash, got, l.objType)})
+			}
+		}
+	}
+
+	reachable := make(map[string]bool)
+	missing := make(map[string]string)
+	var stack []link
+	for _, root := range roots {
+		stack = append(stack, link{hash: root})
+	}
+	for len(stack) > 0 {
+		l := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if reachable[l.hash] {
+			continue
+		}
+		if _, ok := types[l.hash]; !ok {
+			if missing[l.hash] == "" {
+				missing[l.hash] = l.objType
+			}
+			continue
+		}
+		reachable[l.hash] = true
+		stack = append(stack, links[l.hash]...)
+	}
+
+	for hash, objType := range missing {
+		if objType == "" {
+			objType = "object"
+		}
+		rep.Missing = append(rep.Missing, Object{objType, hash})
+	}
+	for hash, objType := range types {
+		if reachable[hash] {
+			continue
+		}
+		rep.Unreachable = append(rep.Unreachable, Object{objType, hash})
+		if !referenced[hash] {
+			rep.Dangling = append(rep.Dangling, Object{objType, hash})
+		}
+	}
+	for _, list := range [][]Object{rep.Missing, rep.Unreachable, rep.Dangling} {
+		sort.Slice(list, func(i, j int) bool { return list[i].Hash < list[j].Hash })
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package fsck_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/fsck"
+	"github.com/MahendraDani/gitloom.git/internal/pack"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/testrepo"
+)
+
+// setupRepo commits "foo.txt" and "foo/bar.txt", whose tree entries only
+// pass the sort check in git order.
+func setupRepo(t *testing.T) *repo.Repo {
+	t.Helper()
+	r := testrepo.New(t)
+	testrepo.WriteFile(t, r.WorkTree(), "foo.txt", "one\n")
+	testrepo.WriteFile(t, r.WorkTree(), "foo/bar.txt", "two\n")
+	testrepo.CommitAll(t, r, "initial")
+	return r
+}
+
+func check(t *testing.T, r *repo.Repo) *fsck.Report {
+	t.Helper()
+	rep, err := fsck.Check(r)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	return rep
+}
+
+func hasIssue(rep *fsck.Report, hash, text string) bool {
+	for _, issue := range rep.Issues {
+		if issue.Hash == hash && strings.Contains(issue.Message, text) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCleanRepository(t *testing.T) {
+	r := setupRepo(t)
+	blob, err := r.WriteRawObject(internal.BlobType, []byte("dangling\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rep := check(t, r)
+	if !rep.OK() {
+		t.Fatalf("expected a clean repository, got %+v", rep)
+	}
+	if rep.Checked != 6 {
+		t.Fatalf("expected 6 objects checked, got %d", rep.Checked)
+	}
+	if len(rep.Dangling) != 1 || rep.Dangling[0].Hash != blob {
+		t.Fatalf("expected %s to be dangling, got %v", blob, rep.Dangling)
+	}
+}
+
+func TestHashMismatch(t *testing.T) {
+	r := setupRepo(t)
+	a, _ := r.WriteRawObject(internal.BlobType, []byte("a\n"))
+	b, _ := r.WriteRawObject(internal.BlobType, []byte("b\n"))
+
+	// Put b's content under a's name
+	data, err := os.ReadFile(r.LooseObjectPath(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Chmod(r.LooseObjectPath(a), repo.FilePerm)
+	if err := os.WriteFile(r.LooseObjectPath(a), data, repo.FilePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	rep := check(t, r)
+	if rep.OK() || !hasIssue(rep, a, "hash mismatch") {
+		t.Fatalf("expected a hash mismatch for %s, got %v", a, rep.Issues)
+	}
+}
+
+func TestMissingObject(t *testing.T) {
+	r := setupRepo(t)
+	tree, _ := r.ReadObject(mustResolveTree(t, r))
+	var blob string
+	for _, e := range tree.(*internal.Tree).Entries {
+		if e.Name == "foo.txt" {
+			blob = e.Hash
+		}
+	}
+	if err := os.Remove(r.LooseObjectPath(blob)); err != nil {
+		t.Fatal(err)
+	}
+
+	rep := check(t, r)
+	if rep.OK() || len(rep.Missing) != 1 || rep.Missing[0] != (fsck.Object{Type: internal.BlobType, Hash: blob}) {
+		t.Fatalf("expected blob %s to be missing, got %v", blob, rep.Missing)
+	}
+	if len(rep.BrokenLinks) != 1 {
+		t.Fatalf("expected one broken link, got %v", rep.BrokenLinks)
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	r := setupRepo(t)
+	blob, _ := r.WriteRawObject(internal.BlobType, []byte("x\n"))
+
+	unsorted, err := internal.NewTree([]internal.TreeEntry{
+		{Mode: internal.ModeBlob, Name: "b", Hash: blob},
+		{Mode: internal.ModeBlob, Name: "a", Hash: blob},
+		{Mode: "100600", Name: "c", Hash: blob},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := r.WriteObject(unsorted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	badCommit, err := r.WriteRawObject(internal.CommitType, []byte("tree "+tree+"\ncommitter nobody\n\nmsg\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rep := check(t, r)
+	for _, want := range []struct{ hash, text string }{
+		{tree, "not properly sorted"},
+		{tree, "invalid mode 100600"},
+		{badCommit, "missing author"},
+	} {
+		if !hasIssue(rep, want.hash, want.text) {
+			t.Errorf("expected issue %q for %s, got %v", want.text, want.hash, rep.Issues)
+		}
+	}
+}
+
+func TestPackedObjectsAreChecked(t *testing.T) {
+	r := setupRepo(t)
+	loose, err := r.LooseObjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.WritePack(loose, pack.Options{}); err != nil {
+		t.Fatalf("WritePack failed: %v", err)
+	}
+	for _, hash := range loose {
+		if err := r.RemoveLooseObject(hash); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rep := check(t, r)
+	if !rep.OK() || rep.Checked != len(loose) {
+		t.Fatalf("expected %d clean packed objects, got %+v", len(loose), rep)
+	}
+}
+
+func TestBadPackIsReported(t *testing.T) {
+	r := setupRepo(t)
+	loose, err := r.LooseObjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checksum, err := r.WritePack(loose, pack.Options{})
+	if err != nil {
+		t.Fatalf("WritePack failed: %v", err)
+	}
+	idxPath := filepath.Join(r.Path, repo.PackDir, "pack-"+checksum+".idx")
+	if err := os.Chmod(idxPath, repo.FilePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(idxPath, 10); err != nil {
+		t.Fatal(err)
+	}
+	r.ReloadPacks()
+
+	// The loose copies are still checked
+	rep := check(t, r)
+	if rep.OK() || rep.Checked != len(loose) {
+		t.Fatalf("expected a bad pack and %d checked objects, got %+v", len(loose), rep)
+	}
+	if !hasIssue(rep, "", idxPath) {
+		t.Fatalf("expected the truncated index to be reported, got %v", rep.Issues)
+	}
+}
+
+func mustResolveTree(t *testing.T, r *repo.Repo) string {
+	t.Helper()
+	head, err := refs.Resolve(r, refs.Head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := r.ReadObject(head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return obj.(*internal.Commit).Tree
+}
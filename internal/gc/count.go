@@ -0,0 +1,94 @@
+package gc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/pack"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// Counts summarizes the object database, as reported by count-objects.
+type Counts struct {
+	Loose         int   // number of loose objects
+	LooseSize     int64 // disk usage of loose objects in bytes
+	InPack        int   // number of objects in packs
+	Packs         int
+	PackSize      int64 // disk usage of packs and their indexes in bytes
+	PrunePackable int   // loose objects that are also packed
+	Garbage       int   // leftover temporary and unrecognized files
+	GarbageSize   int64
+}
+
+// CountObjects gathers the counts for r.
+func CountObjects(r *repo.Repo) (*Counts, error) {
+	c := &Counts{}
+
+	loose, err := r.LooseObjects()
+	if err != nil {
+		return nil, err
+	}
+	packs, err := r.Packs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hash := range loose {
+		fi, err := os.Stat(r.LooseObjectPath(hash))
+		if err != nil {
+			return nil, err
+		}
+		c.Loose++
+		c.LooseSize += fi.Size()
+		for _, p := range packs {
+			if p.Has(hash) {
+				c.PrunePackable++
+				break
+			}
+		}
+	}
+
+	packDir := filepath.Join(r.Path, repo.PackDir)
+	for _, p := range packs {
+		c.Packs++
+		c.InPack += len(p.Index.Entries)
+		for _, path := range []string{p.Path, pack.IndexPath(p.Path)} {
+			if fi, err := os.Stat(path); err == nil {
+				c.PackSize += fi.Size()
+			}
+		}
+	}
+
+	// Anything in the pack directory that is not part of an open pack,
+	// plus temporary files from interrupted writes
+	garbage := garbageFiles(r)
+	entries, err := os.ReadDir(packDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, "tmp_") || isPackFile(name, packs) {
+			continue
+		}
+		garbage = append(garbage, filepath.Join(packDir, name))
+	}
+	for _, path := range garbage {
+		if fi, err := os.Stat(path); err == nil {
+			c.Garbage++
+			c.GarbageSize += fi.Size()
+		}
+	}
+	return c, nil
+}
+
+func isPackFile(name string, packs []*pack.Pack) bool {
+	for _, p := range packs {
+		base := "pack-" + p.Checksum()
+		if name == base+".pack" || name == base+".idx" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,304 @@
+// Package gc keeps the object database compact: it packs loose objects,
+// removes objects that are stored twice and prunes unreachable ones.
+package gc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MahendraDani/gitloom.git/internal/pack"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// DefaultPruneExpire is how old an unreachable object must be before gc
+// deletes it, so that objects written by a command still running are safe.
+const DefaultPruneExpire = "2.weeks.ago"
+
+// RepackOptions mirrors the flags of repack.
+type RepackOptions struct {
+	All    bool // pack every reachable object instead of only loose ones
+	Delete bool // remove packs and loose objects made redundant by the new pack
+
+	// KeepUnreachable writes unreachable objects from deleted packs back
+	// as loose objects, keeping the pack's mtime, so prune can age them.
+	KeepUnreachable bool
+
+	Pack pack.Options
+}
+
+// Repack writes a new pack and returns its checksum, or "" if there was
+// nothing to pack. Without All only loose objects are packed.
+func Repack(r *repo.Repo, opts RepackOptions) (string, error) {
+	var hashes []string
+	if opts.All {
+		roots, err := Roots(r)
+		if err != nil {
+			return "", err
+		}
+		reachable, err := Reachable(r, roots)
+		if err != nil {
+			return "", err
+		}
+		for hash := range reachable {
+			hashes = append(hashes, hash)
+		}
+		// Keep the pack, and so its name, independent of map order
+		sort.Strings(hashes)
+	} else {
+		loose, err := r.LooseObjects()
+		if err != nil {
+			return "", err
+		}
+		hashes = loose
+	}
+	if len(hashes) == 0 {
+		return "", nil
+	}
+
+	oldPacks, err := r.Packs()
+	if err != nil {
+		return "", err
+	}
+	var old []string
+	for _, p := range oldPacks {
+		old = append(old, p.Path)
+	}
+
+	checksum, err := r.WritePack(hashes, opts.Pack)
+	if err != nil {
+		return "", err
+	}
+	if !opts.Delete {
+		return checksum, nil
+	}
+
+	if opts.All {
+		keep := make(map[string]bool, len(hashes))
+		for _, hash := range hashes {
+			keep[hash] = true
+		}
+		for _, path := range old {
+			if err := dropPack(r, path, checksum, keep, opts.KeepUnreachable); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if _, err := PrunePacked(r); err != nil {
+		return "", err
+	}
+	return checksum, nil
+}
+
+// dropPack deletes the pack at path, which is superseded by the pack
+// named checksum, first exploding its objects not in keep if requested.
+func dropPack(r *repo.Repo, path, checksum string, keep map[string]bool, explode bool) error {
+	p, err := pack.Open(path)
+	if err != nil {
+		return err
+	}
+	if p.Checksum() == checksum {
+		// Repacking identical contents reproduces the same pack
+		return p.Close()
+	}
+
+	if explode {
+		fi, err := os.Stat(path)
+		if err != nil {
+			p.Close()
+			return err
+		}
+		for _, e := range p.Index.Entries {
+			if keep[e.Hash] {
+				continue
+			}
+			if err := explodeObject(r, p, e.Hash, fi.ModTime()); err != nil {
+				p.Close()
+				return err
+			}
+		}
+	}
+
+	if err := p.Close(); err != nil {
+		return err
+	}
+	return r.RemovePack(p.Checksum())
+}
+
+func explodeObject(r *repo.Repo, p *pack.Pack, hash string, mtime time.Time) error {
+	objType, data, err := p.Read(hash)
+	if err != nil {
+		return err
+	}
+	if _, err := r.WriteLooseObject(objType, data); err != nil {
+		return err
+	}
+	return os.Chtimes(r.LooseObjectPath(hash), mtime, mtime)
+}
+
+// PrunePacked deletes loose objects that are also stored in a pack and
+// returns how many were removed.
+func PrunePacked(r *repo.Repo) (int, error) {
+	packs, err := r.Packs()
+	if err != nil {
+		return 0, err
+	}
+	loose, err := r.LooseObjects()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, hash := range loose {
+		for _, p := range packs {
+			if !p.Has(hash) {
+				continue
+			}
+			if err := r.RemoveLooseObject(hash); err != nil {
+				return removed, err
+			}
+			removed++
+			break
+		}
+	}
+	return removed, nil
+}
+
+// Prune deletes loose objects that are unreachable and last modified
+// before expire, along with temporary files left behind by interrupted
+// writes. It returns the pruned object hashes.
+func Prune(r *repo.Repo, expire time.Time) ([]string, error) {
+	roots, err := Roots(r)
+	if err != nil {
+		return nil, err
+	}
+	reachable, err := Reachable(r, roots)
+	if err != nil {
+		return nil, err
+	}
+	loose, err := r.LooseObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []string
+	for _, hash := range loose {
+		if reachable[hash] {
+			continue
+		}
+		fi, err := os.Stat(r.LooseObjectPath(hash))
+		if err != nil {
+			return pruned, err
+		}
+		if !fi.ModTime().Before(expire) {
+			continue
+		}
+		if err := r.RemoveLooseObject(hash); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, hash)
+	}
+
+	for _, path := range garbageFiles(r) {
+		if fi, err := os.Stat(path); err == nil && fi.ModTime().Before(expire) {
+			os.Remove(path)
+		}
+	}
+	return pruned, nil
+}
+
+// Options mirrors the flags of gc.
+type Options struct {
+	PruneExpire time.Time // zero disables pruning
+
+	// PruneNow means every unreachable object is to be pruned, so those
+	// in old packs are dropped instead of being written back loose first.
+	PruneNow bool
+
+	Pack pack.Options
+}
+
+// Run packs all reachable objects into a single pack, deletes what that
+// makes redundant and prunes unreachable objects older than PruneExpire.
+// Unreachable objects from the old packs are loosened with the pack's
+// mtime, so that they age like any other loose object.
+func Run(r *repo.Repo, opts Options) error {
+	_, err := Repack(r, RepackOptions{
+		All:             true,
+		Delete:          true,
+		KeepUnreachable: !opts.PruneNow,
+		Pack:            opts.Pack,
+	})
+	if err != nil {
+		return err
+	}
+	if opts.PruneExpire.IsZero() {
+		return nil
+	}
+	_, err = Prune(r, opts.PruneExpire)
+	return err
+}
+
+// ParseExpire converts a prune expiry such as "now", "never", "2.weeks.ago",
+// "14d" or "36h" into the time before which objects may be pruned. The
+// zero time means never.
+func ParseExpire(s string, now time.Time) (time.Time, error) {
+	switch s {
+	case "now":
+		return now, nil
+	case "never", "false":
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+
+	spec := strings.TrimSuffix(s, ".ago")
+	n, unit := spec, ""
+	if i := strings.IndexFunc(spec, func(c rune) bool { return c < '0' || c > '9' }); i >= 0 {
+		n, unit = spec[:i], strings.TrimPrefix(spec[i:], ".")
+	}
+	count, err := strconv.Atoi(n)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiry %q", s)
+	}
+
+	unit = strings.TrimSuffix(unit, "s")
+	switch unit {
+	case "second":
+		return now.Add(-time.Duration(count) * time.Second), nil
+	case "minute":
+		return now.Add(-time.Duration(count) * time.Minute), nil
+	case "hour":
+		return now.Add(-time.Duration(count) * time.Hour), nil
+	case "d", "day":
+		return now.AddDate(0, 0, -count), nil
+	case "w", "week":
+		return now.AddDate(0, 0, -7*count), nil
+	case "month":
+		return now.AddDate(0, -count, 0), nil
+	case "year":
+		return now.AddDate(-count, 0, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid expiry %q", s)
+}
+
+// garbageFiles returns temporary files left in the object directories by
+// interrupted writes.
+func garbageFiles(r *repo.Repo) []string {
+	var paths []string
+	for _, pattern := range []string{
+		filepath.Join(r.Path, repo.ObjectsDir, "tmp_obj_*"),
+		filepath.Join(r.Path, repo.PackDir, "tmp_*"),
+	} {
+		matches, _ := filepath.Glob(pattern)
+		paths = append(paths, matches...)
+	}
+	return paths
+}
@@ -0,0 +1,219 @@
+package gc_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/gc"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/testrepo"
+)
+
+// setupRepo makes two commits of a growing file and writes one
+// unreachable blob.
+func setupRepo(t *testing.T) (*repo.Repo, string) {
+	t.Helper()
+	r := testrepo.New(t)
+
+	var content strings.Builder
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 200; j++ {
+			fmt.Fprintf(&content, "commit %d line %d\n", i, j)
+		}
+		testrepo.WriteFile(t, r.WorkTree(), "file.txt", content.String())
+		testrepo.CommitAll(t, r, fmt.Sprintf("commit %d", i))
+	}
+
+	garbage, err := r.WriteRawObject(internal.BlobType, []byte("unreachable\n"))
+	if err != nil {
+		t.Fatalf("failed to write blob: %v", err)
+	}
+	return r, garbage
+}
+
+func TestReachable(t *testing.T) {
+	r, garbage := setupRepo(t)
+
+	roots, err := gc.Roots(r)
+	if err != nil {
+		t.Fatalf("Roots failed: %v", err)
+	}
+	reachable, err := gc.Reachable(r, roots)
+	if err != nil {
+		t.Fatalf("Reachable failed: %v", err)
+	}
+
+	// Two commits, two trees and two blobs
+	if len(reachable) != 6 {
+		t.Fatalf("expected 6 reachable objects, got %d", len(reachable))
+	}
+	if reachable[garbage] {
+		t.Fatalf("unreachable blob reported as reachable")
+	}
+}
+
+func TestRepackAllDelete(t *testing.T) {
+	r, garbage := setupRepo(t)
+
+	if _, err := gc.Repack(r, gc.RepackOptions{}); err != nil {
+		t.Fatalf("Repack failed: %v", err)
+	}
+	checksum, err := gc.Repack(r, gc.RepackOptions{All: true, Delete: true})
+	if err != nil {
+		t.Fatalf("Repack -a -d failed: %v", err)
+	}
+
+	packs, err := r.Packs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packs) != 1 || packs[0].Checksum() != checksum {
+		t.Fatalf("expected only the new pack to remain, got %d packs", len(packs))
+	}
+
+	c, err := gc.CountObjects(r)
+	if err != nil {
+		t.Fatalf("CountObjects failed: %v", err)
+	}
+	// The unreachable blob was loose as well as packed, so it stays loose
+	if c.Loose != 1 || c.InPack != 6 || c.PrunePackable != 0 {
+		t.Fatalf("unexpected counts after repack: %+v", c)
+	}
+	if !r.HasObject(garbage) {
+		t.Fatalf("loose unreachable object was removed by repack")
+	}
+}
+
+func TestRunPrunesOnlyExpiredObjects(t *testing.T) {
+	r, garbage := setupRepo(t)
+
+	if err := gc.Run(r, gc.Options{PruneExpire: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatalf("gc failed: %v", err)
+	}
+	if !r.HasObject(garbage) {
+		t.Fatalf("recent unreachable object was pruned")
+	}
+
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(r.LooseObjectPath(garbage), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := gc.Run(r, gc.Options{PruneExpire: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatalf("gc failed: %v", err)
+	}
+	if r.HasObject(garbage) {
+		t.Fatalf("expired unreachable object was not pruned")
+	}
+
+	c, err := gc.CountObjects(r)
+	if err != nil {
+		t.Fatalf("CountObjects failed: %v", err)
+	}
+	if c.Loose != 0 || c.Packs != 1 || c.InPack != 6 {
+		t.Fatalf("unexpected counts after gc: %+v", c)
+	}
+}
+
+// orphanHeadCommit packs everything, then moves the branch back one
+// commit and returns the commit left unreachable in the pack.
+func orphanHeadCommit(t *testing.T, r *repo.Repo) string {
+	t.Helper()
+	if _, err := gc.Repack(r, gc.RepackOptions{All: true, Delete: true}); err != nil {
+		t.Fatalf("Repack -a -d failed: %v", err)
+	}
+
+	head, err := refs.Resolve(r, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := r.ReadObject(head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent := obj.(*internal.Commit).Parents[0]
+	if err := refs.Update(r, "HEAD", parent, head); err != nil {
+		t.Fatalf("failed to reset branch: %v", err)
+	}
+	return head
+}
+
+func TestRunPruneNeverKeepsPackedUnreachable(t *testing.T) {
+	r, _ := setupRepo(t)
+	orphan := orphanHeadCommit(t, r)
+
+	if err := gc.Run(r, gc.Options{}); err != nil {
+		t.Fatalf("gc failed: %v", err)
+	}
+	if _, err := r.ReadObject(orphan); err != nil {
+		t.Fatalf("unreachable packed commit lost by gc --prune=never: %v", err)
+	}
+}
+
+func TestRunDefaultExpiryAgesPackedUnreachable(t *testing.T) {
+	r, _ := setupRepo(t)
+	orphan := orphanHeadCommit(t, r)
+
+	now := time.Now()
+	expire, err := gc.ParseExpire(gc.DefaultPruneExpire, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gc.Run(r, gc.Options{PruneExpire: expire}); err != nil {
+		t.Fatalf("gc failed: %v", err)
+	}
+	if !r.HasObject(orphan) {
+		t.Fatalf("recently packed unreachable commit was pruned")
+	}
+
+	// Loosened objects take the pack's mtime, so an old pack's unreachable
+	// objects are pruned by the same gc that drops the pack
+	r, _ = setupRepo(t)
+	orphan = orphanHeadCommit(t, r)
+	packs, err := r.Packs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := now.AddDate(0, 0, -21)
+	if err := os.Chtimes(packs[0].Path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := gc.Run(r, gc.Options{PruneExpire: expire}); err != nil {
+		t.Fatalf("gc failed: %v", err)
+	}
+	if r.HasObject(orphan) {
+		t.Fatalf("unreachable commit from an expired pack was not pruned")
+	}
+}
+
+func TestParseExpire(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"now", now},
+		{"never", time.Time{}},
+		{"36h", now.Add(-36 * time.Hour)},
+		{"14d", now.AddDate(0, 0, -14)},
+		{"2.weeks.ago", now.AddDate(0, 0, -14)},
+		{"1.month.ago", now.AddDate(0, -1, 0)},
+	}
+	for _, tt := range tests {
+		got, err := gc.ParseExpire(tt.in, now)
+		if err != nil {
+			t.Fatalf("ParseExpire(%q) failed: %v", tt.in, err)
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseExpire(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	if _, err := gc.ParseExpire("soon", now); err == nil {
+		t.Fatalf("expected an error for an invalid expiry")
+	}
+}
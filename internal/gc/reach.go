@@ -0,0 +1,96 @@
+package gc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/index"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// Roots returns the objects that keep others alive: every ref, HEAD and
+// the blobs staged in the index.
+func Roots(r *repo.Repo) ([]string, error) {
+	list, err := refs.List(r, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []string
+	for _, ref := range list {
+		roots = append(roots, ref.Hash)
+	}
+
+	// HEAD may be detached, or name an unborn branch
+	head, err := refs.Resolve(r, refs.Head)
+	if err == nil {
+		roots = append(roots, head)
+	} else if !errors.Is(err, refs.ErrNotFound) {
+		return nil, err
+	}
+
+	idx, err := index.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range idx.Entries {
+		roots = append(roots, e.Hash)
+	}
+	return roots, nil
+}
+
+// Reachable returns the set of objects reachable from roots by following
+// tag targets, commit trees and parents, and tree entries. Blobs are
+// marked without being read. A missing object is an error.
+func Reachable(r *repo.Repo, roots []string) (map[string]bool, error) {
+	seen := make(map[string]bool)
+	stack := append([]string(nil), roots...)
+
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[hash] {
+			continue
+		}
+
+		objType, _, err := r.ObjectInfo(hash)
+		if err != nil {
+			return nil, err
+		}
+		seen[hash] = true
+		if objType == internal.BlobType {
+			continue
+		}
+
+		obj, err := r.ReadObject(hash)
+		if err != nil {
+			return nil, fmt.Errorf("object %s: %w", hash, err)
+		}
+		switch o := obj.(type) {
+		case *internal.Tag:
+			stack = append(stack, o.Object)
+		case *internal.Commit:
+			stack = append(stack, o.Tree)
+			stack = append(stack, o.Parents...)
+		case *internal.Tree:
+			for _, e := range o.Entries {
+				if e.Mode == internal.ModeGitlink {
+					continue
+				}
+				if e.Mode == internal.ModeTree {
+					stack = append(stack, e.Hash)
+					continue
+				}
+				if !seen[e.Hash] {
+					if !r.HasObject(e.Hash) {
+						return nil, fmt.Errorf("%w: %s", repo.ErrObjectNotFound, e.Hash)
+					}
+					seen[e.Hash] = true
+				}
+			}
+		}
+	}
+	return seen, nil
+}
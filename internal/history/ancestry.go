@@ -0,0 +1,96 @@
+package history
+
+import (
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// IsAncestor reports whether ancestor is reachable from commit. A commit
+// is its own ancestor.
+func IsAncestor(r *repo.Repo, ancestor, commit string) (bool, error) {
+	found := false
+	err := Walk(r, []string{commit}, func(hash string, c *internal.Commit) error {
+		if hash == ancestor {
+			found = true
+			return ErrStop
+		}
+		return nil
+	})
+	return found, err
+}
+
+// AheadBehind counts the commits reachable from a but not from b (ahead)
+// and from b but not from a (behind).
+func AheadBehind(r *repo.Repo, a, b string) (ahead, behind int, err error) {
+	fromA, err := reachable(r, a)
+	if err != nil {
+		return 0, 0, err
+	}
+	fromB, err := reachable(r, b)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for hash := range fromA {
+		if !fromB[hash] {
+			ahead++
+		}
+	}
+	for hash := range fromB {
+		if !fromA[hash] {
+			behind++
+		}
+	}
+	return ahead, behind, nil
+}
+
+// reachable returns the set of commits reachable from start.
+func reachable(r *repo.Repo, start string) (map[string]bool, error) {
+	seen := make(map[string]bool)
+	err := Walk(r, []string{start}, func(hash string, c *internal.Commit) error {
+		seen[hash] = true
+		return nil
+	})
+	return seen, err
+}
+
+// MergeBases returns the best common ancestors of a and b: the commits
+// reachable from both that are not ancestors of another such commit,
+// newest first. There is usually one; criss-cross merges can leave more,
+// and unrelated histories none.
+func MergeBases(r *repo.Repo, a, b string) ([]string, error) {
+	fromA, err := reachable(r, a)
+	if err != nil {
+		return nil, err
+	}
+
+	var common, parents []string
+	err = Walk(r, []string{b}, func(hash string, c *internal.Commit) error {
+		if fromA[hash] {
+			common = append(common, hash)
+			parents = append(parents, c.Parents...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// A common ancestor reachable from another one's parents is not best
+	redundant := make(map[string]bool)
+	err = Walk(r, parents, func(hash string, c *internal.Commit) error {
+		redundant[hash] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var bases []string
+	for _, hash := range common {
+		if !redundant[hash] {
+			bases = append(bases, hash)
+		}
+	}
+	return bases, nil
+}
@@ -0,0 +1,125 @@
+package history
+
+import "strings"
+
+// Graph renders the ASCII lanes drawn to the left of commits by log --graph.
+// Each lane holds the hash of the commit expected next in that column.
+type Graph struct {
+	lanes []string
+}
+
+// GraphRow is the output produced for a single commit.
+type GraphRow struct {
+	Before  []string // connector lines printed above the commit, when lanes merge
+	Commit  string   // prefix for the commit line itself
+	After   []string // connector lines printed below the commit, when lanes fork
+	Padding string   // prefix for any further lines belonging to the commit
+}
+
+// Next advances the graph past the commit hash with the given parents.
+func (g *Graph) Next(hash string, parents []string) GraphRow {
+	var row GraphRow
+
+	col := g.indexOf(hash, 0)
+	if col < 0 {
+		g.lanes = append(g.lanes, hash)
+		col = len(g.lanes) - 1
+	}
+
+	// Several branches waiting for this commit converge into its column
+	for dup := g.indexOf(hash, col+1); dup >= 0; dup = g.indexOf(hash, col+1) {
+		row.Before = append(row.Before, g.collapseLine(dup, true))
+		g.lanes = append(g.lanes[:dup], g.lanes[dup+1:]...)
+	}
+
+	row.Commit = g.line(func(i int) string {
+		if i == col {
+			return "*"
+		}
+		return "|"
+	})
+
+	if len(parents) == 0 {
+		// Root commit: its lane ends here
+		if col < len(g.lanes)-1 {
+			row.After = append(row.After, g.collapseLine(col, false))
+		}
+		g.lanes = append(g.lanes[:col], g.lanes[col+1:]...)
+		row.Padding = g.line(func(int) string { return "|" })
+		return row
+	}
+
+	g.lanes[col] = parents[0]
+
+	var added []string
+	for _, p := range parents[1:] {
+		if g.indexOf(p, 0) < 0 {
+			added = append(added, p)
+		}
+	}
+	if len(added) > 0 {
+		row.After = append(row.After, g.forkLine(col, len(added)))
+		rest := append(added, g.lanes[col+1:]...)
+		g.lanes = append(g.lanes[:col+1], rest...)
+	}
+
+	row.Padding = g.line(func(int) string { return "|" })
+	return row
+}
+
+// line renders one character per lane, separated by spaces.
+func (g *Graph) line(mark func(i int) string) string {
+	parts := make([]string, len(g.lanes))
+	for i := range g.lanes {
+		parts[i] = mark(i)
+	}
+	return strings.Join(parts, " ")
+}
+
+// collapseLine draws lane k closing and every lane to its right shifting
+// left. When merge is true lane k joins the lane to its left.
+func (g *Graph) collapseLine(k int, merge bool) string {
+	var b strings.Builder
+	for i := range g.lanes {
+		switch {
+		case i < k:
+			b.WriteString("| ")
+		case i == k:
+			if merge {
+				s := strings.TrimSuffix(b.String(), " ")
+				b.Reset()
+				b.WriteString(s + "/")
+			}
+		default:
+			b.WriteString(" /")
+		}
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+// forkLine draws n new lanes opening to the right of col, pushing later lanes right.
+func (g *Graph) forkLine(col, n int) string {
+	var b strings.Builder
+	for i := range g.lanes {
+		switch {
+		case i < col:
+			b.WriteString("| ")
+		case i == col:
+			b.WriteString("|")
+			b.WriteString(strings.Repeat("\\", n))
+			b.WriteString(" ")
+		default:
+			b.WriteString("\\ ")
+		}
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+func (g *Graph) indexOf(hash string, from int) int {
+	for i := from; i < len(g.lanes); i++ {
+		if g.lanes[i] == hash {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,198 @@
+package history_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/history"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/testrepo"
+)
+
+// writeCommit stores a commit on the empty tree made at the given unix time.
+func writeCommit(t *testing.T, r *repo.Repo, message string, when int64, parents ...string) string {
+	t.Helper()
+
+	treeHash, err := r.WriteObject(&internal.Tree{})
+	if err != nil {
+		t.Fatalf("failed to write tree: %v", err)
+	}
+
+	sig := internal.Signature{Name: "Jane Doe", Email: "jane@example.com", When: time.Unix(when, 0).UTC()}
+	hash, err := r.WriteObject(&internal.Commit{
+		Tree:      treeHash,
+		Parents:   parents,
+		Author:    sig,
+		Committer: sig,
+		Message:   message + "\n",
+	})
+	if err != nil {
+		t.Fatalf("failed to write commit: %v", err)
+	}
+	return hash
+}
+
+func setupMergeHistory(t *testing.T) (*repo.Repo, map[string]string) {
+	t.Helper()
+	r := testrepo.New(t)
+
+	hashes := map[string]string{}
+	hashes["base"] = writeCommit(t, r, "base", 1000)
+	hashes["a"] = writeCommit(t, r, "a", 2000, hashes["base"])
+	hashes["b"] = writeCommit(t, r, "b", 3000, hashes["base"])
+	hashes["merge"] = writeCommit(t, r, "merge", 4000, hashes["a"], hashes["b"])
+	return r, hashes
+}
+
+func TestWalk_DateOrder(t *testing.T) {
+	r, hashes := setupMergeHistory(t)
+
+	var got []string
+	err := history.Walk(r, []string{hashes["merge"]}, func(hash string, c *internal.Commit) error {
+		got = append(got, history.Subject(c.Message))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk returned error: %v", err)
+	}
+
+	expected := []string{"merge", "b", "a", "base"}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Fatalf("unexpected order:\n got: %v\nwant: %v", got, expected)
+	}
+}
+
+func TestWalk_ChildrenBeforeParentsOnEqualDates(t *testing.T) {
+	r := testrepo.New(t)
+
+	// Every commit shares one timestamp, so only the graph can order them
+	root := writeCommit(t, r, "root", 1000)
+	side := writeCommit(t, r, "side", 1000, root)
+	tip := writeCommit(t, r, "tip", 1000, side)
+	merge := writeCommit(t, r, "merge", 1000, root, tip)
+
+	emitted := map[string]bool{}
+	err := history.Walk(r, []string{merge}, func(hash string, c *internal.Commit) error {
+		for _, p := range c.Parents {
+			if emitted[p] {
+				t.Errorf("%s walked after its parent %s", history.Subject(c.Message), p)
+			}
+		}
+		emitted[hash] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk returned error: %v", err)
+	}
+	if len(emitted) != 4 {
+		t.Fatalf("expected 4 commits, got %d", len(emitted))
+	}
+}
+
+func TestLog_OnelineGraph(t *testing.T) {
+	r, hashes := setupMergeHistory(t)
+
+	var buf bytes.Buffer
+	err := history.Log(r, &buf, []string{hashes["merge"]}, history.LogOptions{Oneline: true, Graph: true})
+	if err != nil {
+		t.Fatalf("Log returned error: %v", err)
+	}
+
+	expected := strings.Join([]string{
+		"* " + hashes["merge"][:7] + " merge",
+		"|\\",
+		"| * " + hashes["b"][:7] + " b",
+		"* | " + hashes["a"][:7] + " a",
+		"|/",
+		"* " + hashes["base"][:7] + " base",
+		"",
+	}, "\n")
+	if buf.String() != expected {
+		t.Fatalf("unexpected graph:\n got:\n%s\nwant:\n%s", buf.String(), expected)
+	}
+}
+
+func TestLog_MaxCount(t *testing.T) {
+	r, hashes := setupMergeHistory(t)
+
+	var buf bytes.Buffer
+	err := history.Log(r, &buf, []string{hashes["merge"]}, history.LogOptions{MaxCount: 2})
+	if err != nil {
+		t.Fatalf("Log returned error: %v", err)
+	}
+
+	output := buf.String()
+	if strings.Count(output, "commit ") != 2 {
+		t.Fatalf("expected 2 commits, got:\n%s", output)
+	}
+	if !strings.Contains(output, "Merge: "+hashes["a"][:7]+" "+hashes["b"][:7]) {
+		t.Errorf("expected merge line in output, got:\n%s", output)
+	}
+	if !strings.Contains(output, "Date:   Thu Jan 1 01:06:40 1970 +0000") {
+		t.Errorf("expected formatted date in output, got:\n%s", output)
+	}
+}
+
+func TestAncestry(t *testing.T) {
+	r, hashes := setupMergeHistory(t)
+
+	for _, c := range []struct {
+		ancestor, commit string
+		want             bool
+	}{
+		{"base", "merge", true},
+		{"a", "merge", true},
+		{"merge", "merge", true},
+		{"a", "b", false},
+		{"merge", "base", false},
+	} {
+		got, err := history.IsAncestor(r, hashes[c.ancestor], hashes[c.commit])
+		if err != nil {
+			t.Fatalf("IsAncestor returned error: %v", err)
+		}
+		if got != c.want {
+			t.Errorf("IsAncestor(%s, %s) = %v, want %v", c.ancestor, c.commit, got, c.want)
+		}
+	}
+
+	ahead, behind, err := history.AheadBehind(r, hashes["a"], hashes["merge"])
+	if err != nil {
+		t.Fatalf("AheadBehind returned error: %v", err)
+	}
+	if ahead != 0 || behind != 2 {
+		t.Fatalf("expected a to be 0 ahead and 2 behind merge, got %d and %d", ahead, behind)
+	}
+}
+
+func TestMergeBases_CrissCross(t *testing.T) {
+	r, hashes := setupMergeHistory(t)
+
+	// x and y each merge a and b, so both a and b are best common ancestors
+	x := writeCommit(t, r, "x", 5000, hashes["a"], hashes["b"])
+	y := writeCommit(t, r, "y", 6000, hashes["b"], hashes["a"])
+
+	for _, c := range []struct {
+		a, b string
+		want []string
+	}{
+		{hashes["a"], hashes["b"], []string{hashes["base"]}},
+		{hashes["merge"], hashes["a"], []string{hashes["a"]}},
+		{x, y, []string{hashes["b"], hashes["a"]}},
+	} {
+		got, err := history.MergeBases(r, c.a, c.b)
+		if err != nil {
+			t.Fatalf("MergeBases returned error: %v", err)
+		}
+		if strings.Join(got, " ") != strings.Join(c.want, " ") {
+			t.Errorf("MergeBases(%s, %s) = %v, want %v", c.a[:7], c.b[:7], got, c.want)
+		}
+	}
+
+	unrelated := writeCommit(t, r, "unrelated", 7000)
+	if got, err := history.MergeBases(r, x, unrelated); err != nil || len(got) != 0 {
+		t.Fatalf("expected no merge base for unrelated histories, got %v, %v", got, err)
+	}
+}
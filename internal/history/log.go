@@ -0,0 +1,92 @@
+package history
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// DateFormat matches the default date format of git log.
+const DateFormat = "Mon Jan 2 15:04:05 2006 -0700"
+
+// LogOptions controls the output of Log.
+type LogOptions struct {
+	Oneline  bool
+	Graph    bool
+	MaxCount int // 0 means no limit
+}
+
+// Log writes the history reachable from starts to w, newest first.
+func Log(r *repo.Repo, w io.Writer, starts []string, opts LogOptions) error {
+	var graph *Graph
+	if opts.Graph {
+		graph = &Graph{}
+	}
+
+	count := 0
+	return Walk(r, starts, func(hash string, c *internal.Commit) error {
+		if opts.MaxCount > 0 && count >= opts.MaxCount {
+			return ErrStop
+		}
+		count++
+
+		row := GraphRow{}
+		if graph != nil {
+			row = graph.Next(hash, c.Parents)
+		}
+		for _, line := range row.Before {
+			fmt.Fprintln(w, line)
+		}
+
+		lines := formatCommit(hash, c, opts.Oneline)
+		writePrefixed(w, row.Commit, lines[0])
+		for _, line := range row.After {
+			fmt.Fprintln(w, line)
+		}
+		for _, line := range lines[1:] {
+			writePrefixed(w, row.Padding, line)
+		}
+		return nil
+	})
+}
+
+func formatCommit(hash string, c *internal.Commit, oneline bool) []string {
+	if oneline {
+		return []string{hash[:7] + " " + Subject(c.Message)}
+	}
+
+	lines := []string{"commit " + hash}
+	if len(c.Parents) > 1 {
+		short := make([]string, len(c.Parents))
+		for i, p := range c.Parents {
+			short[i] = p[:7]
+		}
+		lines = append(lines, "Merge: "+strings.Join(short, " "))
+	}
+	lines = append(lines,
+		fmt.Sprintf("Author: %s <%s>", c.Author.Name, c.Author.Email),
+		"Date:   "+c.Author.When.Format(DateFormat),
+		"",
+	)
+	for _, line := range strings.Split(strings.TrimRight(c.Message, "\n"), "\n") {
+		lines = append(lines, "    "+line)
+	}
+	return append(lines, "")
+}
+
+// Subject returns the first line of a commit message.
+func Subject(message string) string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return subject
+}
+
+func writePrefixed(w io.Writer, prefix, line string) {
+	if prefix == "" {
+		fmt.Fprintln(w, line)
+		return
+	}
+	fmt.Fprintln(w, strings.TrimRight(prefix+" "+line, " "))
+}
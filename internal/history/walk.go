@@ -0,0 +1,130 @@
+package history
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// ErrStop can be returned by a WalkFunc to end the walk early without error.
+var ErrStop = errors.New("stop walk")
+
+// WalkFunc is called for each commit visited by Walk.
+type WalkFunc func(hash string, c *internal.Commit) error
+
+// Walk visits every commit reachable from starts exactly once, newest
+// committer date first, so commits on parallel branches are interleaved
+// by date the same way git log orders them. Like git log --date-order, a
+// commit is only visited after all of its walked children, even when
+// clock skew or equal dates would put it first.
+func Walk(r *repo.Repo, starts []string, fn WalkFunc) error {
+	commits, children, err := loadGraph(r, starts)
+	if err != nil {
+		return err
+	}
+
+	queue := &commitQueue{}
+	for hash, c := range commits {
+		if children[hash] == 0 {
+			heap.Push(queue, queuedCommit{hash: hash, commit: c})
+		}
+	}
+
+	for queue.Len() > 0 {
+		next := heap.Pop(queue).(queuedCommit)
+		if err := fn(next.hash, next.commit); err != nil {
+			if errors.Is(err, ErrStop) {
+				return nil
+			}
+			return err
+		}
+		for _, p := range next.commit.Parents {
+			children[p]--
+			if children[p] == 0 {
+				heap.Push(queue, queuedCommit{hash: p, commit: commits[p]})
+			}
+		}
+	}
+	return nil
+}
+
+// loadGraph reads every commit reachable from starts and counts, for each
+// of them, the parent links pointing at it from the other walked commits.
+func loadGraph(r *repo.Repo, starts []string) (map[string]*internal.Commit, map[string]int, error) {
+	commits := make(map[string]*internal.Commit)
+	children := make(map[string]int)
+
+	stack := append([]string(nil), starts...)
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if commits[hash] != nil {
+			continue
+		}
+
+		c, err := ReadCommit(r, hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		commits[hash] = c
+		for _, p := range c.Parents {
+			children[p]++
+			stack = append(stack, p)
+		}
+	}
+	return commits, children, nil
+}
+
+// ReadCommit loads hash and checks that it is a commit.
+func ReadCommit(r *repo.Repo, hash string) (*internal.Commit, error) {
+	obj, err := r.ReadObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := obj.(*internal.Commit)
+	if !ok {
+		return nil, fmt.Errorf("object %s is a %s, not a commit", hash, obj.Type())
+	}
+	return c, nil
+}
+
+// CommitTree returns the tree of commit hash.
+func CommitTree(r *repo.Repo, hash string) (string, error) {
+	c, err := ReadCommit(r, hash)
+	if err != nil {
+		return "", err
+	}
+	return c.Tree, nil
+}
+
+type queuedCommit struct {
+	hash   string
+	commit *internal.Commit
+}
+
+// commitQueue is a max-heap of commits keyed by committer date.
+type commitQueue []queuedCommit
+
+func (q commitQueue) Len() int { return len(q) }
+
+func (q commitQueue) Less(i, j int) bool {
+	ti, tj := q[i].commit.Committer.When, q[j].commit.Committer.When
+	if !ti.Equal(tj) {
+		return ti.After(tj)
+	}
+	return q[i].hash < q[j].hash
+}
+
+func (q commitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *commitQueue) Push(x any) { *q = append(*q, x.(queuedCommit)) }
+
+func (q *commitQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,148 @@
+package ignore
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+const (
+	// IgnoreFile is read from every directory of the working tree.
+	IgnoreFile = ".gitloomignore"
+	// GitIgnoreFile replaces IgnoreFile in git-compatible repositories.
+	GitIgnoreFile = ".gitignore"
+	// ExcludeFile holds repository wide patterns that are not committed.
+	ExcludeFile = "info/exclude"
+)
+
+// Matcher decides whether working tree paths are ignored. Patterns in
+// deeper directories take precedence over shallower ones, which take
+// precedence over .gitloom/info/exclude; within a file the last matching
+// line wins. Git-compatible repositories read .gitignore files instead of
+// .gitloomignore. A nil Matcher ignores nothing.
+type Matcher struct {
+	root       string
+	ignoreFile string
+	exclude    []*Rule
+	dirs       map[string][]*Rule // rules of the ignore file in each directory, loaded lazily
+}
+
+// New returns a Matcher for the working tree of r.
+func New(r *repo.Repo) (*Matcher, error) {
+	m := &Matcher{root: r.WorkTree(), ignoreFile: IgnoreFile, dirs: make(map[string][]*Rule)}
+	if r.GitCompatible() {
+		m.ignoreFile = GitIgnoreFile
+	}
+
+	excludePath := filepath.Join(r.Path, filepath.FromSlash(ExcludeFile))
+	source := filepath.Base(r.Path) + "/" + ExcludeFile
+	rules, err := readRules(excludePath, source, "")
+	if err != nil {
+		return nil, err
+	}
+	m.exclude = rules
+	return m, nil
+}
+
+// Ignored reports whether rel (slash separated, relative to the working
+// tree root) is ignored, either directly or because a parent directory
+// is. The deciding rule is returned, which may be a negated rule when a
+// path is explicitly re-included.
+func (m *Matcher) Ignored(rel string, isDir bool) (bool, *Rule, error) {
+	if m == nil || rel == "" {
+		return false, nil, nil
+	}
+
+	// A path inside an ignored directory cannot be re-included
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		rule, err := m.match(strings.Join(parts[:i], "/"), true)
+		if err != nil {
+			return false, nil, err
+		}
+		if rule != nil && !rule.negate {
+			return true, rule, nil
+		}
+	}
+
+	rule, err := m.match(rel, isDir)
+	if err != nil {
+		return false, nil, err
+	}
+	return rule != nil && !rule.negate, rule, nil
+}
+
+// match returns the highest precedence rule matching rel itself.
+func (m *Matcher) match(rel string, isDir bool) (*Rule, error) {
+	var found *Rule
+	check := func(rules []*Rule) {
+		for _, rule := range rules {
+			if rule.matches(rel, isDir) {
+				found = rule
+			}
+		}
+	}
+
+	check(m.exclude)
+
+	dir := path.Dir(rel)
+	var dirs []string
+	for dir != "." {
+		dirs = append([]string{dir}, dirs...)
+		dir = path.Dir(dir)
+	}
+	dirs = append([]string{""}, dirs...)
+
+	for _, d := range dirs {
+		rules, err := m.dirRules(d)
+		if err != nil {
+			return nil, err
+		}
+		check(rules)
+	}
+	return found, nil
+}
+
+func (m *Matcher) dirRules(dir string) ([]*Rule, error) {
+	if rules, ok := m.dirs[dir]; ok {
+		return rules, nil
+	}
+
+	source := m.ignoreFile
+	if dir != "" {
+		source = dir + "/" + m.ignoreFile
+	}
+	rules, err := readRules(filepath.Join(m.root, filepath.FromSlash(source)), source, dir)
+	if err != nil {
+		return nil, err
+	}
+	m.dirs[dir] = rules
+	return rules, nil
+}
+
+// readRules parses the ignore file at path. A missing file has no rules.
+func readRules(path, source, base string) ([]*Rule, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []*Rule
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		if rule := parseRule(scanner.Text(), source, base, lineNo); rule != nil {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, scanner.Err()
+}
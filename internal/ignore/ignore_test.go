@@ -0,0 +1,156 @@
+package ignore_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MahendraDani/gitloom.git/internal/ignore"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/testrepo"
+)
+
+func setupMatcher(t *testing.T, files map[string]string) *ignore.Matcher {
+	t.Helper()
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
+
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), repo.DirPerm); err != nil {
+			t.Fatalf("failed to create dir for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), repo.FilePerm); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	m, err := ignore.New(r)
+	if err != nil {
+		t.Fatalf("ignore.New returned error: %v", err)
+	}
+	return m
+}
+
+func TestIgnored(t *testing.T) {
+	m := setupMatcher(t, map[string]string{
+		".gitloomignore": "# build output\n" +
+			"*.log\n" +
+			"!keep.log\n" +
+			"build/\n" +
+			"/root-only.txt\n" +
+			"docs/**/*.tmp\n" +
+			"**/cache\n" +
+			"\\#hash\n",
+		"lib/.gitloomignore":    "!important.log\n*.o\n",
+		".gitloom/info/exclude": "*.swp\n",
+	})
+
+	cases := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"app.log", false, true},
+		{"nested/dir/app.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"build", false, false}, // directory-only pattern
+		{"build/out.bin", false, true},
+		{"src/build", true, true},
+		{"root-only.txt", false, true},
+		{"sub/root-only.txt", false, false},
+		{"docs/a.tmp", false, true},
+		{"docs/a/b/c.tmp", false, true},
+		{"src/a.tmp", false, false},
+		{"x/y/cache", true, true},
+		{"#hash", false, true},
+		{"lib/important.log", false, false}, // deeper file takes precedence
+		{"lib/main.o", false, true},
+		{"main.o", false, false},
+		{"notes.swp", false, true},
+		{"main.go", false, false},
+	}
+
+	for _, c := range cases {
+		ignored, _, err := m.Ignored(c.path, c.isDir)
+		if err != nil {
+			t.Fatalf("Ignored(%q) returned error: %v", c.path, err)
+		}
+		if ignored != c.ignored {
+			t.Errorf("Ignored(%q, dir=%v) = %v, want %v", c.path, c.isDir, ignored, c.ignored)
+		}
+	}
+}
+
+func TestIgnored_ParentDirectoryCannotBeReincluded(t *testing.T) {
+	m := setupMatcher(t, map[string]string{
+		".gitloomignore": "vendor/\n!vendor/keep.go\n",
+	})
+
+	ignored, rule, err := m.Ignored("vendor/keep.go", false)
+	if err != nil {
+		t.Fatalf("Ignored returned error: %v", err)
+	}
+	if !ignored {
+		t.Fatalf("expected file inside ignored directory to stay ignored")
+	}
+	if rule.Source != ".gitloomignore" || rule.Line != 1 || rule.Pattern != "vendor/" {
+		t.Errorf("unexpected rule: %s:%d:%s", rule.Source, rule.Line, rule.Pattern)
+	}
+}
+
+func TestIgnored_ReportsNegatedRule(t *testing.T) {
+	m := setupMatcher(t, map[string]string{
+		".gitloomignore": "*.log\n!keep.log\n",
+	})
+
+	ignored, rule, err := m.Ignored("keep.log", false)
+	if err != nil {
+		t.Fatalf("Ignored returned error: %v", err)
+	}
+	if ignored || rule == nil || !rule.Negated() || rule.Line != 2 {
+		t.Fatalf("expected keep.log to be re-included by line 2, got ignored=%v rule=%+v", ignored, rule)
+	}
+}
+
+func TestIgnored_NilMatcher(t *testing.T) {
+	var m *ignore.Matcher
+	if ignored, _, _ := m.Ignored("anything", false); ignored {
+		t.Fatalf("expected nil matcher to ignore nothing")
+	}
+}
+
+func TestIgnored_GitCompatibleReadsGitignore(t *testing.T) {
+	tempDir := t.TempDir()
+	r := repo.NewRepo(tempDir)
+	if _, err := r.InitGitCompatible(); err != nil {
+		t.Fatalf("failed to init repository: %v", err)
+	}
+
+	files := map[string]string{
+		".gitignore":        "*.log\n",
+		".gitloomignore":    "*.tmp\n",
+		".git/info/exclude": "secret\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, filepath.FromSlash(name)), []byte(content), repo.FilePerm); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	m, err := ignore.New(r)
+	if err != nil {
+		t.Fatalf("ignore.New returned error: %v", err)
+	}
+	cases := map[string]bool{"app.log": true, "scratch.tmp": false, "secret": true}
+	for path, want := range cases {
+		ignored, _, err := m.Ignored(path, false)
+		if err != nil {
+			t.Fatalf("Ignored(%q) returned error: %v", path, err)
+		}
+		if ignored != want {
+			t.Errorf("Ignored(%q) = %v, want %v", path, ignored, want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package ignore
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Rule is a single pattern line from an ignore file.
+type Rule struct {
+	Source  string // file the rule came from, relative to the working tree root
+	Line    int
+	Pattern string // the pattern as written, after trimming
+
+	negate  bool
+	dirOnly bool
+	base    string // directory containing Source, "" for the root
+	re      *regexp.Regexp
+}
+
+// Negated reports whether the rule re-includes paths ("!pattern").
+func (rule *Rule) Negated() bool {
+	return rule.negate
+}
+
+// parseRule turns one line of an ignore file into a rule. It returns nil
+// for blank lines and comments.
+func parseRule(line string, source, base string, lineNo int) *Rule {
+	line = strings.TrimSuffix(line, "\r")
+	line = trimTrailingSpaces(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	rule := &Rule{Source: source, Line: lineNo, Pattern: line, base: base}
+
+	p := line
+	if strings.HasPrefix(p, "!") {
+		rule.negate = true
+		p = p[1:]
+	} else if strings.HasPrefix(p, `\!`) || strings.HasPrefix(p, `\#`) {
+		p = p[1:]
+	}
+
+	if strings.HasSuffix(p, "/") {
+		rule.dirOnly = true
+		p = strings.TrimRight(p, "/")
+	}
+	if p == "" {
+		return nil
+	}
+
+	// A slash anywhere but the end anchors the pattern to base; otherwise
+	// it matches a name at any depth below base.
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+
+	prefix := ""
+	if !anchored {
+		prefix = "(?:.*/)?"
+	}
+
+	re, err := regexp.Compile("^" + prefix + globToRegexp(p) + "$")
+	if err != nil {
+		return nil
+	}
+	rule.re = re
+	return rule
+}
+
+// matches reports whether rel, relative to the working tree root, matches the rule.
+func (rule *Rule) matches(rel string, isDir bool) bool {
+	if rule.dirOnly && !isDir {
+		return false
+	}
+	if rule.base != "" {
+		if !strings.HasPrefix(rel, rule.base+"/") {
+			return false
+		}
+		rel = rel[len(rule.base)+1:]
+	}
+	return rule.re.MatchString(rel)
+}
+
+// globToRegexp converts a gitignore glob into a regular expression. "*"
+// and "?" never match "/", while "**" between slashes matches any number
+// of directories.
+func globToRegexp(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); {
+		switch {
+		case i == 0 && strings.HasPrefix(p, "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 3
+		case p[i:] == "/**":
+			b.WriteString("/.*")
+			i += 3
+		case strings.HasPrefix(p[i:], "/**/"):
+			b.WriteString("/(?:.*/)?")
+			i += 4
+		case p == "**":
+			b.WriteString(".*")
+			i += 2
+		case p[i] == '*':
+			b.WriteString("[^/]*")
+			i++
+		case p[i] == '?':
+			b.WriteString("[^/]")
+			i++
+		case p[i] == '[':
+			end := strings.IndexByte(p[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				i++
+				continue
+			}
+			class := p[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 2
+		case p[i] == '\\' && i+1 < len(p):
+			b.WriteString(regexp.QuoteMeta(p[i+1 : i+2]))
+			i += 2
+		default:
+			b.WriteString(regexp.QuoteMeta(p[i : i+1]))
+			i++
+		}
+	}
+	return b.String()
+}
+
+// trimTrailingSpaces removes trailing spaces unless they are escaped with a backslash.
+func trimTrailingSpaces(s string) string {
+	for strings.HasSuffix(s, " ") && !strings.HasSuffix(s, `\ `) {
+		s = s[:len(s)-1]
+	}
+	if strings.HasSuffix(s, `\ `) {
+		s = s[:len(s)-2] + " "
+	}
+	return s
+}
@@ -0,0 +1,175 @@
+package index
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/ignore"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/worktree"
+)
+
+// ModeRegular is the index mode of a regular, non-executable file.
+const ModeRegular = 0100644
+
+// ParseMode converts the mode of a tree entry that can be staged, such as
+// "100755", into an index mode. Regular files get 100644 or 100755 as git
+// gives them, even when an old tree recorded e.g. 100664. Malformed modes
+// and those of other types, trees included, are rejected.
+func ParseMode(mode string) (uint32, error) {
+	m, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid file mode %q", mode)
+	}
+	switch m &^ 0777 {
+	case 0100000:
+		if m&0100 != 0 {
+			return 0100755, nil
+		}
+		return ModeRegular, nil
+	case 0120000, 0160000:
+		if m&0777 == 0 {
+			return uint32(m), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid file mode %q", mode)
+}
+
+// Add stages the given files or directories (recursively) in the index of r.
+// Paths that no longer exist in the working tree are removed from the index.
+// Ignored files are skipped inside directories and rejected when named
+// explicitly, unless they are already tracked.
+func Add(r *repo.Repo, paths []string) error {
+	idx, err := Read(r)
+	if err != nil {
+		return err
+	}
+
+	m, err := ignore.New(r)
+	if err != nil {
+		return err
+	}
+
+	root := r.WorkTree()
+	for _, p := range paths {
+		rel, err := RelPath(root, p)
+		if err != nil {
+			return err
+		}
+
+		fi, err := os.Lstat(filepath.Join(root, filepath.FromSlash(rel)))
+		if os.IsNotExist(err) {
+			if !idx.removeUnder(rel) {
+				return fmt.Errorf("pathspec %q did not match any files", p)
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, tracked := idx.Entry(rel); !tracked {
+			ignored, rule, err := m.Ignored(rel, fi.IsDir())
+			if err != nil {
+				return err
+			}
+			if ignored {
+				return fmt.Errorf("path %q is ignored by %s:%d:%s", p, rule.Source, rule.Line, rule.Pattern)
+			}
+		}
+
+		if err := idx.addPath(r, m, rel, fi); err != nil {
+			return err
+		}
+	}
+
+	return idx.Write(r)
+}
+
+func (idx *Index) addPath(r *repo.Repo, m *ignore.Matcher, rel string, fi os.FileInfo) error {
+	if !fi.IsDir() {
+		return idx.addFile(r, rel, fi)
+	}
+
+	// Drop entries for files deleted from this directory
+	root := r.WorkTree()
+	kept := idx.Entries[:0]
+	for _, e := range idx.Entries {
+		if rel == "" || strings.HasPrefix(e.Path, rel+"/") {
+			if _, err := os.Lstat(filepath.Join(root, filepath.FromSlash(e.Path))); os.IsNotExist(err) {
+				continue
+			}
+		}
+		kept = append(kept, e)
+	}
+	idx.Entries = kept
+
+	return worktree.Walk(root, rel, m, func(childRel string, d fs.DirEntry) error {
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return idx.addFile(r, childRel, info)
+	})
+}
+
+func (idx *Index) addFile(r *repo.Repo, rel string, fi os.FileInfo) error {
+	mode, ok := worktree.Mode(fi)
+	if !ok {
+		return nil
+	}
+
+	full := filepath.Join(r.WorkTree(), filepath.FromSlash(rel))
+	hash, err := worktree.HashFile(r, full, fi, true)
+	if err != nil {
+		return err
+	}
+
+	e, err := NewEntry(rel, mode, hash, fi)
+	if err != nil {
+		return err
+	}
+	idx.Set(e)
+	return nil
+}
+
+// removeUnder drops path and everything staged beneath it.
+func (idx *Index) removeUnder(path string) bool {
+	removed := false
+	kept := idx.Entries[:0]
+	for _, e := range idx.Entries {
+		if path == "" || e.Path == path || strings.HasPrefix(e.Path, path+"/") {
+			removed = true
+			continue
+		}
+		kept = append(kept, e)
+	}
+	idx.Entries = kept
+	return removed
+}
+
+// RelPath converts p into a slash separated path relative to root.
+func RelPath(root, p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside repository at %s", p, root)
+	}
+	if rel == "." {
+		return "", nil
+	}
+	return filepath.ToSlash(rel), nil
+}
@@ -0,0 +1,357 @@
+package index
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+const (
+	IndexFile = "index"
+
+	signature = "DIRC"
+	version   = 2
+	// Indexes with extended flags are written as version 3.
+	extendedVersion = 3
+
+	// Fixed size part of an on-disk entry: ten 32-bit stat fields,
+	// a 20 byte hash and 16 bits of flags.
+	entryHeaderSize = 62
+	maxNameLength   = 0xFFF
+
+	// Version 3 entries with this flag carry 16 more bits of extended
+	// flags (skip-worktree, intent-to-add) after the regular ones.
+	extendedFlag = 0x4000
+
+	// Extended flags git defines; others are refused rather than dropped.
+	IntentToAdd  = 0x2000
+	SkipWorktree = 0x4000
+
+	// The merge stage is kept in two bits of the flags.
+	stageShift = 12
+	stageMask  = 0x3
+)
+
+// Merge stages of an unmerged path. Resolved paths have stage 0 and only
+// conflicted ones keep the versions of the merge base, the current branch
+// and the branch being merged in stages 1, 2 and 3.
+const (
+	StageBase   = 1
+	StageOurs   = 2
+	StageTheirs = 3
+)
+
+// Entry records the staged state of a single file. Path is slash
+// separated and relative to the root of the working tree.
+type Entry struct {
+	CTime time.Time
+	MTime time.Time
+	Dev   uint32
+	Ino   uint32
+	Mode  uint32
+	UID   uint32
+	GID   uint32
+	Size  uint32
+	Hash  string
+	Path  string
+	Stage int // 0 unless the path has a merge conflict
+
+	// ExtFlags holds the version 3 extended flags, such as SkipWorktree
+	// set by git in sparse checkouts, so that they survive a rewrite.
+	ExtFlags uint16
+}
+
+// Index is the staging area stored in .gitloom/index, kept sorted by path
+// and then by stage.
+type Index struct {
+	Entries []Entry
+
+	// modTime is when the index file was last written; entries modified
+	// at or after it cannot be trusted to be clean from stat data alone.
+	modTime time.Time
+}
+
+// Read loads the index of r. A missing index file yields an empty index.
+func Read(r *repo.Repo) (*Index, error) {
+	path := filepath.Join(r.Path, IndexFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Index{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if fi, err := os.Stat(path); err == nil {
+		idx.modTime = fi.ModTime()
+	}
+	return idx, nil
+}
+
+// Parse decodes a version 2 or 3 "DIRC" index file, as written by gitloom
+// and git. Extended flags are kept. Optional extensions, such as git's
+// cached trees, are dropped as git allows, and indexes with an extension
+// that must be understood are refused.
+func Parse(data []byte) (*Index, error) {
+	if len(data) < 12+sha1.Size {
+		return nil, errors.New("invalid index: file too short")
+	}
+
+	body := data[:len(data)-sha1.Size]
+	sum := sha1.Sum(body)
+	if !bytes.Equal(sum[:], data[len(data)-sha1.Size:]) {
+		return nil, errors.New("invalid index: checksum mismatch")
+	}
+
+	if string(body[:4]) != signature {
+		return nil, errors.New("invalid index: bad signature")
+	}
+	v := binary.BigEndian.Uint32(body[4:8])
+	if v != 2 && v != 3 {
+		return nil, fmt.Errorf("unsupported index version %d", v)
+	}
+	count := binary.BigEndian.Uint32(body[8:12])
+
+	idx := &Index{Entries: make([]Entry, 0, count)}
+	off := 12
+	for i := uint32(0); i < count; i++ {
+		if off+entryHeaderSize > len(body) {
+			return nil, errors.New("invalid index: truncated entry")
+		}
+		field := func(n int) uint32 {
+			return binary.BigEndian.Uint32(body[off+4*n:])
+		}
+
+		e := Entry{
+			CTime: time.Unix(int64(field(0)), int64(field(1))),
+			MTime: time.Unix(int64(field(2)), int64(field(3))),
+			Dev:   field(4),
+			Ino:   field(5),
+			Mode:  field(6),
+			UID:   field(7),
+			GID:   field(8),
+			Size:  field(9),
+			Hash:  hex.EncodeToString(body[off+40 : off+60]),
+		}
+		flags := binary.BigEndian.Uint16(body[off+60:])
+		e.Stage = int(flags>>stageShift) & stageMask
+
+		// The name is NUL terminated and padded so the entry length is a multiple of 8.
+		headerSize := entryHeaderSize
+		if v == 3 && flags&extendedFlag != 0 {
+			headerSize += 2
+		}
+		nameStart := off + headerSize
+		if nameStart > len(body) {
+			return nil, errors.New("invalid index: truncated entry")
+		}
+		if headerSize > entryHeaderSize {
+			e.ExtFlags = binary.BigEndian.Uint16(body[off+entryHeaderSize:])
+			if e.ExtFlags&^(IntentToAdd|SkipWorktree) != 0 {
+				return nil, fmt.Errorf("unsupported extended flags %#x on %s", e.ExtFlags, string(body[nameStart:]))
+			}
+		}
+		nameEnd := bytes.IndexByte(body[nameStart:], 0)
+		if nameEnd < 0 {
+			return nil, errors.New("invalid index: unterminated entry path")
+		}
+		e.Path = string(body[nameStart : nameStart+nameEnd])
+
+		off += paddedEntryLen(headerSize, len(e.Path))
+		idx.Entries = append(idx.Entries, e)
+	}
+
+	// Extensions follow the entries, each a signature and a size. An
+	// upper case signature marks one that readers may ignore.
+	for off < len(body) {
+		if off+8 > len(body) {
+			return nil, errors.New("invalid index: truncated extension")
+		}
+		sig := string(body[off : off+4])
+		size := int(binary.BigEndian.Uint32(body[off+4:]))
+		if sig[0] < 'A' || sig[0] > 'Z' {
+			return nil, fmt.Errorf("unsupported index extension %q", sig)
+		}
+		if size > len(body)-off-8 {
+			return nil, errors.New("invalid index: truncated extension")
+		}
+		off += 8 + size
+	}
+
+	return idx, nil
+}
+
+// Write stores the index in r, replacing the previous one atomically.
+func (idx *Index) Write(r *repo.Repo) error {
+	path := filepath.Join(r.Path, IndexFile)
+	lockPath := path + ".lock"
+
+	f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, repo.FilePerm)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("index is locked: %s exists", lockPath)
+		}
+		return err
+	}
+
+	if _, err := f.Write(idx.Serialize()); err != nil {
+		f.Close()
+		os.Remove(lockPath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(lockPath)
+		return err
+	}
+
+	return os.Rename(lockPath, path)
+}
+
+// Serialize encodes the index in the version 2 "DIRC" format, or version 3
+// if any entry has extended flags, including the trailing checksum.
+func (idx *Index) Serialize() []byte {
+	v := version
+	for _, e := range idx.Entries {
+		if e.ExtFlags != 0 {
+			v = extendedVersion
+			break
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(signature)
+	binary.Write(&buf, binary.BigEndian, uint32(v))
+	binary.Write(&buf, binary.BigEndian, uint32(len(idx.Entries)))
+
+	for _, e := range idx.Entries {
+		headerSize := entryHeaderSize
+		if e.ExtFlags != 0 {
+			headerSize += 2
+		}
+		entry := make([]byte, paddedEntryLen(headerSize, len(e.Path)))
+		fields := []uint32{
+			uint32(e.CTime.Unix()), uint32(e.CTime.Nanosecond()),
+			uint32(e.MTime.Unix()), uint32(e.MTime.Nanosecond()),
+			e.Dev, e.Ino, e.Mode, e.UID, e.GID, e.Size,
+		}
+		for i, v := range fields {
+			binary.BigEndian.PutUint32(entry[4*i:], v)
+		}
+
+		hashBytes, _ := hex.DecodeString(e.Hash)
+		copy(entry[40:60], hashBytes)
+
+		nameLen := len(e.Path)
+		if nameLen > maxNameLength {
+			nameLen = maxNameLength
+		}
+		flags := uint16(e.Stage&stageMask)<<stageShift | uint16(nameLen)
+		if e.ExtFlags != 0 {
+			flags |= extendedFlag
+			binary.BigEndian.PutUint16(entry[entryHeaderSize:], e.ExtFlags)
+		}
+		binary.BigEndian.PutUint16(entry[60:62], flags)
+		copy(entry[headerSize:], e.Path)
+
+		buf.Write(entry)
+	}
+
+	sum := sha1.Sum(buf.Bytes())
+	buf.Write(sum[:])
+	return buf.Bytes()
+}
+
+// paddedEntryLen returns the on-disk size of an entry, which is NUL padded
+// (with at least one NUL) to a multiple of 8 bytes.
+func paddedEntryLen(headerSize, nameLen int) int {
+	return (headerSize + nameLen + 8) &^ 7
+}
+
+// Entry returns the resolved entry stored for path. Paths with a merge
+// conflict have none.
+func (idx *Index) Entry(path string) (Entry, bool) {
+	i, found := idx.find(path)
+	if !found {
+		return Entry{}, false
+	}
+	return idx.Entries[i], true
+}
+
+// Set inserts e, replacing any existing entry with the same path and
+// stage. Staging a resolved entry drops the path's conflict stages, and
+// staging a conflict stage drops its resolved entry.
+func (idx *Index) Set(e Entry) {
+	lo, hi := idx.span(e.Path)
+	entries := make([]Entry, 0, hi-lo+1)
+	for _, old := range idx.Entries[lo:hi] {
+		if e.Stage != 0 && old.Stage != 0 && old.Stage != e.Stage {
+			entries = append(entries, old)
+		}
+	}
+	entries = append(entries, e)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Stage < entries[j].Stage })
+	idx.Entries = slices.Replace(idx.Entries, lo, hi, entries...)
+}
+
+// Remove deletes every entry for path, including conflict stages, and
+// reports whether there was any.
+func (idx *Index) Remove(path string) bool {
+	lo, hi := idx.span(path)
+	idx.Entries = append(idx.Entries[:lo], idx.Entries[hi:]...)
+	return lo < hi
+}
+
+// Unmerged returns the paths that have merge conflicts, sorted.
+func (idx *Index) Unmerged() []string {
+	var paths []string
+	for _, e := range idx.Entries {
+		if e.Stage != 0 && (len(paths) == 0 || paths[len(paths)-1] != e.Path) {
+			paths = append(paths, e.Path)
+		}
+	}
+	return paths
+}
+
+// Stages returns the conflict stages of path, indexed by stage; missing
+// stages have an empty path.
+func (idx *Index) Stages(path string) [4]Entry {
+	var stages [4]Entry
+	lo, hi := idx.span(path)
+	for _, e := range idx.Entries[lo:hi] {
+		stages[e.Stage] = e
+	}
+	return stages
+}
+
+// find returns the position of the resolved entry for path.
+func (idx *Index) find(path string) (int, bool) {
+	lo, hi := idx.span(path)
+	return lo, lo < hi && idx.Entries[lo].Stage == 0
+}
+
+// span returns the range of entries for path, of any stage.
+func (idx *Index) span(path string) (int, int) {
+	lo := sort.Search(len(idx.Entries), func(i int) bool {
+		return idx.Entries[i].Path >= path
+	})
+	hi := lo
+	for hi < len(idx.Entries) && idx.Entries[hi].Path == path {
+		hi++
+	}
+	return lo, hi
+}
@@ -0,0 +1,295 @@
+package index_test
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MahendraDani/gitloom.git/internal/index"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/testrepo"
+)
+
+func TestSerializeAndParse(t *testing.T) {
+	idx := &index.Index{}
+	idx.Set(index.Entry{
+		MTime: time.Unix(1700000000, 123),
+		CTime: time.Unix(1700000000, 456),
+		Mode:  index.ModeRegular,
+		Size:  12,
+		Hash:  "3b18e512dba79e4c8300dd08aeb37f8e728b8dad",
+		Path:  "src/main.go",
+	})
+	idx.Set(index.Entry{
+		Mode: index.ModeRegular,
+		Hash: "3b18e512dba79e4c8300dd08aeb37f8e728b8dad",
+		Path: "README",
+	})
+
+	parsed, err := index.Parse(idx.Serialize())
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	if len(parsed.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(parsed.Entries))
+	}
+	if parsed.Entries[0].Path != "README" || parsed.Entries[1].Path != "src/main.go" {
+		t.Fatalf("entries not sorted by path: %q, %q", parsed.Entries[0].Path, parsed.Entries[1].Path)
+	}
+
+	e := parsed.Entries[1]
+	if !e.MTime.Equal(time.Unix(1700000000, 123)) || !e.CTime.Equal(time.Unix(1700000000, 456)) {
+		t.Errorf("unexpected times: mtime=%v ctime=%v", e.MTime, e.CTime)
+	}
+	if e.Size != 12 || e.Mode != index.ModeRegular || e.Hash != "3b18e512dba79e4c8300dd08aeb37f8e728b8dad" {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+}
+
+func TestConflictStages(t *testing.T) {
+	const hash = "3b18e512dba79e4c8300dd08aeb37f8e728b8dad"
+	idx := &index.Index{}
+	idx.Set(index.Entry{Mode: index.ModeRegular, Hash: hash, Path: "a"})
+	idx.Set(index.Entry{Mode: index.ModeRegular, Hash: hash, Path: "c"})
+	for _, stage := range []int{index.StageTheirs, index.StageBase, index.StageOurs} {
+		idx.Set(index.Entry{Mode: index.ModeRegular, Hash: hash, Path: "b", Stage: stage})
+	}
+
+	parsed, err := index.Parse(idx.Serialize())
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	var got []string
+	for _, e := range parsed.Entries {
+		got = append(got, fmt.Sprintf("%s:%d", e.Path, e.Stage))
+	}
+	if want := "a:0 b:1 b:2 b:3 c:0"; strings.Join(got, " ") != want {
+		t.Fatalf("entries = %v, want %s", got, want)
+	}
+	if unmerged := parsed.Unmerged(); len(unmerged) != 1 || unmerged[0] != "b" {
+		t.Fatalf("Unmerged() = %v, want [b]", unmerged)
+	}
+	if _, ok := parsed.Entry("b"); ok {
+		t.Fatal("an unmerged path must not have a resolved entry")
+	}
+
+	// Staging the resolution replaces all stages
+	parsed.Set(index.Entry{Mode: index.ModeRegular, Hash: hash, Path: "b"})
+	if len(parsed.Entries) != 3 || len(parsed.Unmerged()) != 0 {
+		t.Fatalf("expected the conflict to be resolved, got %+v", parsed.Entries)
+	}
+}
+
+func TestParse_ChecksumMismatch(t *testing.T) {
+	idx := &index.Index{}
+	idx.Set(index.Entry{Mode: index.ModeRegular, Hash: "3b18e512dba79e4c8300dd08aeb37f8e728b8dad", Path: "a"})
+
+	data := idx.Serialize()
+	data[len(data)-1] ^= 0xff
+
+	if _, err := index.Parse(data); err == nil {
+		t.Fatalf("expected checksum error, got nil")
+	}
+}
+
+func TestAdd(t *testing.T) {
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
+
+	if err := os.WriteFile(filepath.Join(tempDir, "hello.txt"), []byte("hello world\n"), repo.FilePerm); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(tempDir, "lib"), repo.DirPerm); err != nil {
+		t.Fatalf("failed to create lib dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "lib", "util.txt"), []byte("util\n"), repo.FilePerm); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	if err := index.Add(r, []string{filepath.Join(tempDir, "hello.txt"), filepath.Join(tempDir, "lib")}); err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+
+	idx, err := index.Read(r)
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+
+	e, ok := idx.Entry("hello.txt")
+	if !ok {
+		t.Fatalf("expected hello.txt to be staged")
+	}
+	if e.Hash != "3b18e512dba79e4c8300dd08aeb37f8e728b8dad" || e.Size != 12 {
+		t.Errorf("unexpected entry for hello.txt: %+v", e)
+	}
+	if _, ok := idx.Entry("lib/util.txt"); !ok {
+		t.Fatalf("expected lib/util.txt to be staged")
+	}
+
+	// Deleting a file and adding it again removes it from the index
+	if err := os.Remove(filepath.Join(tempDir, "hello.txt")); err != nil {
+		t.Fatalf("failed to remove file: %v", err)
+	}
+	if err := index.Add(r, []string{filepath.Join(tempDir, "hello.txt")}); err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+
+	idx, err = index.Read(r)
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	if _, ok := idx.Entry("hello.txt"); ok {
+		t.Fatalf("expected hello.txt to be removed from the index")
+	}
+	if len(idx.Entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(idx.Entries))
+	}
+}
+
+func TestAdd_UnknownPath(t *testing.T) {
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
+
+	if err := index.Add(r, []string{filepath.Join(tempDir, "missing.txt")}); err == nil {
+		t.Fatalf("expected error for unknown path, got nil")
+	}
+}
+
+func TestAdd_ExecutableAndSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("executable bits and symlinks need a POSIX filesystem")
+	}
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
+
+	if err := os.WriteFile(filepath.Join(tempDir, "run.sh"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+	if err := os.Symlink("run.sh", filepath.Join(tempDir, "link")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	if err := index.Add(r, []string{tempDir}); err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+	idx, err := index.Read(r)
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+
+	if e, _ := idx.Entry("run.sh"); e.Mode != 0100755 {
+		t.Errorf("expected run.sh to be staged as 100755, got %o", e.Mode)
+	}
+	e, _ := idx.Entry("link")
+	if e.Mode != 0120000 {
+		t.Errorf("expected link to be staged as 120000, got %o", e.Mode)
+	}
+	// The blob of a symlink holds its target
+	if _, data, err := r.ReadRawObject(e.Hash); err != nil || string(data) != "run.sh" {
+		t.Errorf("expected symlink blob %q, got %q (%v)", "run.sh", data, err)
+	}
+}
+
+func TestParse_Version3(t *testing.T) {
+	idx := &index.Index{}
+	idx.Set(index.Entry{Mode: index.ModeRegular, Hash: "3b18e512dba79e4c8300dd08aeb37f8e728b8dad", Path: "a"})
+	idx.Set(index.Entry{Mode: index.ModeRegular, Hash: "3b18e512dba79e4c8300dd08aeb37f8e728b8dad", Path: "b"})
+	v2 := idx.Serialize()
+
+	// Rewrite as version 3 with extended flags (intent-to-add) on "a",
+	// which moves its name two bytes further and changes its padding
+	var body []byte
+	body = append(body, v2[:12]...)
+	binary.BigEndian.PutUint32(body[4:], 3)
+	entryA := append([]byte{}, v2[12:12+62]...)
+	binary.BigEndian.PutUint16(entryA[60:], 0x4000|1)
+	entryA = append(entryA, 0x20, 0x00, 'a')
+	for len(entryA)%8 != 0 || entryA[len(entryA)-1] != 0 {
+		entryA = append(entryA, 0)
+	}
+	body = append(body, entryA...)
+	body = append(body, v2[12+64:len(v2)-20]...)
+	sum := sha1.Sum(body)
+	data := append(body, sum[:]...)
+
+	parsed, err := index.Parse(data)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if len(parsed.Entries) != 2 || parsed.Entries[0].Path != "a" || parsed.Entries[1].Path != "b" {
+		t.Fatalf("unexpected entries: %+v", parsed.Entries)
+	}
+	if parsed.Entries[0].ExtFlags != index.IntentToAdd || parsed.Entries[1].ExtFlags != 0 {
+		t.Fatalf("extended flags not kept: %+v", parsed.Entries)
+	}
+
+	// Rewriting keeps the extended flags, so the index stays version 3
+	out := parsed.Serialize()
+	if v := binary.BigEndian.Uint32(out[4:]); v != 3 {
+		t.Fatalf("expected version 3 index, got %d", v)
+	}
+	if string(out) != string(data) {
+		t.Fatalf("version 3 index does not round-trip")
+	}
+}
+
+func TestParse_Extensions(t *testing.T) {
+	idx := &index.Index{}
+	idx.Set(index.Entry{Mode: index.ModeRegular, Hash: "3b18e512dba79e4c8300dd08aeb37f8e728b8dad", Path: "a"})
+	v2 := idx.Serialize()
+
+	withExtension := func(sig string) []byte {
+		body := append([]byte{}, v2[:len(v2)-20]...)
+		body = append(body, sig...)
+		body = binary.BigEndian.AppendUint32(body, 4)
+		body = append(body, 0, 0, 0, 0)
+		sum := sha1.Sum(body)
+		return append(body, sum[:]...)
+	}
+
+	// Optional extensions such as the cached tree can be dropped
+	parsed, err := index.Parse(withExtension("TREE"))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if len(parsed.Entries) != 1 || parsed.Entries[0].Path != "a" {
+		t.Fatalf("unexpected entries: %+v", parsed.Entries)
+	}
+
+	// Required ones, such as a split index link, cannot
+	if _, err := index.Parse(withExtension("link")); err == nil {
+		t.Fatalf("expected an error for a required extension")
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		mode string
+		want uint32
+	}{
+		{"100644", 0100644},
+		{"100755", 0100755},
+		{"100664", 0100644}, // written by old versions of git
+		{"120000", 0120000},
+		{"160000", 0160000},
+	}
+	for _, tt := range tests {
+		got, err := index.ParseMode(tt.mode)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseMode(%q) = %o, %v; want %o", tt.mode, got, err, tt.want)
+		}
+	}
+
+	for _, mode := range []string{"", "10064x", "40000", "644", "120777"} {
+		if _, err := index.ParseMode(mode); err == nil {
+			t.Errorf("expected an error for mode %q", mode)
+		}
+	}
+}
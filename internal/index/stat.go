@@ -0,0 +1,50 @@
+package index
+
+import (
+	"fmt"
+	"os"
+)
+
+// NewEntry returns the entry staging blob hash at path with the given tree
+// entry mode, caching fi, which must describe the file in the working tree.
+func NewEntry(path, mode, hash string, fi os.FileInfo) (Entry, error) {
+	m, err := ParseMode(mode)
+	if err != nil {
+		return Entry{}, fmt.Errorf("%s: %w", path, err)
+	}
+	e := Entry{Mode: m, Hash: hash, Path: path}
+	fillStat(&e, fi)
+	return e, nil
+}
+
+// StatMatches reports whether fi still describes the file recorded in e.
+// A match means the file can be assumed unchanged without rehashing it,
+// unless the entry IsRacy.
+func (e Entry) StatMatches(fi os.FileInfo) bool {
+	var cur Entry
+	fillStat(&cur, fi)
+
+	return cur.MTime.Equal(e.MTime) &&
+		cur.CTime.Equal(e.CTime) &&
+		cur.Size == e.Size &&
+		cur.Ino == e.Ino &&
+		cur.Dev == e.Dev &&
+		cur.UID == e.UID &&
+		cur.GID == e.GID
+}
+
+// IsRacy reports whether e was modified so close to when the index was
+// written that a later change may not have altered its mtime.
+func (idx *Index) IsRacy(e Entry) bool {
+	return idx.modTime.IsZero() || !e.MTime.Before(idx.modTime)
+}
+
+// RefreshStat replaces the cached stat data for path with fi, keeping its hash.
+func (idx *Index) RefreshStat(path string, fi os.FileInfo) bool {
+	i, found := idx.find(path)
+	if !found {
+		return false
+	}
+	fillStat(&idx.Entries[i], fi)
+	return true
+}
@@ -0,0 +1,26 @@
+//go:build linux
+
+package index
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// fillStat copies the stat data git caches in the index from fi.
+func fillStat(e *Entry, fi os.FileInfo) {
+	e.MTime = fi.ModTime()
+	e.CTime = fi.ModTime()
+	e.Size = uint32(fi.Size())
+
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+	e.CTime = time.Unix(int64(st.Ctim.Sec), int64(st.Ctim.Nsec))
+	e.Dev = uint32(st.Dev)
+	e.Ino = uint32(st.Ino)
+	e.UID = st.Uid
+	e.GID = st.Gid
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package index
+
+import "os"
+
+// fillStat copies the portable subset of stat data into e.
+func fillStat(e *Entry, fi os.FileInfo) {
+	e.MTime = fi.ModTime()
+	e.CTime = fi.ModTime()
+	e.Size = uint32(fi.Size())
+}
@@ -0,0 +1,204 @@
+// Package interop moves history between a gitloom repository and a git
+// repository, in either direction.
+package interop
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/ignore"
+	"github.com/MahendraDani/gitloom.git/internal/index"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// Result records what Copy transferred.
+type Result struct {
+	Objects int        // loose objects copied
+	Packs   int        // packs copied
+	Refs    []refs.Ref // refs created or moved
+	Index   bool       // whether the index was copied
+}
+
+// OpenGitDir opens the git repository at path, which may be a working
+// tree containing a .git directory, a .git directory or a bare repository.
+func OpenGitDir(path string) (*repo.Repo, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if fi, err := os.Stat(filepath.Join(abs, repo.GitDirName)); err == nil && fi.IsDir() {
+		abs = filepath.Join(abs, repo.GitDirName)
+	}
+
+	for _, name := range []string{repo.HeadFile, repo.ObjectsDir, repo.RefsDir} {
+		if _, err := os.Stat(filepath.Join(abs, name)); err != nil {
+			return nil, fmt.Errorf("not a git repository: %s", path)
+		}
+	}
+	return repo.NewRepo(abs), nil
+}
+
+// Copy makes every object and ref of src available in dst. Packs are
+// copied whole and loose objects one at a time, skipping objects dst
+// already has, before any ref is written, so dst never holds a ref to a
+// missing object. Refs in dst are overwritten with the values from src,
+// and HEAD is only set when dst has no commits on its current branch yet.
+// When both repositories belong to the same working tree, the index is
+// copied too so that the working tree stays clean in dst, and .gitloom is
+// excluded in the git repository so git does not list it as untracked.
+func Copy(src, dst *repo.Repo) (*Result, error) {
+	res := &Result{}
+
+	packs, err := src.Packs()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range packs {
+		added, err := dst.AddPack(p.Path)
+		if err != nil {
+			return nil, err
+		}
+		if added {
+			res.Packs++
+		}
+	}
+
+	loose, err := src.LooseObjects()
+	if err != nil {
+		return nil, err
+	}
+	for _, hash := range loose {
+		if dst.HasObject(hash) {
+			continue
+		}
+		if err := repo.CopyObject(src, dst, hash); err != nil {
+			return nil, err
+		}
+		res.Objects++
+	}
+
+	if res.Refs, err = copyRefs(src, dst); err != nil {
+		return nil, err
+	}
+	if err := copyHead(src, dst); err != nil {
+		return nil, err
+	}
+	if src.Bare() || dst.Bare() || src.WorkTree() != dst.WorkTree() {
+		return res, nil
+	}
+	if res.Index, err = copyIndex(src, dst); err != nil {
+		return nil, err
+	}
+	for _, r := range []*repo.Repo{src, dst} {
+		if r.GitCompatible() {
+			if err := excludeRepoDir(r); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, nil
+}
+
+// copyRefs writes every ref of src to dst and returns the ones that changed.
+// Symbolic refs such as refs/remotes/origin/HEAD stay symbolic.
+func copyRefs(src, dst *repo.Repo) ([]refs.Ref, error) {
+	all, err := refs.List(src, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []refs.Ref
+	for _, ref := range all {
+		target, err := refs.ReadSymbolic(src, ref.Name)
+		if err == nil {
+			if current, err := refs.ReadSymbolic(dst, ref.Name); err == nil && current == target {
+				continue
+			}
+			if err := refs.SetSymbolic(dst, ref.Name, target); err != nil {
+				return nil, err
+			}
+			changed = append(changed, ref)
+			continue
+		}
+		// Packed refs have no file of their own and are never symbolic
+		if !errors.Is(err, refs.ErrNotSymbolic) && !errors.Is(err, refs.ErrNotFound) {
+			return nil, err
+		}
+
+		if current, err := refs.Resolve(dst, ref.Name); err == nil && current == ref.Hash {
+			continue
+		}
+		if err := refs.UpdateNoDeref(dst, ref.Name, ref.Hash, ""); err != nil {
+			return nil, err
+		}
+		changed = append(changed, ref)
+	}
+	return changed, nil
+}
+
+// copyHead points HEAD of dst where HEAD of src points, unless dst already
+// has a commit checked out.
+func copyHead(src, dst *repo.Repo) error {
+	if _, err := refs.Resolve(dst, refs.Head); !errors.Is(err, refs.ErrNotFound) {
+		return err
+	}
+
+	target, err := refs.ReadSymbolic(src, refs.Head)
+	if err == nil {
+		return refs.SetSymbolic(dst, refs.Head, target)
+	}
+	if errors.Is(err, refs.ErrNotFound) {
+		return nil
+	}
+	if !errors.Is(err, refs.ErrNotSymbolic) {
+		return err
+	}
+
+	hash, err := refs.Resolve(src, refs.Head)
+	if err != nil {
+		return err
+	}
+	return refs.UpdateNoDeref(dst, refs.Head, hash, "")
+}
+
+func copyIndex(src, dst *repo.Repo) (bool, error) {
+	if _, err := os.Stat(filepath.Join(src.Path, index.IndexFile)); os.IsNotExist(err) {
+		return false, nil
+	}
+
+	idx, err := index.Read(src)
+	if err != nil {
+		return false, err
+	}
+	return true, idx.Write(dst)
+}
+
+// excludeRepoDir adds the .gitloom directory to info/exclude of the git
+// repository r unless it is already listed.
+func excludeRepoDir(r *repo.Repo) error {
+	path := filepath.Join(r.Path, filepath.FromSlash(ignore.ExcludeFile))
+	pattern := "/" + repo.RepoDirName + "/"
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == pattern {
+			return nil
+		}
+	}
+
+	if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		data = append(data, '\n')
+	}
+	data = append(data, pattern+"\n"...)
+	if err := os.MkdirAll(filepath.Dir(path), repo.DirPerm); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, repo.FilePerm)
+}
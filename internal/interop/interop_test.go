@@ -0,0 +1,150 @@
+package interop_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MahendraDani/gitloom.git/internal/interop"
+	"github.com/MahendraDani/gitloom.git/internal/pack"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/status"
+	"github.com/MahendraDani/gitloom.git/internal/testrepo"
+)
+
+// setupRepo makes two commits in a gitloom repository, the first of which
+// is packed while the second stays loose.
+func setupRepo(t *testing.T) (*repo.Repo, string) {
+	t.Helper()
+	r := testrepo.New(t)
+
+	for i, content := range []string{"first\n", "second\n"} {
+		testrepo.WriteFile(t, r.WorkTree(), "file.txt", content)
+		testrepo.CommitAll(t, r, content)
+		if i == 0 {
+			loose, err := r.LooseObjects()
+			if err != nil {
+				t.Fatalf("LooseObjects failed: %v", err)
+			}
+			if _, err := r.WritePack(loose, pack.Options{}); err != nil {
+				t.Fatalf("WritePack failed: %v", err)
+			}
+			for _, hash := range loose {
+				if err := r.RemoveLooseObject(hash); err != nil {
+					t.Fatalf("RemoveLooseObject failed: %v", err)
+				}
+			}
+		}
+	}
+
+	head, err := refs.Resolve(r, refs.Head)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	return r, head
+}
+
+func TestCopy_ExportIntoWorkTree(t *testing.T) {
+	src, head := setupRepo(t)
+
+	if err := repo.InitGitDir(filepath.Join(src.WorkTree(), repo.GitDirName), false); err != nil {
+		t.Fatalf("InitGitDir failed: %v", err)
+	}
+	gitRepo, err := interop.OpenGitDir(src.WorkTree())
+	if err != nil {
+		t.Fatalf("OpenGitDir failed: %v", err)
+	}
+
+	res, err := interop.Copy(src, gitRepo)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if res.Packs != 1 || res.Objects != 3 || len(res.Refs) != 1 || !res.Index {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	got, err := refs.Resolve(gitRepo, refs.Head)
+	if err != nil || got != head {
+		t.Fatalf("expected HEAD at %s, got %s (%v)", head, got, err)
+	}
+
+	s, err := status.Compute(gitRepo)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	if !s.Clean() {
+		t.Fatalf("expected a clean working tree, got %+v", s)
+	}
+
+	exclude, err := os.ReadFile(filepath.Join(gitRepo.Path, "info", "exclude"))
+	if err != nil || !strings.Contains(string(exclude), "/.gitloom/") {
+		t.Fatalf("expected .gitloom to be excluded, got %q (%v)", exclude, err)
+	}
+
+	// A second copy has nothing left to do
+	res, err = interop.Copy(src, gitRepo)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if res.Packs != 0 || res.Objects != 0 || len(res.Refs) != 0 {
+		t.Fatalf("expected nothing to copy, got %+v", res)
+	}
+}
+
+func TestCopy_ImportFromBare(t *testing.T) {
+	src, head := setupRepo(t)
+
+	bare := filepath.Join(t.TempDir(), "project.git")
+	if err := repo.InitGitDir(bare, true); err != nil {
+		t.Fatalf("InitGitDir failed: %v", err)
+	}
+	gitRepo, err := interop.OpenGitDir(bare)
+	if err != nil {
+		t.Fatalf("OpenGitDir failed: %v", err)
+	}
+	if _, err := interop.Copy(src, gitRepo); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	// Move the branch into packed-refs and switch HEAD to it, as git gc and
+	// a default branch of master would leave things
+	line := head + " refs/heads/master\n"
+	if err := os.WriteFile(filepath.Join(bare, refs.PackedRefsFile), []byte(line), repo.FilePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(bare, "refs", "heads", "main")); err != nil {
+		t.Fatal(err)
+	}
+	if err := refs.SetSymbolic(gitRepo, refs.Head, "refs/heads/master"); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := testrepo.New(t)
+	res, err := interop.Copy(gitRepo, dst)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if res.Index {
+		t.Fatalf("expected no index to be copied from a bare repository")
+	}
+
+	branch, _, err := refs.CurrentBranch(dst)
+	if err != nil || branch != "refs/heads/master" {
+		t.Fatalf("expected HEAD on refs/heads/master, got %q (%v)", branch, err)
+	}
+	got, err := refs.Resolve(dst, refs.Head)
+	if err != nil || got != head {
+		t.Fatalf("expected HEAD at %s, got %s (%v)", head, got, err)
+	}
+	if _, err := dst.ReadObject(head); err != nil {
+		t.Fatalf("expected the commit to be readable: %v", err)
+	}
+}
+
+func TestOpenGitDir_NotARepository(t *testing.T) {
+	if _, err := interop.OpenGitDir(t.TempDir()); err == nil {
+		t.Fatalf("expected an error for a plain directory")
+	}
+}
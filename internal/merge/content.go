@@ -0,0 +1,129 @@
+package merge
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/diff"
+)
+
+// markerSize is the length of the <<<<<<<, ======= and >>>>>>> markers.
+const markerSize = 7
+
+// Labels name the two sides of a merge in conflict markers, usually
+// "HEAD" and the branch being merged.
+type Labels struct {
+	Ours, Theirs string
+}
+
+// Content merges the changes from base to ours and from base to theirs
+// line by line. Where both sides changed the same lines in different
+// ways, both versions are kept between conflict markers and conflict is
+// reported. Lines both versions agree on at either end of a conflict are
+// kept outside its markers.
+func Content(base, ours, theirs []byte, labels Labels) (merged []byte, conflict bool) {
+	b, o, t := diff.Lines(base), diff.Lines(ours), diff.Lines(theirs)
+	toOurs, toTheirs := matches(b, o), matches(b, t)
+
+	var out bytes.Buffer
+	i, j, k := 0, 0, 0
+	for i < len(b) || j < len(o) || k < len(t) {
+		// Base lines both sides kept in place are stable
+		n := 0
+		for i+n < len(b) && toOurs[i+n] == j+n && toTheirs[i+n] == k+n {
+			n++
+		}
+		if n > 0 {
+			writeLines(&out, b[i:i+n])
+			i, j, k = i+n, j+n, k+n
+			continue
+		}
+
+		// The unstable chunk runs up to the next base line both kept
+		next := i
+		for next < len(b) && (toOurs[next] < 0 || toTheirs[next] < 0) {
+			next++
+		}
+		nextOurs, nextTheirs := len(o), len(t)
+		if next < len(b) {
+			nextOurs, nextTheirs = toOurs[next], toTheirs[next]
+		}
+		if chunk(&out, b[i:next], o[j:nextOurs], t[k:nextTheirs], labels) {
+			conflict = true
+		}
+		i, j, k = next, nextOurs, nextTheirs
+	}
+	return out.Bytes(), conflict
+}
+
+// matches maps every line of a to the line of b it was kept as, or -1 if
+// it was removed.
+func matches(a, b []string) []int {
+	m := make([]int, len(a))
+	for i := range m {
+		m[i] = -1
+	}
+	for _, e := range diff.Diff(a, b, diff.Myers) {
+		if e.Op == diff.Equal {
+			m[e.A] = e.B
+		}
+	}
+	return m
+}
+
+// chunk writes the merge of a region both sides may have changed and
+// reports whether it conflicts.
+func chunk(out *bytes.Buffer, base, ours, theirs []string, labels Labels) bool {
+	switch {
+	case slices.Equal(ours, theirs), slices.Equal(base, theirs):
+		writeLines(out, ours)
+		return false
+	case slices.Equal(base, ours):
+		writeLines(out, theirs)
+		return false
+	}
+
+	prefix := 0
+	for prefix < len(ours) && prefix < len(theirs) && ours[prefix] == theirs[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(ours)-prefix && suffix < len(theirs)-prefix &&
+		ours[len(ours)-1-suffix] == theirs[len(theirs)-1-suffix] {
+		suffix++
+	}
+
+	writeLines(out, ours[:prefix])
+	out.WriteString(marker('<', labels.Ours))
+	writeLines(out, ours[prefix:len(ours)-suffix])
+	terminate(out)
+	out.WriteString(marker('=', ""))
+	writeLines(out, theirs[prefix:len(theirs)-suffix])
+	terminate(out)
+	out.WriteString(marker('>', labels.Theirs))
+	writeLines(out, ours[len(ours)-suffix:])
+	return true
+}
+
+func marker(c byte, label string) string {
+	m := strings.Repeat(string(c), markerSize)
+	if label != "" {
+		m += " " + label
+	}
+	return m + "\n"
+}
+
+// terminate ends the last line written, so a marker after a file's last
+// line, which may lack a newline, starts a line of its own.
+func terminate(out *bytes.Buffer) {
+	if out.Len() > 0 && out.Bytes()[out.Len()-1] != '\n' {
+		out.WriteByte('\n')
+	}
+}
+
+func writeLines(out *bytes.Buffer, lines []string) {
+	for _, l := range lines {
+		out.WriteString(l)
+	}
+}
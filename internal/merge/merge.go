@@ -0,0 +1,305 @@
+// Package merge joins the history of another commit into the current
+// branch: it finds the merge bases of the two commits, merges their trees
+// three ways and records the result as a commit with both as parents.
+package merge
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/branch"
+	"github.com/MahendraDani/gitloom.git/internal/checkout"
+	"github.com/MahendraDani/gitloom.git/internal/commit"
+	"github.com/MahendraDani/gitloom.git/internal/history"
+	"github.com/MahendraDani/gitloom.git/internal/index"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/revision"
+	"github.com/MahendraDani/gitloom.git/internal/tree"
+)
+
+// Options controls Merge.
+type Options struct {
+	// Message of the merge commit; empty for "Merge branch '<rev>'".
+	Message string
+	// NoFF creates a merge commit even when a fast-forward is possible.
+	NoFF bool
+	// FFOnly refuses to merge unless HEAD can be fast-forwarded.
+	FFOnly bool
+	// AllowUnrelated merges commits that share no history.
+	AllowUnrelated bool
+}
+
+// Result describes what Merge did.
+type Result struct {
+	Head   string // commit HEAD pointed to before, empty on an unborn branch
+	Merged string // commit that was merged in
+	Commit string // commit HEAD points to now; empty when stopped by conflicts
+
+	UpToDate    bool // Merged was already part of HEAD's history
+	FastForward bool // HEAD moved to Merged without a merge commit
+
+	// OldTree and NewTree are the trees checked out before and after.
+	OldTree, NewTree string
+
+	Conflicts []Conflict
+	Messages  []string // steps of the tree merge, see TreeResult
+}
+
+// Merge merges the commit rev resolves to into HEAD. When HEAD is an
+// ancestor of it the branch is fast-forwarded; otherwise the trees are
+// merged and a commit with both parents is created. If the merge
+// conflicts, the working tree holds the conflicted files with markers,
+// the index their versions in stages 1, 2 and 3, and MERGE_HEAD and
+// MERGE_MSG wait for the resolution to be committed.
+//
+// The index must match HEAD, and local changes to files the merge touches
+// make it refuse with a *checkout.ConflictError before anything changes.
+func Merge(r *repo.Repo, rev string, opts Options) (*Result, error) {
+	if _, err := refs.Resolve(r, refs.MergeHead); err == nil {
+		return nil, errors.New("you have not concluded your merge (MERGE_HEAD exists); commit the resolution or abort it")
+	} else if !errors.Is(err, refs.ErrNotFound) {
+		return nil, err
+	}
+
+	theirs, err := revision.ResolveCommit(r, rev)
+	if err != nil {
+		return nil, fmt.Errorf("%s - not something we can merge", rev)
+	}
+	theirTree, err := history.CommitTree(r, theirs)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Merged: theirs}
+
+	head, err := refs.Resolve(r, refs.Head)
+	if errors.Is(err, refs.ErrNotFound) {
+		// On an unborn branch the merged commit simply becomes HEAD
+		if err := moveTree(r, "", theirTree); err != nil {
+			return nil, err
+		}
+		res.FastForward, res.Commit, res.NewTree = true, theirs, theirTree
+		return res, refs.Update(r, refs.Head, theirs, refs.ZeroHash)
+	}
+	if err != nil {
+		return nil, err
+	}
+	headTree, err := history.CommitTree(r, head)
+	if err != nil {
+		return nil, err
+	}
+	res.Head, res.Commit = head, head
+	res.OldTree, res.NewTree = headTree, headTree
+
+	bases, err := history.MergeBases(r, head, theirs)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case slices.Contains(bases, theirs):
+		res.UpToDate = true
+		return res, nil
+	case slices.Contains(bases, head) && !opts.NoFF:
+		if err := moveTree(r, headTree, theirTree); err != nil {
+			return nil, err
+		}
+		res.FastForward, res.Commit, res.NewTree = true, theirs, theirTree
+		return res, refs.Update(r, refs.Head, theirs, head)
+	case opts.FFOnly:
+		return nil, errors.New("not possible to fast-forward, aborting")
+	case len(bases) == 0 && !opts.AllowUnrelated:
+		return nil, errors.New("refusing to merge unrelated histories")
+	}
+
+	if err := checkIndex(r, headTree); err != nil {
+		return nil, err
+	}
+	baseTree, err := mergeBaseTree(r, bases)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := Trees(r, baseTree, headTree, theirTree, Labels{Ours: "HEAD", Theirs: rev})
+	if err != nil {
+		return nil, err
+	}
+	res.Conflicts, res.Messages = merged.Conflicts, merged.Messages
+	if err := moveTree(r, headTree, merged.Tree); err != nil {
+		return nil, err
+	}
+
+	message := opts.Message
+	if message == "" {
+		if message, err = defaultMessage(r, rev); err != nil {
+			return nil, err
+		}
+	}
+	if len(merged.Conflicts) > 0 {
+		res.Commit = ""
+		return res, stop(r, theirs, message, merged.Conflicts)
+	}
+
+	hash, err := commit.CommitTree(r, merged.Tree, []string{head, theirs}, message)
+	if err != nil {
+		return nil, err
+	}
+	res.Commit, res.NewTree = hash, merged.Tree
+	return res, refs.Update(r, refs.Head, hash, head)
+}
+
+// Abort gives up a merge stopped by conflicts. The index and working tree
+// are reset to HEAD, which also discards any other uncommitted changes to
+// tracked files, and the merge state is removed.
+func Abort(r *repo.Repo) error {
+	if _, err := refs.Resolve(r, refs.MergeHead); errors.Is(err, refs.ErrNotFound) {
+		return errors.New("there is no merge to abort (MERGE_HEAD missing)")
+	} else if err != nil {
+		return err
+	}
+	head, err := refs.Resolve(r, refs.Head)
+	if err != nil {
+		return err
+	}
+	headTree, err := history.CommitTree(r, head)
+	if err != nil {
+		return err
+	}
+	if err := checkout.Tree(r, headTree, headTree, true); err != nil {
+		return err
+	}
+	return commit.ClearMerge(r)
+}
+
+// stop records a conflicted merge: the conflicted paths are staged with
+// their base, our and their versions, and MERGE_HEAD and MERGE_MSG are
+// written so that committing the resolution concludes the merge.
+func stop(r *repo.Repo, theirs, message string, conflicts []Conflict) error {
+	idx, err := index.Read(r)
+	if err != nil {
+		return err
+	}
+	for _, c := range conflicts {
+		if c.MovedTo != "" {
+			// The moved file is left untracked until resolved
+			idx.Remove(c.MovedTo)
+		}
+		idx.Remove(c.Path)
+		stages := [...]internal.TreeEntry{index.StageBase: c.Base, index.StageOurs: c.Ours, index.StageTheirs: c.Theirs}
+		for stage, e := range stages {
+			if e.Hash == "" {
+				continue
+			}
+			mode, err := index.ParseMode(e.Mode)
+			if err != nil {
+				return fmt.Errorf("%s: %w", c.Path, err)
+			}
+			idx.Set(index.Entry{Mode: mode, Hash: e.Hash, Path: c.Path, Stage: stage})
+		}
+	}
+	if err := idx.Write(r); err != nil {
+		return err
+	}
+
+	if err := refs.UpdateNoDeref(r, refs.MergeHead, theirs, ""); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(r.Path, commit.MergeMsgFile), []byte(message+"\n"), repo.FilePerm)
+}
+
+// moveTree updates the index and working tree from tree from to tree to,
+// refusing to overwrite local changes.
+func moveTree(r *repo.Repo, from, to string) error {
+	err := checkout.Tree(r, from, to, false)
+	var conflict *checkout.ConflictError
+	if errors.As(err, &conflict) {
+		conflict.Op = "merge"
+	}
+	return err
+}
+
+// checkIndex makes sure the index holds nothing but HEAD's tree, so the
+// merge result can be staged without losing anything.
+func checkIndex(r *repo.Repo, headTree string) error {
+	idx, err := index.Read(r)
+	if err != nil {
+		return err
+	}
+	if len(idx.Unmerged()) > 0 {
+		return errors.New("you need to resolve your current index first")
+	}
+	files, err := tree.Flatten(r, headTree)
+	if err != nil {
+		return err
+	}
+	staged := len(idx.Entries) != len(files)
+	for _, e := range idx.Entries {
+		f, ok := files[e.Path]
+		if !ok || f.Hash != e.Hash || f.Mode != fmt.Sprintf("%o", e.Mode) {
+			staged = true
+			break
+		}
+	}
+	if staged {
+		return errors.New("your index contains uncommitted changes; commit them before you merge")
+	}
+	return nil
+}
+
+// mergeBaseTree returns the tree changes are merged against: the merge
+// base's, or when criss-cross history leaves several bases, the result of
+// merging them into one virtual base as git's recursive strategy does.
+// Unrelated histories merge against the empty tree.
+func mergeBaseTree(r *repo.Repo, bases []string) (string, error) {
+	if len(bases) == 0 {
+		return "", nil
+	}
+	base, err := history.CommitTree(r, bases[0])
+	if err != nil {
+		return "", err
+	}
+	for _, other := range bases[1:] {
+		inner, err := history.MergeBases(r, bases[0], other)
+		if err != nil {
+			return "", err
+		}
+		innerTree, err := mergeBaseTree(r, inner)
+		if err != nil {
+			return "", err
+		}
+		otherTree, err := history.CommitTree(r, other)
+		if err != nil {
+			return "", err
+		}
+		// Conflicts are kept with their markers in the virtual base
+		merged, err := Trees(r, innerTree, base, otherTree, Labels{Ours: "Temporary merge branch 1", Theirs: "Temporary merge branch 2"})
+		if err != nil {
+			return "", err
+		}
+		base = merged.Tree
+	}
+	return base, nil
+}
+
+// defaultMessage is the merge commit message git would use for rev.
+func defaultMessage(r *repo.Repo, rev string) (string, error) {
+	isBranch, err := branch.Exists(r, rev)
+	if err != nil {
+		return "", err
+	}
+	message := fmt.Sprintf("Merge commit '%s'", rev)
+	if isBranch {
+		message = fmt.Sprintf("Merge branch '%s'", rev)
+	}
+
+	current, err := branch.Current(r)
+	if err != nil {
+		return "", err
+	}
+	if current != "" && current != "main" && current != "master" {
+		message += " into " + current
+	}
+	return message, nil
+}
@@ -0,0 +1,202 @@
+package merge_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/MahendraDani/gitloom.git/internal/branch"
+	"github.com/MahendraDani/gitloom.git/internal/checkout"
+	"github.com/MahendraDani/gitloom.git/internal/commit"
+	"github.com/MahendraDani/gitloom.git/internal/history"
+	"github.com/MahendraDani/gitloom.git/internal/index"
+	"github.com/MahendraDani/gitloom.git/internal/merge"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/status"
+	"github.com/MahendraDani/gitloom.git/internal/testrepo"
+)
+
+func switchTo(t *testing.T, r *repo.Repo, name string) {
+	t.Helper()
+	if _, err := checkout.Switch(r, name, checkout.Options{}); err != nil {
+		t.Fatalf("Switch failed: %v", err)
+	}
+}
+
+// setupRepo commits f.txt on main, then a topic branch whose commit edits
+// its first line, and checks main out again.
+func setupRepo(t *testing.T) (*repo.Repo, string) {
+	t.Helper()
+	r := testrepo.New(t)
+	dir := r.WorkTree()
+	testrepo.WriteFile(t, dir, "f.txt", "one\ntwo\nthree\nfour\nfive\n")
+	base := testrepo.CommitAll(t, r, "base")
+	if err := branch.Create(r, "topic", base, false); err != nil {
+		t.Fatal(err)
+	}
+
+	switchTo(t, r, "topic")
+	testrepo.WriteFile(t, dir, "f.txt", "ONE\ntwo\nthree\nfour\nfive\n")
+	testrepo.CommitAll(t, r, "topic")
+	switchTo(t, r, "main")
+	return r, dir
+}
+
+func TestContent(t *testing.T) {
+	base := "a\nb\nc\nd\ne\n"
+	labels := merge.Labels{Ours: "HEAD", Theirs: "topic"}
+
+	merged, conflict := merge.Content([]byte(base), []byte("A\nb\nc\nd\ne\n"), []byte("a\nb\nc\nd\nE\n"), labels)
+	if conflict || string(merged) != "A\nb\nc\nd\nE\n" {
+		t.Fatalf("clean merge = %q, conflict %v", merged, conflict)
+	}
+
+	// Lines both sides agree on stay outside the markers
+	merged, conflict = merge.Content([]byte(base), []byte("a\nx\ny\nd\ne\n"), []byte("a\nx\nz\nd\ne"), labels)
+	want := "a\nx\n<<<<<<< HEAD\ny\n=======\nz\n>>>>>>> topic\nd\ne"
+	if !conflict || string(merged) != want {
+		t.Fatalf("conflicted merge = %q, want %q", merged, want)
+	}
+}
+
+func TestMerge_FastForwardAndMergeCommit(t *testing.T) {
+	r, dir := setupRepo(t)
+
+	res, err := merge.Merge(r, "topic", merge.Options{})
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	topic, _ := refs.Resolve(r, branch.Ref("topic"))
+	if !res.FastForward || res.Commit != topic {
+		t.Fatalf("expected a fast-forward to topic, got %+v", res)
+	}
+	if got := testrepo.ReadFile(t, dir, "f.txt"); got != "ONE\ntwo\nthree\nfour\nfive\n" {
+		t.Fatalf("f.txt = %q after fast-forward", got)
+	}
+	if res, err := merge.Merge(r, "topic", merge.Options{}); err != nil || !res.UpToDate {
+		t.Fatalf("expected topic to be up to date, got %+v, %v", res, err)
+	}
+
+	// Diverging changes to different lines merge cleanly
+	testrepo.WriteFile(t, dir, "f.txt", "ONE\ntwo\nthree\nfour\nFIVE\n")
+	head := testrepo.CommitAll(t, r, "main")
+	switchTo(t, r, "topic")
+	testrepo.WriteFile(t, dir, "f.txt", "ONE\nTWO\nthree\nfour\nfive\n")
+	theirs := testrepo.CommitAll(t, r, "topic 2")
+	switchTo(t, r, "main")
+
+	res, err = merge.Merge(r, "topic", merge.Options{})
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if got := testrepo.ReadFile(t, dir, "f.txt"); got != "ONE\nTWO\nthree\nfour\nFIVE\n" {
+		t.Fatalf("f.txt = %q after merge", got)
+	}
+	c, err := history.ReadCommit(r, res.Commit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Parents) != 2 || c.Parents[0] != head || c.Parents[1] != theirs || c.Message != "Merge branch 'topic'\n" {
+		t.Fatalf("unexpected merge commit: %+v", c)
+	}
+	if s, err := status.Compute(r); err != nil || !s.Clean() {
+		t.Fatalf("expected a clean status after merging, got %+v, %v", s, err)
+	}
+}
+
+func TestMerge_ConflictThenCommit(t *testing.T) {
+	r, dir := setupRepo(t)
+	testrepo.WriteFile(t, dir, "f.txt", "uno\ntwo\nthree\nfour\nfive\n")
+	head := testrepo.CommitAll(t, r, "main")
+
+	res, err := merge.Merge(r, "topic", merge.Options{})
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if res.Commit != "" || len(res.Conflicts) != 1 || res.Conflicts[0].Kind != "content" {
+		t.Fatalf("expected a content conflict, got %+v", res)
+	}
+	want := "<<<<<<< HEAD\nuno\n=======\nONE\n>>>>>>> topic\ntwo\nthree\nfour\nfive\n"
+	if got := testrepo.ReadFile(t, dir, "f.txt"); got != want {
+		t.Fatalf("f.txt = %q, want %q", got, want)
+	}
+
+	idx, err := index.Read(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stages := idx.Stages("f.txt")
+	if stages[index.StageBase].Hash == "" || stages[index.StageOurs].Hash == "" || stages[index.StageTheirs].Hash == "" {
+		t.Fatalf("expected stages 1, 2 and 3, got %+v", stages)
+	}
+	s, err := status.Compute(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Merging || len(s.Unmerged) != 1 || s.Unmerged[0].Kind != status.BothModified {
+		t.Fatalf("unexpected status: %+v", s)
+	}
+
+	if _, err := commit.Commit(r, "too early"); err == nil {
+		t.Fatal("expected committing unmerged files to fail")
+	}
+	if _, err := merge.Merge(r, "topic", merge.Options{}); err == nil {
+		t.Fatal("expected a second merge to be refused while one is in progress")
+	}
+
+	testrepo.WriteFile(t, dir, "f.txt", "uno y ONE\ntwo\nthree\nfour\nfive\n")
+	message, err := commit.MergeMessage(r)
+	if err != nil || message != "Merge branch 'topic'\n" {
+		t.Fatalf("MergeMessage() = %q, %v", message, err)
+	}
+	hash := testrepo.CommitAll(t, r, message)
+
+	c, err := history.ReadCommit(r, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	topic, _ := refs.Resolve(r, branch.Ref("topic"))
+	if len(c.Parents) != 2 || c.Parents[0] != head || c.Parents[1] != topic {
+		t.Fatalf("expected parents %s and %s, got %v", head, topic, c.Parents)
+	}
+	if _, err := refs.Resolve(r, refs.MergeHead); !errors.Is(err, refs.ErrNotFound) {
+		t.Fatalf("expected MERGE_HEAD to be removed, got %v", err)
+	}
+}
+
+func TestMerge_Abort(t *testing.T) {
+	r, dir := setupRepo(t)
+	testrepo.WriteFile(t, dir, "f.txt", "uno\ntwo\nthree\nfour\nfive\n")
+	testrepo.CommitAll(t, r, "main")
+
+	if _, err := merge.Merge(r, "topic", merge.Options{}); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if err := merge.Abort(r); err != nil {
+		t.Fatalf("Abort failed: %v", err)
+	}
+	if got := testrepo.ReadFile(t, dir, "f.txt"); got != "uno\ntwo\nthree\nfour\nfive\n" {
+		t.Fatalf("f.txt = %q after abort", got)
+	}
+	s, err := status.Compute(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Merging || !s.Clean() {
+		t.Fatalf("expected a clean status after abort, got %+v", s)
+	}
+}
+
+func TestMerge_RefusesToOverwriteLocalChanges(t *testing.T) {
+	r, dir := setupRepo(t)
+	testrepo.WriteFile(t, dir, "f.txt", "local\n")
+
+	_, err := merge.Merge(r, "topic", merge.Options{})
+	var conflict *checkout.ConflictError
+	if !errors.As(err, &conflict) || len(conflict.Modified) != 1 {
+		t.Fatalf("expected a ConflictError for f.txt, got %v", err)
+	}
+	if got := testrepo.ReadFile(t, dir, "f.txt"); got != "local\n" {
+		t.Fatalf("local change was lost: %q", got)
+	}
+}
@@ -0,0 +1,293 @@
+package merge
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/diff"
+	"github.com/MahendraDani/gitloom.git/internal/index"
+	"github.com/MahendraDani/gitloom.git/internal/object"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/tree"
+)
+
+// Conflict is a path the merge could not resolve on its own. Versions a
+// side does not have are left zero.
+type Conflict struct {
+	Path               string
+	Kind               string // content, add/add, modify/delete, binary, submodule, file/directory
+	Base, Ours, Theirs internal.TreeEntry
+
+	// MovedTo is where the file of a file/directory conflict was written
+	// instead, to make room for the directory.
+	MovedTo string
+}
+
+// TreeResult is the outcome of merging three trees.
+type TreeResult struct {
+	// Tree holds the merged files. Conflicted files appear as they are
+	// left in the working tree: with conflict markers, or as the version
+	// of the side that kept them.
+	Tree      string
+	Files     map[string]internal.TreeEntry
+	Conflicts []Conflict
+	// Messages describe the notable steps of the merge, e.g.
+	// "Auto-merging f" and "CONFLICT (content): Merge conflict in f".
+	Messages []string
+}
+
+// Trees merges the changes from base to ours and from base to theirs.
+// Any of the trees may be empty to stand for the empty tree. A path only
+// one side changed takes that side's version; a file both changed is
+// merged line by line.
+func Trees(r *repo.Repo, base, ours, theirs string, labels Labels) (*TreeResult, error) {
+	baseFiles, err := tree.Flatten(r, base)
+	if err != nil {
+		return nil, err
+	}
+	ourFiles, err := tree.Flatten(r, ours)
+	if err != nil {
+		return nil, err
+	}
+	theirFiles, err := tree.Flatten(r, theirs)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]bool)
+	for _, files := range []map[string]internal.TreeEntry{baseFiles, ourFiles, theirFiles} {
+		for p := range files {
+			paths[p] = true
+		}
+	}
+	sorted := make([]string, 0, len(paths))
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+
+	m := &merger{r: r, labels: labels, res: &TreeResult{Files: make(map[string]internal.TreeEntry)}}
+	for _, p := range sorted {
+		b, inBase := baseFiles[p]
+		o, inOurs := ourFiles[p]
+		t, inTheirs := theirFiles[p]
+		if err := m.file(p, b, inBase, o, inOurs, t, inTheirs); err != nil {
+			return nil, err
+		}
+	}
+	m.fileDirectory(ourFiles)
+
+	if m.res.Tree, err = writeTree(r, m.res.Files); err != nil {
+		return nil, err
+	}
+	return m.res, nil
+}
+
+// merger collects the result of Trees.
+type merger struct {
+	r      *repo.Repo
+	labels Labels
+	res    *TreeResult
+}
+
+// file merges path p given its version in each tree.
+func (m *merger) file(p string, b internal.TreeEntry, inBase bool, o internal.TreeEntry, inOurs bool, t internal.TreeEntry, inTheirs bool) error {
+	keep := func(e internal.TreeEntry, present bool) {
+		if present {
+			m.res.Files[p] = e
+		}
+	}
+	switch {
+	case same(o, inOurs, t, inTheirs), same(b, inBase, t, inTheirs):
+		keep(o, inOurs)
+		return nil
+	case same(b, inBase, o, inOurs):
+		keep(t, inTheirs)
+		return nil
+	}
+
+	c := Conflict{Path: p, Base: b, Ours: o, Theirs: t}
+	if !inOurs || !inTheirs {
+		// One side deleted what the other changed; the change is kept
+		deleted, modified := m.labels.Ours, m.labels.Theirs
+		if inOurs {
+			deleted, modified = modified, deleted
+			keep(o, true)
+		} else {
+			keep(t, true)
+		}
+		c.Kind = "modify/delete"
+		m.conflict(c, fmt.Sprintf("CONFLICT (modify/delete): %s deleted in %s and modified in %s.  Version %s of %s left in tree.",
+			p, deleted, modified, modified, p))
+		return nil
+	}
+
+	// Both sides have the file: settle the mode, then the content
+	mode, modeClean := o.Mode, true
+	switch {
+	case o.Mode == t.Mode:
+	case inBase && b.Mode == o.Mode:
+		mode = t.Mode
+	case !inBase || b.Mode != t.Mode:
+		modeClean = false
+	}
+
+	if !mergeable(o.Mode) || !mergeable(t.Mode) || (inBase && !mergeable(b.Mode)) {
+		// Symlinks, submodules and type changes cannot be merged by line
+		keep(o, true)
+		c.Kind = "content"
+		if o.Mode == internal.ModeGitlink || t.Mode == internal.ModeGitlink {
+			c.Kind = "submodule"
+		}
+		m.conflict(c, fmt.Sprintf("CONFLICT (%s): Merge conflict in %s", c.Kind, p))
+		return nil
+	}
+
+	hash, clean := o.Hash, true
+	if o.Hash != t.Hash {
+		m.res.Messages = append(m.res.Messages, "Auto-merging "+p)
+		var err error
+		if hash, clean, err = m.content(b, inBase, o, t); err != nil {
+			return err
+		}
+		if hash == "" {
+			keep(o, true)
+			c.Kind = "binary"
+			m.res.Messages = append(m.res.Messages, fmt.Sprintf("warning: Cannot merge binary files: %s (%s vs. %s)", p, m.labels.Ours, m.labels.Theirs))
+			m.conflict(c, fmt.Sprintf("CONFLICT (content): Merge conflict in %s", p))
+			return nil
+		}
+	}
+	m.res.Files[p] = internal.TreeEntry{Mode: mode, Name: p, Hash: hash}
+	if clean && modeClean {
+		return nil
+	}
+
+	c.Kind = "content"
+	if !inBase {
+		c.Kind = "add/add"
+	}
+	m.conflict(c, fmt.Sprintf("CONFLICT (%s): Merge conflict in %s", c.Kind, p))
+	return nil
+}
+
+// content merges the text of two regular files and stores the result. It
+// returns an empty hash for binary files, which are not merged.
+func (m *merger) content(b internal.TreeEntry, inBase bool, o, t internal.TreeEntry) (string, bool, error) {
+	var baseData []byte
+	if inBase {
+		var err error
+		if baseData, err = object.ReadBlob(m.r, b.Hash); err != nil {
+			return "", false, err
+		}
+	}
+	ourData, err := object.ReadBlob(m.r, o.Hash)
+	if err != nil {
+		return "", false, err
+	}
+	theirData, err := object.ReadBlob(m.r, t.Hash)
+	if err != nil {
+		return "", false, err
+	}
+	if diff.IsBinary(baseData) || diff.IsBinary(ourData) || diff.IsBinary(theirData) {
+		return "", false, nil
+	}
+
+	merged, conflict := Content(baseData, ourData, theirData, m.labels)
+	hash, err := m.r.WriteObject(internal.NewBlob(merged))
+	return hash, !conflict, err
+}
+
+func (m *merger) conflict(c Conflict, message string) {
+	m.res.Conflicts = append(m.res.Conflicts, c)
+	m.res.Messages = append(m.res.Messages, message)
+}
+
+// fileDirectory moves files out of the way of directories the merge put
+// at the same path, e.g. when one side added the file a and the other
+// a/b. The file is renamed to a~<label> and its path marked conflicted.
+func (m *merger) fileDirectory(ourFiles map[string]internal.TreeEntry) {
+	dirs := make(map[string]bool)
+	for p := range m.res.Files {
+		for i := range len(p) {
+			if p[i] == '/' {
+				dirs[p[:i]] = true
+			}
+		}
+	}
+
+	var moved []string
+	for p := range m.res.Files {
+		if dirs[p] {
+			moved = append(moved, p)
+		}
+	}
+	sort.Strings(moved)
+
+	for _, p := range moved {
+		e := m.res.Files[p]
+		label := m.labels.Theirs
+		if o, ok := ourFiles[p]; ok && o.Hash == e.Hash && o.Mode == e.Mode {
+			label = m.labels.Ours
+		}
+		to := p + "~" + strings.ReplaceAll(label, "/", "_")
+		delete(m.res.Files, p)
+		e.Name = to
+		m.res.Files[to] = e
+
+		i := m.find(p)
+		if i < 0 {
+			// Otherwise clean: the side that had the file is its only version
+			c := Conflict{Path: p, Kind: "file/directory"}
+			if label == m.labels.Ours {
+				c.Ours = e
+			} else {
+				c.Theirs = e
+			}
+			m.res.Conflicts = append(m.res.Conflicts, c)
+			i = len(m.res.Conflicts) - 1
+		}
+		m.res.Conflicts[i].Kind = "file/directory"
+		m.res.Conflicts[i].MovedTo = to
+		m.res.Messages = append(m.res.Messages, fmt.Sprintf("CONFLICT (file/directory): directory in the way of %s from %s; moving it to %s instead.", p, label, to))
+	}
+}
+
+func (m *merger) find(path string) int {
+	for i, c := range m.res.Conflicts {
+		if c.Path == path {
+			return i
+		}
+	}
+	return -1
+}
+
+// same reports whether two versions of a path are identical, counting a
+// path missing on both sides as the same.
+func same(a internal.TreeEntry, inA bool, b internal.TreeEntry, inB bool) bool {
+	if !inA || !inB {
+		return inA == inB
+	}
+	return a.Hash == b.Hash && a.Mode == b.Mode
+}
+
+// mergeable reports whether files with mode can be merged line by line.
+func mergeable(mode string) bool {
+	return mode == internal.ModeBlob || mode == internal.ModeExecutable
+}
+
+// writeTree stores files, keyed by path, as a tree and its subtrees.
+func writeTree(r *repo.Repo, files map[string]internal.TreeEntry) (string, error) {
+	idx := &index.Index{}
+	for p, e := range files {
+		mode, err := index.ParseMode(e.Mode)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", p, err)
+		}
+		idx.Entries = append(idx.Entries, index.Entry{Mode: mode, Hash: e.Hash, Path: p})
+	}
+	sort.Slice(idx.Entries, func(i, j int) bool { return idx.Entries[i].Path < idx.Entries[j].Path })
+	return tree.WriteIndexTree(r, idx)
+}
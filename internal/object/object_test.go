@@ -11,16 +11,13 @@ import (
 
 	"github.com/MahendraDani/gitloom.git/internal/object"
 	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/testrepo"
 )
 
 func TestHashObjectAndWrite(t *testing.T) {
-	// Create a temporary directory for the repo
-	tempDir := t.TempDir()
-
-	r := repo.NewRepo(tempDir)
-	if err := r.Init(); err != nil {
-		t.Fatalf("failed to init repository: %v", err)
-	}
+	// Create a temporary repo
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
 
 	// Create a test file
 	filePath := filepath.Join(tempDir, "hello.txt")
@@ -69,13 +66,9 @@ func TestHashObjectAndWrite(t *testing.T) {
 }
 
 func TestHashObject(t *testing.T) {
-	// Create a temporary directory for the repo
-	tempDir := t.TempDir()
-
-	r := repo.NewRepo(tempDir)
-	if err := r.Init(); err != nil {
-		t.Fatalf("failed to init repository: %v", err)
-	}
+	// Create a temporary repo
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
 
 	// Create a test file
 	filePath := filepath.Join(tempDir, "hello.txt")
@@ -98,14 +91,9 @@ func TestHashObject(t *testing.T) {
 }
 
 func TestCatFilePrint(t *testing.T) {
-	// Create a temporary directory for the repo
-	tempDir := t.TempDir()
-
-	// setup a gitloom repo
-	r := repo.NewRepo(tempDir)
-	if err := r.Init(); err != nil {
-		t.Fatalf("failed to init repository: %v", err)
-	}
+	// Create a temporary repo
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
 
 	// create a temp file
 	filePath := filepath.Join(tempDir, "hello.txt")
@@ -134,12 +122,9 @@ func TestCatFilePrint(t *testing.T) {
 }
 
 func TestCatFileSize(t *testing.T) {
-	// Create a temporary gitloom repo
-	tempDir := t.TempDir()
-	r := repo.NewRepo(tempDir)
-	if err := r.Init(); err != nil {
-		t.Fatalf("failed to init repository: %v", err)
-	}
+	// Create a temporary repo
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
 	// Create a test file
 	filePath := filepath.Join(tempDir, "hello.txt")
 	content := []byte("hello world\n")
@@ -168,14 +153,9 @@ func TestCatFileSize(t *testing.T) {
 }
 
 func TestCatFileType(t *testing.T) {
-	// Create a temporary directory for the repo
-	tempDir := t.TempDir()
-
-	// setup a gitloom repo
-	r := repo.NewRepo(tempDir)
-	if err := r.Init(); err != nil {
-		t.Fatalf("failed to init repository: %v", err)
-	}
+	// Create a temporary repo
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
 
 	// create a temp file
 	filePath := filepath.Join(tempDir, "hello.txt")
@@ -202,3 +182,35 @@ func TestCatFileType(t *testing.T) {
 		t.Errorf("expected type %q, got %q", expectedType, output)
 	}
 }
+
+func TestWriteBlob(t *testing.T) {
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
+
+	filePath := filepath.Join(tempDir, "large.bin")
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64*1024)
+	if err := os.WriteFile(filePath, content, 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	hash, err := object.HashObject(filePath, r, true)
+	if err != nil {
+		t.Fatalf("HashObject returned error: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := object.WriteBlob(r, hash, &out); err != nil {
+		t.Fatalf("WriteBlob returned error: %v", err)
+	}
+	if !bytes.Equal(out.Bytes(), content) {
+		t.Fatalf("streamed blob does not match file content")
+	}
+
+	size, err := object.CatFile(r, hash, "s")
+	if err != nil {
+		t.Fatalf("CatFile returned error: %v", err)
+	}
+	if size != fmt.Sprintf("%d", len(content)) {
+		t.Fatalf("unexpected size %s", size)
+	}
+}
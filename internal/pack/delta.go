@@ -0,0 +1,202 @@
+package pack
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+const (
+	// deltaBlockSize is the length of the chunks of the base that are
+	// indexed when searching for copies.
+	deltaBlockSize = 16
+	maxCopySize    = 0xffffff
+	maxInsertSize  = 0x7f
+)
+
+// ApplyDelta rebuilds an object from base and a git delta, which is the
+// base size, the result size and a list of copy and insert instructions.
+func ApplyDelta(base, delta []byte) ([]byte, error) {
+	rd := bytes.NewReader(delta)
+
+	baseSize, err := readDeltaSize(rd)
+	if err != nil {
+		return nil, err
+	}
+	if baseSize != int64(len(base)) {
+		return nil, fmt.Errorf("delta base size %d does not match base of %d bytes", baseSize, len(base))
+	}
+	resultSize, err := readDeltaSize(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	// No instruction emits more than maxCopySize bytes, so a larger result
+	// is corrupt, and the buffer is only presized up to what is on hand
+	if resultSize > int64(rd.Len())*maxCopySize {
+		return nil, fmt.Errorf("delta result size %d is more than its instructions can produce", resultSize)
+	}
+	result := make([]byte, 0, min(resultSize, int64(len(base)+len(delta))))
+	for rd.Len() > 0 {
+		op, _ := rd.ReadByte()
+
+		switch {
+		case op&0x80 != 0:
+			// Copy from base: bits 0-3 select offset bytes, bits 4-6 size bytes
+			var offset, size int64
+			for i := uint(0); i < 4; i++ {
+				if op&(1<<i) != 0 {
+					b, err := rd.ReadByte()
+					if err != nil {
+						return nil, errors.New("truncated delta copy instruction")
+					}
+					offset |= int64(b) << (8 * i)
+				}
+			}
+			for i := uint(0); i < 3; i++ {
+				if op&(1<<(4+i)) != 0 {
+					b, err := rd.ReadByte()
+					if err != nil {
+						return nil, errors.New("truncated delta copy instruction")
+					}
+					size |= int64(b) << (8 * i)
+				}
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if offset+size > int64(len(base)) {
+				return nil, errors.New("delta copy out of base bounds")
+			}
+			result = append(result, base[offset:offset+size]...)
+			if int64(len(result)) > resultSize {
+				return nil, fmt.Errorf("delta produced more than %d bytes", resultSize)
+			}
+
+		case op != 0:
+			// Insert the next op bytes literally
+			data := make([]byte, op)
+			if n, _ := rd.Read(data); n != int(op) {
+				return nil, errors.New("truncated delta insert instruction")
+			}
+			result = append(result, data...)
+
+		default:
+			return nil, errors.New("invalid delta instruction 0")
+		}
+	}
+
+	if int64(len(result)) != resultSize {
+		return nil, fmt.Errorf("delta produced %d bytes, expected %d", len(result), resultSize)
+	}
+	return result, nil
+}
+
+// CreateDelta returns a delta that turns base into target. Blocks of the
+// base are indexed and matches are extended as far as possible; anything
+// else is inserted literally.
+func CreateDelta(base, target []byte) []byte {
+	var out bytes.Buffer
+	writeDeltaSize(&out, int64(len(base)))
+	writeDeltaSize(&out, int64(len(target)))
+
+	blocks := make(map[string]int)
+	for i := 0; i+deltaBlockSize <= len(base); i += deltaBlockSize {
+		key := string(base[i : i+deltaBlockSize])
+		if _, ok := blocks[key]; !ok {
+			blocks[key] = i
+		}
+	}
+
+	var pending []byte
+	flush := func() {
+		for len(pending) > 0 {
+			n := len(pending)
+			if n > maxInsertSize {
+				n = maxInsertSize
+			}
+			out.WriteByte(byte(n))
+			out.Write(pending[:n])
+			pending = pending[n:]
+		}
+	}
+
+	for i := 0; i < len(target); {
+		offset, found := -1, false
+		if i+deltaBlockSize <= len(target) {
+			offset, found = blocks[string(target[i:i+deltaBlockSize])]
+		}
+		if !found {
+			pending = append(pending, target[i])
+			i++
+			continue
+		}
+
+		length := deltaBlockSize
+		for offset+length < len(base) && i+length < len(target) && base[offset+length] == target[i+length] {
+			length++
+		}
+
+		flush()
+		for length > 0 {
+			n := length
+			if n > maxCopySize {
+				n = maxCopySize
+			}
+			writeCopy(&out, offset, n)
+			offset += n
+			i += n
+			length -= n
+		}
+	}
+	flush()
+
+	return out.Bytes()
+}
+
+func writeCopy(out *bytes.Buffer, offset, size int) {
+	op := byte(0x80)
+	var args []byte
+	for i := uint(0); i < 4; i++ {
+		if b := byte(offset >> (8 * i)); b != 0 {
+			op |= 1 << i
+			args = append(args, b)
+		}
+	}
+	for i := uint(0); i < 3; i++ {
+		if b := byte(size >> (8 * i)); b != 0 {
+			op |= 1 << (4 + i)
+			args = append(args, b)
+		}
+	}
+	out.WriteByte(op)
+	out.Write(args)
+}
+
+func writeDeltaSize(out *bytes.Buffer, size int64) {
+	for {
+		b := byte(size & 0x7f)
+		size >>= 7
+		if size == 0 {
+			out.WriteByte(b)
+			return
+		}
+		out.WriteByte(b | 0x80)
+	}
+}
+
+func readDeltaSize(rd *bytes.Reader) (int64, error) {
+	var size int64
+	var shift uint
+	for {
+		b, err := rd.ReadByte()
+		if err != nil {
+			return 0, errors.New("truncated delta header")
+		}
+		size |= int64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			return size, nil
+		}
+	}
+}
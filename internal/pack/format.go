@@ -0,0 +1,118 @@
+package pack
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+)
+
+const (
+	packSignature = "PACK"
+	packVersion   = 2
+	hashSize      = 20
+
+	// Object types as stored in the pack entry header
+	typeCommit   = 1
+	typeTree     = 2
+	typeBlob     = 3
+	typeTag      = 4
+	typeOfsDelta = 6
+	typeRefDelta = 7
+)
+
+// ErrNotFound is returned when an object is not stored in a pack.
+var ErrNotFound = errors.New("object not in pack")
+
+func typeCode(objType string) (byte, error) {
+	switch objType {
+	case internal.CommitType:
+		return typeCommit, nil
+	case internal.TreeType:
+		return typeTree, nil
+	case internal.BlobType:
+		return typeBlob, nil
+	case internal.TagType:
+		return typeTag, nil
+	default:
+		return 0, fmt.Errorf("cannot pack object of type %q", objType)
+	}
+}
+
+func typeName(code byte) (string, error) {
+	switch code {
+	case typeCommit:
+		return internal.CommitType, nil
+	case typeTree:
+		return internal.TreeType, nil
+	case typeBlob:
+		return internal.BlobType, nil
+	case typeTag:
+		return internal.TagType, nil
+	default:
+		return "", fmt.Errorf("unknown pack object type %d", code)
+	}
+}
+
+// encodeEntryHeader encodes the type and inflated size of a pack entry:
+// the first byte holds a continuation bit, three type bits and the low
+// four size bits; each following byte adds seven more size bits.
+func encodeEntryHeader(code byte, size int64) []byte {
+	b := code<<4 | byte(size&0x0f)
+	size >>= 4
+	var out []byte
+	for size > 0 {
+		out = append(out, b|0x80)
+		b = byte(size & 0x7f)
+		size >>= 7
+	}
+	return append(out, b)
+}
+
+func decodeEntryHeader(r io.ByteReader) (byte, int64, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	code := (b >> 4) & 0x07
+	size := int64(b & 0x0f)
+	shift := uint(4)
+	for b&0x80 != 0 {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, 0, err
+		}
+		size |= int64(b&0x7f) << shift
+		shift += 7
+	}
+	return code, size, nil
+}
+
+// encodeOffset encodes the backwards distance to the base of an OFS_DELTA
+// entry. Each continuation adds one before shifting, so that no offset has
+// two encodings.
+func encodeOffset(offset int64) []byte {
+	out := []byte{byte(offset & 0x7f)}
+	offset >>= 7
+	for offset > 0 {
+		offset--
+		out = append([]byte{byte(0x80 | offset&0x7f)}, out...)
+		offset >>= 7
+	}
+	return out
+}
+
+func decodeOffset(r io.ByteReader) (int64, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	offset := int64(b & 0x7f)
+	for b&0x80 != 0 {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, err
+		}
+		offset = ((offset + 1) << 7) | int64(b&0x7f)
+	}
+	return offset, nil
+}
@@ -0,0 +1,189 @@
+package pack
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+var idxSignature = []byte{0xff, 't', 'O', 'c'}
+
+const idxVersion = 2
+
+// IndexEntry locates one object inside a pack.
+type IndexEntry struct {
+	Hash   string
+	Offset int64
+	CRC32  uint32
+}
+
+// Index is a parsed .idx v2 file: a fanout table over the first hash
+// byte, the sorted object names, their CRC32s and pack offsets, followed
+// by the pack checksum and a checksum of the index itself.
+type Index struct {
+	Entries      []IndexEntry // sorted by hash
+	PackChecksum string
+
+	byOffset []int64 // sorted entry offsets, used to find entry ends
+}
+
+// ParseIndex decodes and verifies an .idx v2 file.
+func ParseIndex(data []byte) (*Index, error) {
+	const headerSize = 8 + 256*4
+	if len(data) < headerSize+2*hashSize {
+		return nil, errors.New("pack index too short")
+	}
+	if !bytes.Equal(data[:4], idxSignature) || binary.BigEndian.Uint32(data[4:8]) != idxVersion {
+		return nil, errors.New("unsupported pack index version")
+	}
+
+	sum := sha1.Sum(data[:len(data)-hashSize])
+	if !bytes.Equal(sum[:], data[len(data)-hashSize:]) {
+		return nil, errors.New("pack index checksum mismatch")
+	}
+
+	// Each object takes a name, a CRC and an offset, so a count the file
+	// cannot hold is rejected before it sizes anything
+	count := int(binary.BigEndian.Uint32(data[8+255*4 : headerSize]))
+	if count > (len(data)-headerSize-2*hashSize)/(hashSize+8) {
+		return nil, errors.New("pack index truncated")
+	}
+	namesEnd := headerSize + count*hashSize
+	crcEnd := namesEnd + count*4
+	offsetsEnd := crcEnd + count*4
+	if len(data) < offsetsEnd+2*hashSize {
+		return nil, errors.New("pack index truncated")
+	}
+	large := data[offsetsEnd : len(data)-2*hashSize]
+
+	idx := &Index{
+		Entries:      make([]IndexEntry, count),
+		PackChecksum: hex.EncodeToString(data[len(data)-2*hashSize : len(data)-hashSize]),
+	}
+	for i := range idx.Entries {
+		e := &idx.Entries[i]
+		e.Hash = hex.EncodeToString(data[headerSize+i*hashSize : headerSize+(i+1)*hashSize])
+		e.CRC32 = binary.BigEndian.Uint32(data[namesEnd+i*4:])
+
+		offset := binary.BigEndian.Uint32(data[crcEnd+i*4:])
+		if offset&0x80000000 == 0 {
+			e.Offset = int64(offset)
+			continue
+		}
+		// The high bit points into the table of 64-bit offsets
+		j := int(offset&0x7fffffff) * 8
+		if j+8 > len(large) {
+			return nil, errors.New("pack index large offset out of range")
+		}
+		e.Offset = int64(binary.BigEndian.Uint64(large[j:]))
+	}
+
+	for i := 1; i < count; i++ {
+		if idx.Entries[i-1].Hash >= idx.Entries[i].Hash {
+			return nil, errors.New("pack index entries are not sorted")
+		}
+	}
+	idx.sortOffsets()
+	return idx, nil
+}
+
+// WriteIndex encodes entries and the checksum of their pack as an .idx
+// v2 file. Entries are sorted in place.
+func WriteIndex(w io.Writer, entries []IndexEntry, packChecksum string) error {
+	sum, err := hex.DecodeString(packChecksum)
+	if err != nil || len(sum) != hashSize {
+		return fmt.Errorf("invalid pack checksum %q", packChecksum)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Hash < entries[j].Hash })
+
+	var buf bytes.Buffer
+	buf.Write(idxSignature)
+	binary.Write(&buf, binary.BigEndian, uint32(idxVersion))
+
+	var fanout [256]uint32
+	for _, e := range entries {
+		b, err := hex.DecodeString(e.Hash[:2])
+		if err != nil {
+			return fmt.Errorf("invalid object name %q", e.Hash)
+		}
+		fanout[b[0]]++
+	}
+	var total uint32
+	for i := range fanout {
+		total += fanout[i]
+		fanout[i] = total
+	}
+	binary.Write(&buf, binary.BigEndian, fanout)
+
+	for _, e := range entries {
+		name, err := hex.DecodeString(e.Hash)
+		if err != nil || len(name) != hashSize {
+			return fmt.Errorf("invalid object name %q", e.Hash)
+		}
+		buf.Write(name)
+	}
+	for _, e := range entries {
+		binary.Write(&buf, binary.BigEndian, e.CRC32)
+	}
+
+	var large []uint64
+	for _, e := range entries {
+		if e.Offset < 0x80000000 {
+			binary.Write(&buf, binary.BigEndian, uint32(e.Offset))
+			continue
+		}
+		binary.Write(&buf, binary.BigEndian, uint32(0x80000000|len(large)))
+		large = append(large, uint64(e.Offset))
+	}
+	binary.Write(&buf, binary.BigEndian, large)
+
+	buf.Write(sum)
+	idxSum := sha1.Sum(buf.Bytes())
+	buf.Write(idxSum[:])
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// Find returns the entry for hash.
+func (idx *Index) Find(hash string) (IndexEntry, bool) {
+	i := sort.Search(len(idx.Entries), func(i int) bool { return idx.Entries[i].Hash >= hash })
+	if i < len(idx.Entries) && idx.Entries[i].Hash == hash {
+		return idx.Entries[i], true
+	}
+	return IndexEntry{}, false
+}
+
+// FindPrefix returns the names of all objects starting with prefix.
+func (idx *Index) FindPrefix(prefix string) []string {
+	i := sort.Search(len(idx.Entries), func(i int) bool { return idx.Entries[i].Hash >= prefix })
+	var hashes []string
+	for ; i < len(idx.Entries) && strings.HasPrefix(idx.Entries[i].Hash, prefix); i++ {
+		hashes = append(hashes, idx.Entries[i].Hash)
+	}
+	return hashes
+}
+
+func (idx *Index) sortOffsets() {
+	idx.byOffset = make([]int64, len(idx.Entries))
+	for i, e := range idx.Entries {
+		idx.byOffset[i] = e.Offset
+	}
+	sort.Slice(idx.byOffset, func(i, j int) bool { return idx.byOffset[i] < idx.byOffset[j] })
+}
+
+// entryEnd returns the offset where the entry starting at offset ends,
+// given that the pack data ends at dataEnd.
+func (idx *Index) entryEnd(offset, dataEnd int64) int64 {
+	i := sort.Search(len(idx.byOffset), func(i int) bool { return idx.byOffset[i] > offset })
+	if i < len(idx.byOffset) {
+		return idx.byOffset[i]
+	}
+	return dataEnd
+}
@@ -0,0 +1,253 @@
+package pack_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/pack"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/testrepo"
+)
+
+func TestDeltaRoundTrip(t *testing.T) {
+	var base strings.Builder
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&base, "line %d\n", i)
+	}
+	target := strings.Replace(base.String(), "line 1000\n", "changed\n", 1) + "appended\n"
+
+	delta := pack.CreateDelta([]byte(base.String()), []byte(target))
+	if len(delta) > len(target)/10 {
+		t.Fatalf("delta of %d bytes for a one line change", len(delta))
+	}
+
+	got, err := pack.ApplyDelta([]byte(base.String()), delta)
+	if err != nil {
+		t.Fatalf("ApplyDelta failed: %v", err)
+	}
+	if string(got) != target {
+		t.Fatalf("ApplyDelta did not reproduce the target")
+	}
+
+	if _, err := pack.ApplyDelta([]byte("other base"), delta); err == nil {
+		t.Fatalf("expected an error for a delta applied to the wrong base")
+	}
+}
+
+func TestIndexRoundTrip(t *testing.T) {
+	entries := []pack.IndexEntry{
+		{Hash: strings.Repeat("b", 40), Offset: 12, CRC32: 1},
+		{Hash: strings.Repeat("a", 40), Offset: 5 << 30, CRC32: 2},
+	}
+	checksum := strings.Repeat("c", 40)
+
+	var buf bytes.Buffer
+	if err := pack.WriteIndex(&buf, entries, checksum); err != nil {
+		t.Fatalf("WriteIndex failed: %v", err)
+	}
+	idx, err := pack.ParseIndex(buf.Bytes())
+	if err != nil {
+		t.Fatalf("ParseIndex failed: %v", err)
+	}
+
+	if idx.PackChecksum != checksum {
+		t.Fatalf("pack checksum = %s, want %s", idx.PackChecksum, checksum)
+	}
+	e, ok := idx.Find(strings.Repeat("a", 40))
+	if !ok || e.Offset != 5<<30 || e.CRC32 != 2 {
+		t.Fatalf("unexpected entry for large offset: %+v", e)
+	}
+	if got := idx.FindPrefix("bbbb"); len(got) != 1 {
+		t.Fatalf("FindPrefix = %v", got)
+	}
+
+	data := buf.Bytes()
+	data[len(data)-1] ^= 0xff
+	if _, err := pack.ParseIndex(data); err == nil {
+		t.Fatalf("expected a checksum error for a corrupt index")
+	}
+}
+
+// newSource stores similar blobs and a commit in a fresh repository and
+// returns it with their hashes.
+func newSource(t *testing.T) (*repo.Repo, []string) {
+	t.Helper()
+	r := testrepo.New(t)
+
+	var content strings.Builder
+	var hashes []string
+	for i := 0; i < 5; i++ {
+		for j := 0; j < 300; j++ {
+			fmt.Fprintf(&content, "version %d line %d\n", i, j)
+		}
+		hash, err := r.WriteRawObject(internal.BlobType, []byte(content.String()))
+		if err != nil {
+			t.Fatalf("failed to write blob: %v", err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	hash, err := r.WriteRawObject(internal.CommitType, []byte("tree "+strings.Repeat("0", 40)+"\n\nmessage\n"))
+	if err != nil {
+		t.Fatalf("failed to write commit: %v", err)
+	}
+	return r, append(hashes, hash)
+}
+
+func TestWriteAndReadPack(t *testing.T) {
+	for _, refDelta := range []bool{false, true} {
+		t.Run(fmt.Sprintf("refDelta=%v", refDelta), func(t *testing.T) {
+			r, hashes := newSource(t)
+			dir := t.TempDir()
+
+			checksum, err := pack.Write(dir, r, hashes, pack.Options{RefDelta: refDelta})
+			if err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			p, err := pack.Open(filepath.Join(dir, "pack-"+checksum+".pack"))
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer p.Close()
+
+			for _, hash := range hashes {
+				wantType, want, err := r.ReadRawObject(hash)
+				if err != nil {
+					t.Fatalf("failed to read loose object: %v", err)
+				}
+				objType, data, err := p.Read(hash)
+				if err != nil {
+					t.Fatalf("Read(%s) failed: %v", hash, err)
+				}
+				if objType != wantType || !bytes.Equal(data, want) {
+					t.Fatalf("packed object %s does not match the loose object", hash)
+				}
+
+				objType, size, err := p.Info(hash)
+				if err != nil || objType != wantType || size != int64(len(want)) {
+					t.Fatalf("Info(%s) = %s, %d, %v", hash, objType, size, err)
+				}
+			}
+
+			stats, err := p.Verify()
+			if err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			deltas := 0
+			for _, st := range stats {
+				if st.Depth > 0 {
+					deltas++
+				}
+			}
+			if deltas == 0 {
+				t.Fatalf("expected similar blobs to be stored as deltas")
+			}
+		})
+	}
+}
+
+func TestVerifyDetectsCorruption(t *testing.T) {
+	r, hashes := newSource(t)
+	dir := t.TempDir()
+	checksum, err := pack.Write(dir, r, hashes, pack.Options{})
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	path := filepath.Join(dir, "pack-"+checksum+".pack")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)/2] ^= 0xff
+	os.Chmod(path, 0644)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := pack.Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer p.Close()
+	if _, err := p.Verify(); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected a checksum mismatch, got %v", err)
+	}
+}
+
+// appendVarint appends v seven bits at a time, least significant first,
+// as delta sizes and the tail of entry headers are encoded.
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func TestUntrustedSizesAreRejected(t *testing.T) {
+	// An index claiming more objects than it holds
+	var buf bytes.Buffer
+	if err := pack.WriteIndex(&buf, []pack.IndexEntry{{Hash: strings.Repeat("a", 40), Offset: 12}}, strings.Repeat("c", 40)); err != nil {
+		t.Fatalf("WriteIndex failed: %v", err)
+	}
+	idxData := buf.Bytes()
+	binary.BigEndian.PutUint32(idxData[8+255*4:], 0xffffffff)
+	sum := sha1.Sum(idxData[:len(idxData)-20])
+	copy(idxData[len(idxData)-20:], sum[:])
+	if _, err := pack.ParseIndex(idxData); err == nil {
+		t.Fatalf("expected an error for an object count larger than the index")
+	}
+
+	// A delta whose result is larger than its instructions could build
+	delta := appendVarint(appendVarint(nil, 0), 1<<40)
+	delta = append(delta, 1, 'x')
+	if _, err := pack.ApplyDelta(nil, delta); err == nil {
+		t.Fatalf("expected an error for an oversized delta result")
+	}
+
+	// A blob entry whose header claims far more than the pack holds
+	var body bytes.Buffer
+	zw := zlib.NewWriter(&body)
+	zw.Write([]byte("x"))
+	zw.Close()
+	size := uint64(1) << 40
+	data := []byte("PACK\x00\x00\x00\x02\x00\x00\x00\x01")
+	data = append(data, 0x80|3<<4|byte(size&0x0f))
+	data = appendVarint(data, size>>4)
+	data = append(data, body.Bytes()...)
+	trailer := sha1.Sum(data)
+	data = append(data, trailer[:]...)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pack-"+hex.EncodeToString(trailer[:])+".pack")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	hash := strings.Repeat("b", 40)
+	if err := pack.WriteIndex(&buf, []pack.IndexEntry{{Hash: hash, Offset: 12}}, hex.EncodeToString(trailer[:])); err != nil {
+		t.Fatalf("WriteIndex failed: %v", err)
+	}
+	if err := os.WriteFile(pack.IndexPath(path), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := pack.Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer p.Close()
+	if _, _, err := p.Read(hash); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Fatalf("expected an error for an oversized entry, got %v", err)
+	}
+}
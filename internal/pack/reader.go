@@ -0,0 +1,352 @@
+package pack
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+)
+
+// maxDeltaChain bounds how many deltas are followed to reach a base, so a
+// corrupt pack with a REF_DELTA cycle cannot recurse forever.
+const maxDeltaChain = 10000
+
+// maxInflateRatio is the most deflate can expand its input, with every
+// two bits of a block coding a 258 byte match.
+const maxInflateRatio = 1032
+
+// Pack is an open packfile together with its index.
+type Pack struct {
+	Path  string // path of the .pack file
+	Index *Index
+
+	file    *os.File
+	dataEnd int64 // offset of the trailing checksum
+}
+
+// Open opens the .pack file at path and the .idx file next to it. The
+// header and the pack checksum recorded in the index are checked; use
+// Verify to check every object.
+func Open(path string) (*Pack, error) {
+	idxData, err := os.ReadFile(IndexPath(path))
+	if err != nil {
+		return nil, err
+	}
+	idx, err := ParseIndex(idxData)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", IndexPath(path), err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	p := &Pack{Path: path, Index: idx, file: f}
+	if err := p.checkHeader(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// IndexPath returns the path of the .idx file belonging to a .pack file.
+func IndexPath(packPath string) string {
+	return strings.TrimSuffix(packPath, ".pack") + ".idx"
+}
+
+func (p *Pack) checkHeader() error {
+	fi, err := p.file.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Size() < 12+hashSize {
+		return errors.New("pack too short")
+	}
+	p.dataEnd = fi.Size() - hashSize
+
+	header := make([]byte, 12)
+	if _, err := p.file.ReadAt(header, 0); err != nil {
+		return err
+	}
+	if string(header[:4]) != packSignature || binary.BigEndian.Uint32(header[4:8]) != packVersion {
+		return errors.New("unsupported pack version")
+	}
+	if count := binary.BigEndian.Uint32(header[8:12]); int(count) != len(p.Index.Entries) {
+		return fmt.Errorf("pack has %d objects but its index has %d", count, len(p.Index.Entries))
+	}
+
+	trailer := make([]byte, hashSize)
+	if _, err := p.file.ReadAt(trailer, p.dataEnd); err != nil {
+		return err
+	}
+	if hex.EncodeToString(trailer) != p.Index.PackChecksum {
+		return errors.New("pack checksum does not match its index")
+	}
+	return nil
+}
+
+// Close releases the pack file.
+func (p *Pack) Close() error {
+	return p.file.Close()
+}
+
+// Checksum returns the hex SHA-1 trailer that names the pack.
+func (p *Pack) Checksum() string {
+	return p.Index.PackChecksum
+}
+
+// Has reports whether the pack contains hash.
+func (p *Pack) Has(hash string) bool {
+	_, ok := p.Index.Find(hash)
+	return ok
+}
+
+// Read returns the type and body of hash, resolving any deltas.
+func (p *Pack) Read(hash string) (string, []byte, error) {
+	e, ok := p.Index.Find(hash)
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %s", ErrNotFound, hash)
+	}
+	return p.readAt(e.Offset, 0)
+}
+
+// Info returns the type and body size of hash. Only the entry headers of
+// a delta chain are read, not the full objects.
+func (p *Pack) Info(hash string) (string, int64, error) {
+	e, ok := p.Index.Find(hash)
+	if !ok {
+		return "", 0, fmt.Errorf("%w: %s", ErrNotFound, hash)
+	}
+
+	offset := e.Offset
+	var size int64 = -1
+	for depth := 0; depth < maxDeltaChain; depth++ {
+		ent, err := p.entry(offset)
+		if err != nil {
+			return "", 0, err
+		}
+		if ent.code != typeOfsDelta && ent.code != typeRefDelta {
+			objType, err := typeName(ent.code)
+			if size < 0 {
+				size = ent.size
+			}
+			return objType, size, err
+		}
+
+		if size < 0 {
+			// The result size is the second varint of the delta
+			head := make([]byte, 20)
+			n, err := io.ReadFull(ent.body, head)
+			if err != nil && err != io.ErrUnexpectedEOF {
+				return "", 0, err
+			}
+			rd := bytes.NewReader(head[:n])
+			if _, err := readDeltaSize(rd); err != nil {
+				return "", 0, err
+			}
+			if size, err = readDeltaSize(rd); err != nil {
+				return "", 0, err
+			}
+		}
+		if offset, err = p.baseOffset(ent); err != nil {
+			return "", 0, err
+		}
+	}
+	return "", 0, errors.New("delta chain too long")
+}
+
+// entry is a decoded pack entry header with a reader over its inflated data.
+type entry struct {
+	code   byte
+	size   int64 // inflated size of the data, which is the delta for deltas
+	base   int64 // absolute base offset for OFS_DELTA
+	ref    string
+	offset int64
+	body   io.Reader
+}
+
+func (p *Pack) entry(offset int64) (*entry, error) {
+	if offset < 12 || offset >= p.dataEnd {
+		return nil, fmt.Errorf("pack offset %d out of range", offset)
+	}
+	br := bufio.NewReader(io.NewSectionReader(p.file, offset, p.dataEnd-offset))
+
+	code, size, err := decodeEntryHeader(br)
+	if err != nil {
+		return nil, fmt.Errorf("pack entry at %d: %w", offset, err)
+	}
+	if size > (p.dataEnd-offset)*maxInflateRatio {
+		return nil, fmt.Errorf("pack entry at %d: size %d exceeds what the rest of the pack can hold", offset, size)
+	}
+	e := &entry{code: code, size: size, offset: offset}
+
+	switch code {
+	case typeOfsDelta:
+		rel, err := decodeOffset(br)
+		if err != nil {
+			return nil, fmt.Errorf("pack entry at %d: %w", offset, err)
+		}
+		if rel <= 0 || rel > offset {
+			return nil, fmt.Errorf("pack entry at %d has invalid base offset", offset)
+		}
+		e.base = offset - rel
+	case typeRefDelta:
+		ref := make([]byte, hashSize)
+		if _, err := io.ReadFull(br, ref); err != nil {
+			return nil, fmt.Errorf("pack entry at %d: %w", offset, err)
+		}
+		e.ref = hex.EncodeToString(ref)
+	}
+
+	zr, err := zlib.NewReader(br)
+	if err != nil {
+		return nil, fmt.Errorf("pack entry at %d: %w", offset, err)
+	}
+	e.body = zr
+	return e, nil
+}
+
+// data inflates the entry. The buffer grows with what is actually read
+// instead of being sized from the header up front.
+func (e *entry) data() ([]byte, error) {
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(e.body, e.size))
+	if err != nil {
+		return nil, fmt.Errorf("pack entry at %d: %w", e.offset, err)
+	}
+	if n != e.size {
+		return nil, fmt.Errorf("pack entry at %d: %w", e.offset, io.ErrUnexpectedEOF)
+	}
+	return buf.Bytes(), nil
+}
+
+func (p *Pack) baseOffset(e *entry) (int64, error) {
+	if e.code == typeOfsDelta {
+		return e.base, nil
+	}
+	base, ok := p.Index.Find(e.ref)
+	if !ok {
+		return 0, fmt.Errorf("delta base %s is not in pack", e.ref)
+	}
+	return base.Offset, nil
+}
+
+func (p *Pack) readAt(offset int64, depth int) (string, []byte, error) {
+	if depth > maxDeltaChain {
+		return "", nil, errors.New("delta chain too long")
+	}
+	e, err := p.entry(offset)
+	if err != nil {
+		return "", nil, err
+	}
+	data, err := e.data()
+	if err != nil {
+		return "", nil, err
+	}
+	if e.code != typeOfsDelta && e.code != typeRefDelta {
+		objType, err := typeName(e.code)
+		return objType, data, err
+	}
+
+	baseOffset, err := p.baseOffset(e)
+	if err != nil {
+		return "", nil, err
+	}
+	objType, base, err := p.readAt(baseOffset, depth+1)
+	if err != nil {
+		return "", nil, err
+	}
+	result, err := ApplyDelta(base, data)
+	if err != nil {
+		return "", nil, fmt.Errorf("pack entry at %d: %w", offset, err)
+	}
+	return objType, result, nil
+}
+
+// Stat describes one object of a verified pack.
+type Stat struct {
+	Hash       string
+	Type       string
+	Size       int64 // size of the object body
+	PackedSize int64 // bytes used in the pack, including the entry header
+	Offset     int64
+	Depth      int    // length of the delta chain, 0 for whole objects
+	Base       string // delta base, if any
+}
+
+// Verify checks the pack trailer, the CRC32 of every entry recorded in
+// the index, and that every object hashes to its name. It returns the
+// objects in pack order.
+func (p *Pack) Verify() ([]Stat, error) {
+	h := sha1.New()
+	if _, err := io.Copy(h, io.NewSectionReader(p.file, 0, p.dataEnd)); err != nil {
+		return nil, err
+	}
+	if hex.EncodeToString(h.Sum(nil)) != p.Index.PackChecksum {
+		return nil, fmt.Errorf("%s: pack checksum mismatch", p.Path)
+	}
+
+	entries := append([]IndexEntry(nil), p.Index.Entries...)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Offset < entries[j].Offset })
+
+	byOffset := make(map[int64]string, len(entries))
+	for _, e := range entries {
+		byOffset[e.Offset] = e.Hash
+	}
+
+	stats := make([]Stat, 0, len(entries))
+	for _, ie := range entries {
+		end := p.Index.entryEnd(ie.Offset, p.dataEnd)
+		crc := crc32.NewIEEE()
+		if _, err := io.Copy(crc, io.NewSectionReader(p.file, ie.Offset, end-ie.Offset)); err != nil {
+			return nil, err
+		}
+		if crc.Sum32() != ie.CRC32 {
+			return nil, fmt.Errorf("%s: CRC mismatch for object %s", p.Path, ie.Hash)
+		}
+
+		objType, data, err := p.readAt(ie.Offset, 0)
+		if err != nil {
+			return nil, fmt.Errorf("%s: object %s: %w", p.Path, ie.Hash, err)
+		}
+		if got := internal.Hash(objType, data); got != ie.Hash {
+			return nil, fmt.Errorf("%s: object %s hashes to %s", p.Path, ie.Hash, got)
+		}
+
+		st := Stat{
+			Hash:       ie.Hash,
+			Type:       objType,
+			Size:       int64(len(data)),
+			PackedSize: end - ie.Offset,
+			Offset:     ie.Offset,
+		}
+		for offset := ie.Offset; ; st.Depth++ {
+			e, err := p.entry(offset)
+			if err != nil {
+				return nil, err
+			}
+			if e.code != typeOfsDelta && e.code != typeRefDelta {
+				break
+			}
+			if offset, err = p.baseOffset(e); err != nil {
+				return nil, err
+			}
+			if st.Depth == 0 {
+				st.Base = byOffset[offset]
+			}
+		}
+		stats = append(stats, st)
+	}
+	return stats, nil
+}
@@ -0,0 +1,311 @@
+package pack
+
+import (
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	DefaultWindow = 10
+	DefaultDepth  = 50
+
+	// NoCompression stores entries without compressing them. The zero
+	// Options.Compression already means zlib.DefaultCompression.
+	NoCompression = -3
+
+	// bigFileThreshold is the size above which objects are stored whole
+	// and never used as delta bases.
+	bigFileThreshold = 512 << 20
+)
+
+// Source provides the objects to be packed. *repo.Repo implements it.
+type Source interface {
+	ObjectInfo(hash string) (string, int64, error)
+	ReadRawObject(hash string) (string, []byte, error)
+}
+
+// Options controls delta compression when writing a pack.
+type Options struct {
+	Window      int  // number of previous objects tried as delta bases
+	MaxDepth    int  // longest allowed delta chain
+	RefDelta    bool // name bases by hash (REF_DELTA) instead of by offset
+	Compression int  // zlib level of entry data, or NoCompression
+}
+
+func (o Options) withDefaults() Options {
+	switch o.Compression {
+	case 0:
+		o.Compression = zlib.DefaultCompression
+	case NoCompression:
+		o.Compression = zlib.NoCompression
+	}
+	if o.Window == 0 {
+		o.Window = DefaultWindow
+	}
+	if o.MaxDepth == 0 {
+		o.MaxDepth = DefaultDepth
+	}
+	return o
+}
+
+// Write packs the given objects into dir as pack-<checksum>.pack with a
+// matching .idx, and returns the checksum. Both files are written under
+// temporary names and renamed into place once complete.
+func Write(dir string, src Source, hashes []string, opts Options) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(dir, "tmp_pack_")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	checksum, entries, err := Encode(tmp, src, hashes, opts)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	idxTmp, err := os.CreateTemp(dir, "tmp_idx_")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(idxTmp.Name())
+
+	err = WriteIndex(idxTmp, entries, checksum)
+	if err == nil {
+		err = idxTmp.Sync()
+	}
+	if cerr := idxTmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// The pack goes first so that an index never names a missing pack
+	base := filepath.Join(dir, "pack-"+checksum)
+	for _, f := range []*os.File{tmp, idxTmp} {
+		if err := os.Chmod(f.Name(), 0444); err != nil {
+			return "", err
+		}
+	}
+	if err := os.Rename(tmp.Name(), base+".pack"); err != nil {
+		return "", err
+	}
+	if err := os.Rename(idxTmp.Name(), base+".idx"); err != nil {
+		return "", err
+	}
+	return checksum, nil
+}
+
+// packObject is an object waiting to be written, or one kept in the
+// delta window after being written.
+type packObject struct {
+	hash   string
+	typ    string
+	size   int64
+	data   []byte
+	offset int64
+	depth  int
+}
+
+// Encode writes a pack of the given objects to w and returns its checksum
+// and index entries. Objects are grouped by type and ordered from largest
+// to smallest, so that each one can be stored as a delta against a
+// larger object written just before it.
+func Encode(w io.Writer, src Source, hashes []string, opts Options) (string, []IndexEntry, error) {
+	opts = opts.withDefaults()
+
+	objects := make([]*packObject, 0, len(hashes))
+	seen := make(map[string]bool, len(hashes))
+	for _, h := range hashes {
+		if seen[h] {
+			continue
+		}
+		seen[h] = true
+		typ, size, err := src.ObjectInfo(h)
+		if err != nil {
+			return "", nil, err
+		}
+		objects = append(objects, &packObject{hash: h, typ: typ, size: size})
+	}
+	sort.SliceStable(objects, func(i, j int) bool {
+		if objects[i].typ != objects[j].typ {
+			return objects[i].typ < objects[j].typ
+		}
+		return objects[i].size > objects[j].size
+	})
+
+	pw := &packWriter{w: w, sum: sha1.New(), level: opts.Compression}
+	header := make([]byte, 12)
+	copy(header, packSignature)
+	binary.BigEndian.PutUint32(header[4:], packVersion)
+	binary.BigEndian.PutUint32(header[8:], uint32(len(objects)))
+	if err := pw.write(header); err != nil {
+		return "", nil, err
+	}
+
+	entries := make([]IndexEntry, 0, len(objects))
+	var window []*packObject
+	for _, obj := range objects {
+		typ, data, err := src.ReadRawObject(obj.hash)
+		if err != nil {
+			return "", nil, err
+		}
+		if typ != obj.typ || int64(len(data)) != obj.size {
+			return "", nil, fmt.Errorf("object %s changed while packing", obj.hash)
+		}
+		obj.data = data
+		obj.offset = pw.offset
+
+		base, delta := bestDelta(obj, window, opts.MaxDepth)
+
+		pw.crc = crc32.NewIEEE()
+		if base == nil {
+			err = pw.writeWhole(obj)
+		} else {
+			obj.depth = base.depth + 1
+			err = pw.writeDelta(obj, base, delta, opts.RefDelta)
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		entries = append(entries, IndexEntry{Hash: obj.hash, Offset: obj.offset, CRC32: pw.crc.Sum32()})
+		pw.crc = nil
+
+		if obj.size > bigFileThreshold {
+			obj.data = nil
+			continue
+		}
+		window = append(window, obj)
+		if len(window) > opts.Window {
+			window[0].data = nil
+			window = window[1:]
+		}
+	}
+
+	checksum := pw.sum.Sum(nil)
+	if _, err := w.Write(checksum); err != nil {
+		return "", nil, err
+	}
+	return hex.EncodeToString(checksum), entries, nil
+}
+
+// bestDelta returns the window object that gives the smallest delta for
+// obj, or nil when storing obj whole is cheaper.
+func bestDelta(obj *packObject, window []*packObject, maxDepth int) (*packObject, []byte) {
+	if obj.size > bigFileThreshold {
+		return nil, nil
+	}
+
+	var best *packObject
+	var bestDelta []byte
+	for i := len(window) - 1; i >= 0; i-- {
+		base := window[i]
+		if base.typ != obj.typ || base.depth >= maxDepth {
+			continue
+		}
+		// A delta is only worthwhile if it is well under the object size
+		limit := obj.size / 2
+		if bestDelta != nil {
+			limit = int64(len(bestDelta))
+		}
+		if base.size-obj.size > limit*16 {
+			continue
+		}
+		delta := CreateDelta(base.data, obj.data)
+		if int64(len(delta)) < limit {
+			best, bestDelta = base, delta
+		}
+	}
+	return best, bestDelta
+}
+
+// packWriter tracks the pack offset and checksum, and the CRC32 of the
+// entry being written.
+type packWriter struct {
+	w      io.Writer
+	sum    hash.Hash
+	crc    hash.Hash32
+	offset int64
+	level  int
+}
+
+func (pw *packWriter) write(p []byte) error {
+	n, err := pw.w.Write(p)
+	pw.sum.Write(p[:n])
+	if pw.crc != nil {
+		pw.crc.Write(p[:n])
+	}
+	pw.offset += int64(n)
+	return err
+}
+
+func (pw *packWriter) Write(p []byte) (int, error) {
+	if err := pw.write(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (pw *packWriter) writeWhole(obj *packObject) error {
+	code, err := typeCode(obj.typ)
+	if err != nil {
+		return err
+	}
+	if err := pw.write(encodeEntryHeader(code, obj.size)); err != nil {
+		return err
+	}
+	return pw.compress(obj.data)
+}
+
+func (pw *packWriter) writeDelta(obj, base *packObject, delta []byte, refDelta bool) error {
+	if refDelta {
+		ref, err := hex.DecodeString(base.hash)
+		if err != nil {
+			return err
+		}
+		if err := pw.write(encodeEntryHeader(typeRefDelta, int64(len(delta)))); err != nil {
+			return err
+		}
+		if err := pw.write(ref); err != nil {
+			return err
+		}
+	} else {
+		if err := pw.write(encodeEntryHeader(typeOfsDelta, int64(len(delta)))); err != nil {
+			return err
+		}
+		if err := pw.write(encodeOffset(obj.offset - base.offset)); err != nil {
+			return err
+		}
+	}
+	return pw.compress(delta)
+}
+
+func (pw *packWriter) compress(data []byte) error {
+	zw, err := zlib.NewWriterLevel(pw, pw.level)
+	if err != nil {
+		return err
+	}
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
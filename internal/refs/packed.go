@@ -0,0 +1,111 @@
+package refs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// PackedRefsFile holds refs that git has moved out of individual files,
+// one "<hash> <name>" line each. A loose ref file shadows a packed entry
+// of the same name.
+const PackedRefsFile = "packed-refs"
+
+// readPackedRefs returns the refs listed in packed-refs. Header comments
+// and the "^<hash>" lines git adds after annotated tags are skipped.
+func readPackedRefs(r *repo.Repo) ([]Ref, error) {
+	f, err := os.Open(filepath.Join(r.Path, PackedRefsFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var result []Ref
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "^") {
+			continue
+		}
+		hash, name, ok := strings.Cut(line, " ")
+		if !ok || !internal.IsHash(hash) {
+			return nil, fmt.Errorf("invalid %s line %q", PackedRefsFile, line)
+		}
+		result = append(result, Ref{Name: name, Hash: hash})
+	}
+	return result, scanner.Err()
+}
+
+// readPackedRef returns the hash packed-refs records for name.
+func readPackedRef(r *repo.Repo, name string) (string, error) {
+	packed, err := readPackedRefs(r)
+	if err != nil {
+		return "", err
+	}
+	for _, ref := range packed {
+		if ref.Name == name {
+			return ref.Hash, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// removePackedRef rewrites packed-refs without name while holding
+// packed-refs.lock, and reports whether name was listed.
+func removePackedRef(r *repo.Repo, name string) (bool, error) {
+	path := filepath.Join(r.Path, PackedRefsFile)
+	f, err := lockRef(path, PackedRefsFile)
+	if err != nil {
+		return false, err
+	}
+	lockPath := path + lockSuffix
+	defer os.Remove(lockPath)
+	defer f.Close()
+
+	// Read only once the lock is held, so a concurrent rewrite cannot be
+	// lost between reading and renaming
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var kept bytes.Buffer
+	found, skipPeeled := false, false
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "^") && skipPeeled {
+			continue
+		}
+		skipPeeled = false
+		if _, refName, ok := strings.Cut(strings.TrimSuffix(line, "\n"), " "); ok && refName == name && !strings.HasPrefix(line, "#") {
+			found, skipPeeled = true, true
+			continue
+		}
+		kept.WriteString(line)
+	}
+	if !found {
+		return false, nil
+	}
+
+	if _, err := f.Write(kept.Bytes()); err != nil {
+		return false, err
+	}
+	if err := f.Close(); err != nil {
+		return false, err
+	}
+	return true, os.Rename(lockPath, path)
+}
@@ -0,0 +1,391 @@
+package refs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+const (
+	Head = "HEAD"
+	// MergeHead holds the commit being merged while a merge with
+	// conflicts waits to be committed.
+	MergeHead = "MERGE_HEAD"
+
+	// ZeroHash is the old value that asserts a ref is being created.
+	ZeroHash = "0000000000000000000000000000000000000000"
+
+	symbolicPrefix = "ref: "
+	lockSuffix     = ".lock"
+	// maxSymbolicDepth bounds how many symbolic refs are followed before giving up.
+	maxSymbolicDepth = 5
+)
+
+var (
+	// ErrNotFound is returned when a ref, or the ref a symbolic ref points to, does not exist yet.
+	ErrNotFound = errors.New("ref not found")
+	// ErrNotSymbolic is returned when a symbolic ref was expected but name holds a hash.
+	ErrNotSymbolic = errors.New("not a symbolic ref")
+)
+
+// Ref is a named pointer to an object.
+type Ref struct {
+	Name string
+	Hash string
+}
+
+// Resolve follows name through any symbolic refs and returns the object hash it points to.
+func Resolve(r *repo.Repo, name string) (string, error) {
+	target, err := Deref(r, name)
+	if err != nil {
+		return "", err
+	}
+	return readRef(r, target)
+}
+
+// Update points name at newHash, following symbolic refs. If oldHash is
+// non-empty the ref must currently hold oldHash; pass ZeroHash to require
+// that the ref does not exist yet.
+func Update(r *repo.Repo, name, newHash, oldHash string) error {
+	target, err := Deref(r, name)
+	if err != nil {
+		return err
+	}
+	return UpdateNoDeref(r, target, newHash, oldHash)
+}
+
+// UpdateNoDeref is like Update but overwrites name itself even if it is a
+// symbolic ref. Writing a hash to HEAD this way detaches it.
+func UpdateNoDeref(r *repo.Repo, name, newHash, oldHash string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if !internal.IsHash(newHash) {
+		return fmt.Errorf("invalid object name %q", newHash)
+	}
+	if !r.HasObject(newHash) {
+		return fmt.Errorf("cannot update %s: object %s does not exist", name, newHash)
+	}
+	return writeRef(r, name, newHash+"\n", oldHash)
+}
+
+// Delete removes name, following symbolic refs. oldHash has the same meaning as in Update.
+func Delete(r *repo.Repo, name, oldHash string) error {
+	target, err := Deref(r, name)
+	if err != nil {
+		return err
+	}
+
+	return deleteRef(r, target, oldHash)
+}
+
+// DeleteNoDeref is like Delete but removes name itself even if it is a
+// symbolic ref.
+func DeleteNoDeref(r *repo.Repo, name, oldHash string) error {
+	return deleteRef(r, name, oldHash)
+}
+
+// ReadSymbolic returns the ref that the symbolic ref name points to.
+func ReadSymbolic(r *repo.Repo, name string) (string, error) {
+	data, err := os.ReadFile(refPath(r, name))
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	content := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(content, symbolicPrefix) {
+		return "", fmt.Errorf("ref %s is %w", name, ErrNotSymbolic)
+	}
+	return strings.TrimPrefix(content, symbolicPrefix), nil
+}
+
+// SetSymbolic makes name a symbolic ref pointing at target, e.g. HEAD -> refs/heads/main.
+func SetSymbolic(r *repo.Repo, name, target string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(target, repo.RefsDir+"/") {
+		return fmt.Errorf("refusing to point %s outside of refs/: %s", name, target)
+	}
+	if err := ValidateName(target); err != nil {
+		return err
+	}
+	return writeRef(r, name, symbolicPrefix+target+"\n", "")
+}
+
+// CurrentBranch returns the full name of the branch HEAD points to. detached
+// is true when HEAD holds a commit hash directly.
+func CurrentBranch(r *repo.Repo) (branch string, detached bool, err error) {
+	target, err := ReadSymbolic(r, Head)
+	if errors.Is(err, ErrNotSymbolic) {
+		return "", true, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return target, false, nil
+}
+
+// List returns every ref below prefix (e.g. "refs/heads/"), sorted by name.
+// An empty prefix lists all refs under refs/, including packed ones.
+func List(r *repo.Repo, prefix string) ([]Ref, error) {
+	root := filepath.Join(r.Path, repo.RefsDir)
+
+	var result []Ref
+	loose := make(map[string]bool)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(path, lockSuffix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(r.Path, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if !strings.HasPrefix(name, prefix) {
+			return nil
+		}
+
+		loose[name] = true
+
+		hash, err := Resolve(r, name)
+		if errors.Is(err, ErrNotFound) {
+			// Dangling symbolic ref
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		result = append(result, Ref{Name: name, Hash: hash})
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	packed, err := readPackedRefs(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range packed {
+		if !loose[ref.Name] && strings.HasPrefix(ref.Name, prefix) {
+			result = append(result, ref)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// ResolveShort resolves a possibly abbreviated ref name such as "main" or
+// "tags/v1" by trying the same locations as git, in order.
+func ResolveShort(r *repo.Repo, name string) (string, error) {
+	candidates := []string{name}
+	if name != Head {
+		candidates = append(candidates,
+			repo.RefsDir+"/"+name,
+			repo.TagsDir+"/"+name,
+			repo.HeadsDir+"/"+name,
+			"refs/remotes/"+name,
+			"refs/remotes/"+name+"/"+Head,
+		)
+	}
+
+	for _, c := range candidates {
+		if ValidateName(c) != nil {
+			continue
+		}
+		hash, err := Resolve(r, c)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return hash, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// Deref follows symbolic refs starting at name and returns the name of
+// the first non-symbolic ref, which need not exist yet.
+func Deref(r *repo.Repo, name string) (string, error) {
+	for i := 0; i < maxSymbolicDepth; i++ {
+		target, err := ReadSymbolic(r, name)
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrNotSymbolic) {
+			return name, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		name = target
+	}
+	return "", fmt.Errorf("too many levels of symbolic refs at %s", name)
+}
+
+// ValidateName checks that name is HEAD, a pseudo ref such as MERGE_HEAD,
+// or a well formed ref below refs/.
+func ValidateName(name string) error {
+	if isPseudoRef(name) {
+		return nil
+	}
+	if !strings.HasPrefix(name, repo.RefsDir+"/") {
+		return fmt.Errorf("invalid ref name %q: must start with %s/", name, repo.RefsDir)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == "" || part == "." || part == ".." || strings.HasPrefix(part, ".") || strings.HasSuffix(part, lockSuffix) {
+			return fmt.Errorf("invalid ref name %q", name)
+		}
+	}
+	if strings.ContainsAny(name, " ~^:?*[\\\x7f") || strings.Contains(name, "..") || strings.Contains(name, "@{") {
+		return fmt.Errorf("invalid ref name %q", name)
+	}
+	for _, c := range name {
+		if c < 0x20 {
+			return fmt.Errorf("invalid ref name %q", name)
+		}
+	}
+	return nil
+}
+
+// isPseudoRef reports whether name is one of the refs git keeps at the top
+// of the repository, which are upper case and end in HEAD.
+func isPseudoRef(name string) bool {
+	if !strings.HasSuffix(name, Head) {
+		return false
+	}
+	for _, c := range name {
+		if (c < 'A' || c > 'Z') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// readRef returns the hash stored directly in name.
+func readRef(r *repo.Repo, name string) (string, error) {
+	data, err := os.ReadFile(refPath(r, name))
+	if os.IsNotExist(err) {
+		return readPackedRef(r, name)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// writeRef replaces the contents of name while holding name.lock, so
+// concurrent writers cannot interleave and readers never see a partial file.
+func writeRef(r *repo.Repo, name, content, oldHash string) error {
+	path := refPath(r, name)
+	f, err := lockRef(path, name)
+	if err != nil {
+		return err
+	}
+
+	lockPath := path + lockSuffix
+	if err := checkOld(r, name, oldHash); err != nil {
+		f.Close()
+		os.Remove(lockPath)
+		return err
+	}
+
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		os.Remove(lockPath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(lockPath)
+		return err
+	}
+
+	return os.Rename(lockPath, path)
+}
+
+// deleteRef removes name while holding name.lock.
+func deleteRef(r *repo.Repo, name, oldHash string) error {
+	path := refPath(r, name)
+	f, err := lockRef(path, name)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path + lockSuffix)
+	defer f.Close()
+
+	if err := checkOld(r, name, oldHash); err != nil {
+		return err
+	}
+
+	removed := true
+	if err := os.Remove(path); os.IsNotExist(err) {
+		removed = false
+	} else if err != nil {
+		return err
+	}
+
+	// A packed copy would otherwise become visible again
+	packed, err := removePackedRef(r, name)
+	if err != nil {
+		return err
+	}
+	if !removed && !packed {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return nil
+}
+
+// lockRef creates the lock file for the ref stored at path.
+func lockRef(path, name string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), repo.DirPerm); err != nil {
+		return nil, err
+	}
+
+	lockPath := path + lockSuffix
+	f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, repo.FilePerm)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, fmt.Errorf("unable to lock %s: %s exists", name, lockPath)
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+func checkOld(r *repo.Repo, name, oldHash string) error {
+	if oldHash == "" {
+		return nil
+	}
+
+	current, err := Resolve(r, name)
+	if errors.Is(err, ErrNotFound) {
+		current = ZeroHash
+	} else if err != nil {
+		return err
+	}
+
+	if current != oldHash {
+		return fmt.Errorf("cannot update %s: expected %s but it is %s", name, oldHash, current)
+	}
+	return nil
+}
+
+func refPath(r *repo.Repo, name string) string {
+	return filepath.Join(r.Path, filepath.FromSlash(name))
+}
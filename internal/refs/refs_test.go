@@ -0,0 +1,311 @@
+package refs_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/testrepo"
+)
+
+func setupRepo(t *testing.T) (*repo.Repo, string, string) {
+	t.Helper()
+	r := testrepo.New(t)
+
+	first, err := r.WriteObject(internal.NewBlob([]byte("first\n")))
+	if err != nil {
+		t.Fatalf("WriteObject returned error: %v", err)
+	}
+	second, err := r.WriteObject(internal.NewBlob([]byte("second\n")))
+	if err != nil {
+		t.Fatalf("WriteObject returned error: %v", err)
+	}
+	return r, first, second
+}
+
+func TestResolve_UnbornBranch(t *testing.T) {
+	r, _, _ := setupRepo(t)
+
+	if _, err := refs.Resolve(r, refs.Head); !errors.Is(err, refs.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for unborn HEAD, got %v", err)
+	}
+
+	branch, detached, err := refs.CurrentBranch(r)
+	if err != nil {
+		t.Fatalf("CurrentBranch returned error: %v", err)
+	}
+	if detached || branch != "refs/heads/main" {
+		t.Fatalf("expected HEAD on refs/heads/main, got %q (detached=%v)", branch, detached)
+	}
+}
+
+func TestUpdate_ThroughHead(t *testing.T) {
+	r, first, _ := setupRepo(t)
+
+	if err := refs.Update(r, refs.Head, first, refs.ZeroHash); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(r.Path, repo.HeadsDir, "main"))
+	if err != nil {
+		t.Fatalf("failed to read branch ref: %v", err)
+	}
+	if string(data) != first+"\n" {
+		t.Fatalf("unexpected branch content %q", data)
+	}
+
+	hash, err := refs.Resolve(r, refs.Head)
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if hash != first {
+		t.Fatalf("expected HEAD to resolve to %s, got %s", first, hash)
+	}
+}
+
+func TestUpdate_CompareAndSwap(t *testing.T) {
+	r, first, second := setupRepo(t)
+
+	if err := refs.Update(r, "refs/heads/main", first, ""); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+
+	// Creating again must fail because the ref exists
+	if err := refs.Update(r, "refs/heads/main", second, refs.ZeroHash); err == nil {
+		t.Fatalf("expected error creating existing ref, got nil")
+	}
+	// Wrong old value must fail
+	if err := refs.Update(r, "refs/heads/main", second, second); err == nil {
+		t.Fatalf("expected error for stale old value, got nil")
+	}
+	if err := refs.Update(r, "refs/heads/main", second, first); err != nil {
+		t.Fatalf("Update with correct old value returned error: %v", err)
+	}
+
+	hash, err := refs.Resolve(r, "refs/heads/main")
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if hash != second {
+		t.Fatalf("expected %s, got %s", second, hash)
+	}
+
+	if _, err := os.Stat(filepath.Join(r.Path, repo.HeadsDir, "main.lock")); !os.IsNotExist(err) {
+		t.Fatalf("expected lock file to be cleaned up")
+	}
+}
+
+func TestUpdate_RejectsMissingObject(t *testing.T) {
+	r, _, _ := setupRepo(t)
+
+	if err := refs.Update(r, "refs/heads/main", "1234567890123456789012345678901234567890", ""); err == nil {
+		t.Fatalf("expected error for missing object, got nil")
+	}
+}
+
+func TestDetachedHead(t *testing.T) {
+	r, first, _ := setupRepo(t)
+
+	if err := refs.UpdateNoDeref(r, refs.Head, first, ""); err != nil {
+		t.Fatalf("UpdateNoDeref returned error: %v", err)
+	}
+
+	_, detached, err := refs.CurrentBranch(r)
+	if err != nil {
+		t.Fatalf("CurrentBranch returned error: %v", err)
+	}
+	if !detached {
+		t.Fatalf("expected HEAD to be detached")
+	}
+
+	if _, err := refs.ReadSymbolic(r, refs.Head); !errors.Is(err, refs.ErrNotSymbolic) {
+		t.Fatalf("expected ErrNotSymbolic, got %v", err)
+	}
+
+	if err := refs.SetSymbolic(r, refs.Head, "refs/heads/topic"); err != nil {
+		t.Fatalf("SetSymbolic returned error: %v", err)
+	}
+	target, err := refs.ReadSymbolic(r, refs.Head)
+	if err != nil {
+		t.Fatalf("ReadSymbolic returned error: %v", err)
+	}
+	if target != "refs/heads/topic" {
+		t.Fatalf("expected HEAD -> refs/heads/topic, got %s", target)
+	}
+}
+
+func TestListAndDelete(t *testing.T) {
+	r, first, second := setupRepo(t)
+
+	for name, hash := range map[string]string{
+		"refs/heads/main":  first,
+		"refs/heads/topic": second,
+		"refs/tags/v1":     first,
+	} {
+		if err := refs.Update(r, name, hash, ""); err != nil {
+			t.Fatalf("Update %s returned error: %v", name, err)
+		}
+	}
+
+	heads, err := refs.List(r, "refs/heads/")
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if len(heads) != 2 || heads[0].Name != "refs/heads/main" || heads[1].Name != "refs/heads/topic" {
+		t.Fatalf("unexpected heads: %+v", heads)
+	}
+
+	if err := refs.Delete(r, "refs/heads/topic", first); err == nil {
+		t.Fatalf("expected error deleting with stale old value, got nil")
+	}
+	if err := refs.Delete(r, "refs/heads/topic", second); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+
+	all, err := refs.List(r, "")
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected 2 refs after delete, got %+v", all)
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	valid := []string{"HEAD", "MERGE_HEAD", "refs/heads/main", "refs/heads/feature/x", "refs/tags/v1.0"}
+	for _, name := range valid {
+		if err := refs.ValidateName(name); err != nil {
+			t.Errorf("expected %q to be valid, got %v", name, err)
+		}
+	}
+
+	invalid := []string{"main", "merge_HEAD", "HEADS", "refs/heads/", "refs/heads/a..b", "refs/heads/x.lock", "refs/heads/has space", "refs/heads/.hidden"}
+	for _, name := range invalid {
+		if err := refs.ValidateName(name); err == nil {
+			t.Errorf("expected %q to be invalid", name)
+		}
+	}
+}
+
+func TestResolveShort(t *testing.T) {
+	r, first, second := setupRepo(t)
+
+	if err := refs.Update(r, "refs/heads/v1", first, ""); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if err := refs.Update(r, "refs/tags/v1", second, ""); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+
+	// Tags take precedence over branches, like git
+	hash, err := refs.ResolveShort(r, "v1")
+	if err != nil {
+		t.Fatalf("ResolveShort returned error: %v", err)
+	}
+	if hash != second {
+		t.Fatalf("expected v1 to resolve to the tag %s, got %s", second, hash)
+	}
+
+	hash, err = refs.ResolveShort(r, "heads/v1")
+	if err != nil {
+		t.Fatalf("ResolveShort returned error: %v", err)
+	}
+	if hash != first {
+		t.Fatalf("expected heads/v1 to resolve to %s, got %s", first, hash)
+	}
+
+	if _, err := refs.ResolveShort(r, "missing"); !errors.Is(err, refs.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestPackedRefs(t *testing.T) {
+	r, first, second := setupRepo(t)
+
+	packed := "# pack-refs with: peeled fully-peeled sorted \n" +
+		first + " refs/heads/main\n" +
+		first + " refs/heads/old\n" +
+		second + " refs/tags/v1\n" +
+		"^" + first + "\n"
+	if err := os.WriteFile(filepath.Join(r.Path, refs.PackedRefsFile), []byte(packed), repo.FilePerm); err != nil {
+		t.Fatalf("failed to write packed-refs: %v", err)
+	}
+
+	hash, err := refs.Resolve(r, refs.Head)
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if hash != first {
+		t.Fatalf("expected HEAD to resolve through packed-refs to %s, got %s", first, hash)
+	}
+
+	// A loose ref shadows the packed one
+	if err := refs.Update(r, "refs/heads/main", second, first); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	list, err := refs.List(r, "")
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	expected := []refs.Ref{
+		{Name: "refs/heads/main", Hash: second},
+		{Name: "refs/heads/old", Hash: first},
+		{Name: "refs/tags/v1", Hash: second},
+	}
+	if len(list) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, list)
+	}
+	for i := range expected {
+		if list[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, list)
+		}
+	}
+
+	// Deleting removes both the loose file and the packed entry
+	if err := refs.Delete(r, "refs/heads/main", ""); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if err := refs.Delete(r, "refs/tags/v1", ""); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if _, err := refs.Resolve(r, "refs/heads/main"); !errors.Is(err, refs.ErrNotFound) {
+		t.Fatalf("expected deleted ref to be gone, got %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(r.Path, refs.PackedRefsFile))
+	if err != nil {
+		t.Fatalf("failed to read packed-refs: %v", err)
+	}
+	want := "# pack-refs with: peeled fully-peeled sorted \n" + first + " refs/heads/old\n"
+	if string(data) != want {
+		t.Fatalf("unexpected packed-refs:\n got: %q\nwant: %q", data, want)
+	}
+}
+
+func TestDelete_PackedRefsLocked(t *testing.T) {
+	r, first, _ := setupRepo(t)
+
+	path := filepath.Join(r.Path, refs.PackedRefsFile)
+	packed := first + " refs/heads/old\n"
+	if err := os.WriteFile(path, []byte(packed), repo.FilePerm); err != nil {
+		t.Fatalf("failed to write packed-refs: %v", err)
+	}
+	if err := os.WriteFile(path+".lock", nil, repo.FilePerm); err != nil {
+		t.Fatalf("failed to write packed-refs.lock: %v", err)
+	}
+
+	// Another writer holds the lock, so packed-refs must be left alone
+	if err := refs.Delete(r, "refs/heads/old", ""); err == nil {
+		t.Fatalf("expected Delete to fail while packed-refs is locked")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != packed {
+		t.Fatalf("expected packed-refs to be unchanged, got %q (%v)", data, err)
+	}
+	if _, err := os.Stat(path + ".lock"); err != nil {
+		t.Fatalf("expected the other writer's lock to be kept: %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/branch"
+	"github.com/MahendraDani/gitloom.git/internal/checkout"
+	"github.com/MahendraDani/gitloom.git/internal/config"
+	"github.com/MahendraDani/gitloom.git/internal/history"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// CloneOptions controls Clone.
+type CloneOptions struct {
+	// Bare creates a repository without a working tree whose branches
+	// are the remote's branches, suitable as a shared remote to push to.
+	Bare bool
+}
+
+// CloneResult describes what Clone did.
+type CloneResult struct {
+	Repo   *repo.Repo
+	Fetch  *FetchResult
+	Branch string // branch checked out, empty when HEAD is detached
+	Empty  bool   // the remote had no commits
+}
+
+// Clone creates a repository at path, which must not exist or be an empty
+// directory, with url configured as its origin remote, and fetches it.
+// The branch the remote's HEAD names is created to track the remote one
+// and checked out; a detached remote HEAD is checked out detached. If the
+// clone fails, whatever it created at path is removed again.
+func Clone(url, path string, opts CloneOptions) (res *CloneResult, err error) {
+	src, err := Open(url)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(url, fileScheme) {
+		// The clone may be used from anywhere, so record where src is
+		if url, err = filepath.Abs(url); err != nil {
+			return nil, err
+		}
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(abs)
+	existed := err == nil
+	if existed && len(entries) > 0 {
+		return nil, fmt.Errorf("destination path '%s' already exists and is not an empty directory", path)
+	} else if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			cleanup(abs, existed)
+		}
+	}()
+
+	r := repo.NewRepo(abs)
+	if opts.Bare {
+		err = repo.InitGitDir(abs, true)
+	} else {
+		err = r.Init()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := Add(r, Origin, url); err != nil {
+		return nil, err
+	}
+	if opts.Bare {
+		// A bare clone mirrors the remote's branches as its own
+		err := r.EditConfig(func(f *config.File) error {
+			return f.Set("remote."+Origin+".fetch", "+"+repo.HeadsDir+"/*:"+repo.HeadsDir+"/*")
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res = &CloneResult{Repo: r}
+	if res.Fetch, err = Fetch(r, Origin, FetchOptions{}); err != nil {
+		return nil, err
+	}
+	if err := cloneHead(r, src, res, opts.Bare); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// cloneHead points HEAD of the new repository r where HEAD of src points
+// and checks it out.
+func cloneHead(r, src *repo.Repo, res *CloneResult, bare bool) error {
+	hash, err := refs.Resolve(src, refs.Head)
+	if errors.Is(err, refs.ErrNotFound) {
+		res.Empty = true
+		hash = ""
+	} else if err != nil {
+		return err
+	}
+
+	target, err := refs.ReadSymbolic(src, refs.Head)
+	switch {
+	case errors.Is(err, refs.ErrNotSymbolic):
+		// A detached HEAD is cloned detached, with history no branch has
+		missing, err := missingObjects(src, r, []string{hash})
+		if err != nil {
+			return err
+		}
+		if err := copyObjects(src, r, missing); err != nil {
+			return err
+		}
+		if err := refs.UpdateNoDeref(r, refs.Head, hash, ""); err != nil {
+			return err
+		}
+		return checkoutHead(r, hash, bare)
+	case err != nil:
+		return err
+	case !strings.HasPrefix(target, repo.HeadsDir+"/"):
+		return fmt.Errorf("remote HEAD points outside of %s: %s", repo.HeadsDir, target)
+	}
+
+	res.Branch = strings.TrimPrefix(target, repo.HeadsDir+"/")
+	if err := refs.SetSymbolic(r, refs.Head, target); err != nil {
+		return err
+	}
+	if res.Empty || bare {
+		return nil
+	}
+
+	tracking := branch.RemotesDir + "/" + Origin + "/" + res.Branch
+	if err := refs.SetSymbolic(r, branch.RemotesDir+"/"+Origin+"/"+refs.Head, tracking); err != nil {
+		return err
+	}
+	if err := refs.UpdateNoDeref(r, target, hash, refs.ZeroHash); err != nil {
+		return err
+	}
+	if err := branch.SetUpstream(r, res.Branch, Origin+"/"+res.Branch); err != nil {
+		return err
+	}
+	return checkoutHead(r, hash, bare)
+}
+
+// checkoutHead fills the empty index and working tree of r from commit.
+func checkoutHead(r *repo.Repo, commit string, bare bool) error {
+	if bare {
+		return nil
+	}
+	c, err := history.ReadCommit(r, commit)
+	if err != nil {
+		return err
+	}
+	return checkout.Tree(r, "", c.Tree, false)
+}
+
+// cleanup removes what a failed clone created in dir, and dir itself
+// unless it existed before.
+func cleanup(dir string, existed bool) {
+	if !existed {
+		os.RemoveAll(dir)
+		return
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		os.RemoveAll(filepath.Join(dir, e.Name()))
+	}
+}
@@ -0,0 +1,218 @@
+package remote
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/branch"
+	"github.com/MahendraDani/gitloom.git/internal/history"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/revision"
+)
+
+// Status is the outcome of a ref update.
+type Status int
+
+const (
+	UpToDate    Status = iota // the ref already had the new value
+	Created                   // the ref did not exist
+	FastForward               // the new value descends from the old one
+	Forced                    // the old value was discarded
+	Deleted                   // the ref was removed
+	Rejected                  // the update was refused, see Reason
+)
+
+// RefUpdate is a ref that a fetch or push set, or refused to set.
+type RefUpdate struct {
+	Src string // full name of the ref on the sending side; empty when deleting
+	Dst string // full name of the ref on the receiving side
+	Old string // previous value of Dst, empty when it did not exist
+	New string // value sent, empty when deleting
+
+	Status Status
+	Reason string // why the update was rejected
+}
+
+// FetchOptions controls Fetch.
+type FetchOptions struct {
+	// Prune deletes remote-tracking refs whose branch is gone on the remote.
+	Prune bool
+}
+
+// FetchResult describes what Fetch did.
+type FetchResult struct {
+	URL     string
+	Objects int // objects copied
+	Updates []RefUpdate
+}
+
+// fetchRef is a remote ref and where a fetch stores it.
+type fetchRef struct {
+	remote refs.Ref
+	dst    string
+	force  bool
+}
+
+// Fetch copies the refs of remote name that its fetch refspecs select into
+// the local refs they map to, after copying the objects they reach that
+// are missing locally. Without a '+' on the refspec, a ref is only moved
+// when it fast-forwards. Tags pointing into history that is present
+// locally afterwards are fetched too, unless a local tag has their name.
+func Fetch(r *repo.Repo, name string, opts FetchOptions) (*FetchResult, error) {
+	rem, err := Get(r, name)
+	if err != nil {
+		return nil, err
+	}
+	src, err := Open(rem.URL)
+	if err != nil {
+		return nil, err
+	}
+	res := &FetchResult{URL: rem.URL}
+
+	remoteRefs, err := refs.List(src, "")
+	if err != nil {
+		return nil, err
+	}
+	var selected []fetchRef
+	var wants []string
+	for _, ref := range remoteRefs {
+		for _, spec := range rem.Fetch {
+			if dst, ok := branch.MapRefspec(spec, ref.Name); ok {
+				selected = append(selected, fetchRef{remote: ref, dst: dst, force: strings.HasPrefix(spec, "+")})
+				wants = append(wants, ref.Hash)
+				break
+			}
+		}
+	}
+
+	missing, err := missingObjects(src, r, wants)
+	if err != nil {
+		return nil, err
+	}
+	if err := copyObjects(src, r, missing); err != nil {
+		return nil, err
+	}
+	res.Objects = len(missing)
+
+	for _, f := range selected {
+		u, err := updateRef(r, f.remote.Name, f.dst, f.remote.Hash, f.force)
+		if err != nil {
+			return nil, err
+		}
+		res.Updates = append(res.Updates, u)
+	}
+
+	tags, copied, err := followTags(r, src, remoteRefs)
+	if err != nil {
+		return nil, err
+	}
+	res.Objects += copied
+	res.Updates = append(res.Updates, tags...)
+
+	if opts.Prune {
+		pruned, err := prune(r, rem, selected)
+		if err != nil {
+			return nil, err
+		}
+		res.Updates = append(res.Updates, pruned...)
+	}
+	return res, nil
+}
+
+// updateRef points the local ref dst at hash, which must be present.
+func updateRef(r *repo.Repo, src, dst, hash string, force bool) (RefUpdate, error) {
+	u := RefUpdate{Src: src, Dst: dst, New: hash, Status: Created}
+	old, err := refs.Resolve(r, dst)
+	switch {
+	case errors.Is(err, refs.ErrNotFound):
+		old = refs.ZeroHash
+	case err != nil:
+		return u, err
+	case old == hash:
+		u.Old, u.Status = old, UpToDate
+		return u, nil
+	default:
+		u.Old, u.Status = old, FastForward
+		ok, err := history.IsAncestor(r, old, hash)
+		if err != nil {
+			return u, err
+		}
+		if !ok {
+			if !force {
+				u.Status, u.Reason = Rejected, "non-fast-forward"
+				return u, nil
+			}
+			u.Status = Forced
+		}
+	}
+	return u, refs.UpdateNoDeref(r, dst, hash, old)
+}
+
+// followTags fetches the tags of src that point at objects present in r
+// and that r has no tag of the same name for. It returns the tags created
+// and the number of objects copied.
+func followTags(r, src *repo.Repo, remoteRefs []refs.Ref) ([]RefUpdate, int, error) {
+	var updates []RefUpdate
+	copied := 0
+	for _, ref := range remoteRefs {
+		if !strings.HasPrefix(ref.Name, repo.TagsDir+"/") {
+			continue
+		}
+		if _, err := refs.Resolve(r, ref.Name); err == nil {
+			continue
+		} else if !errors.Is(err, refs.ErrNotFound) {
+			return nil, 0, err
+		}
+		target, err := revision.Peel(src, ref.Hash, "")
+		if err != nil {
+			return nil, 0, err
+		}
+		if !r.HasObject(target) {
+			continue
+		}
+
+		missing, err := missingObjects(src, r, []string{ref.Hash})
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := copyObjects(src, r, missing); err != nil {
+			return nil, 0, err
+		}
+		copied += len(missing)
+		if err := refs.UpdateNoDeref(r, ref.Name, ref.Hash, refs.ZeroHash); err != nil {
+			return nil, 0, err
+		}
+		updates = append(updates, RefUpdate{Src: ref.Name, Dst: ref.Name, New: ref.Hash, Status: Created})
+	}
+	return updates, copied, nil
+}
+
+// prune deletes the remote-tracking refs of rem that no remote ref maps
+// to anymore.
+func prune(r *repo.Repo, rem *Remote, selected []fetchRef) ([]RefUpdate, error) {
+	kept := make(map[string]bool)
+	for _, f := range selected {
+		kept[f.dst] = true
+	}
+	tracking, err := refs.List(r, branch.RemotesDir+"/"+rem.Name+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []RefUpdate
+	for _, ref := range tracking {
+		if kept[ref.Name] {
+			continue
+		}
+		if _, err := refs.ReadSymbolic(r, ref.Name); err == nil {
+			// refs/remotes/<name>/HEAD names the remote's default branch
+			continue
+		}
+		if err := refs.DeleteNoDeref(r, ref.Name, ref.Hash); err != nil {
+			return nil, err
+		}
+		updates = append(updates, RefUpdate{Dst: ref.Name, Old: ref.Hash, Status: Deleted})
+	}
+	return updates, nil
+}
@@ -0,0 +1,297 @@
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/branch"
+	"github.com/MahendraDani/gitloom.git/internal/history"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/revision"
+)
+
+// PushOptions controls Push.
+type PushOptions struct {
+	// Force allows updates that are not fast-forwards, like a '+' on
+	// every refspec.
+	Force bool
+	// SetUpstream makes every pushed branch track the branch it was
+	// pushed to.
+	SetUpstream bool
+}
+
+// PushResult describes what Push did.
+type PushResult struct {
+	URL     string
+	Objects int // objects copied
+	Updates []RefUpdate
+}
+
+// Push updates refs of remote name from local ones. Each refspec is
+// "<src>:<dst>", "<src>" to push to the ref of the same name, or ":<dst>"
+// to delete dst; a leading '+' allows a non-fast-forward update. Without
+// refspecs the current branch is pushed to its upstream branch on the
+// remote, or to the branch of the same name.
+//
+// The objects the updated refs reach that the remote is missing are copied
+// first. Refs the remote has moved since they were read, branches checked
+// out in the remote's working tree, non-fast-forward updates and changes
+// to existing tags are rejected; everything else is applied. The
+// remote-tracking refs of the pushed branches are updated to match.
+func Push(r *repo.Repo, name string, refspecs []string, opts PushOptions) (*PushResult, error) {
+	rem, err := Get(r, name)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := Open(rem.URL)
+	if err != nil {
+		return nil, err
+	}
+	if len(refspecs) == 0 {
+		spec, err := defaultPushRefspec(r, name)
+		if err != nil {
+			return nil, err
+		}
+		refspecs = []string{spec}
+	}
+
+	res := &PushResult{URL: rem.URL}
+	var wants []string
+	for _, spec := range refspecs {
+		u, force, err := parsePushRefspec(r, spec)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkPush(r, dst, &u, force || opts.Force); err != nil {
+			return nil, err
+		}
+		if u.Status != Rejected && u.Status != UpToDate && u.New != "" {
+			wants = append(wants, u.New)
+		}
+		res.Updates = append(res.Updates, u)
+	}
+
+	missing, err := missingObjects(r, dst, wants)
+	if err != nil {
+		return nil, err
+	}
+	if err := copyObjects(r, dst, missing); err != nil {
+		return nil, err
+	}
+	res.Objects = len(missing)
+
+	for i := range res.Updates {
+		u := &res.Updates[i]
+		if u.Status == Rejected {
+			continue
+		}
+		if u.Status != UpToDate {
+			if err := applyPush(dst, u); err != nil {
+				u.Status, u.Reason = Rejected, err.Error()
+				continue
+			}
+		}
+		if err := updateTracking(r, rem, u); err != nil {
+			return nil, err
+		}
+		if opts.SetUpstream && u.New != "" && strings.HasPrefix(u.Src, repo.HeadsDir+"/") && strings.HasPrefix(u.Dst, repo.HeadsDir+"/") {
+			upstream := name + "/" + strings.TrimPrefix(u.Dst, repo.HeadsDir+"/")
+			if err := branch.SetUpstream(r, strings.TrimPrefix(u.Src, repo.HeadsDir+"/"), upstream); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, nil
+}
+
+// defaultPushRefspec pushes the current branch to its upstream on remote
+// name, or else to the remote branch of the same name.
+func defaultPushRefspec(r *repo.Repo, name string) (string, error) {
+	current, err := branch.Current(r)
+	if err != nil {
+		return "", err
+	}
+	if current == "" {
+		return "", errors.New("you are not currently on a branch; name the ref to push")
+	}
+	cfg, err := r.Config()
+	if err != nil {
+		return "", err
+	}
+	dst := branch.Ref(current)
+	if remote, _ := cfg.Get("branch." + current + ".remote"); remote == name {
+		if merge, ok := cfg.Get("branch." + current + ".merge"); ok {
+			dst = merge
+		}
+	}
+	return branch.Ref(current) + ":" + dst, nil
+}
+
+// parsePushRefspec resolves the local side of spec and returns the update
+// it asks for, without Old and Status, and whether it is forced.
+func parsePushRefspec(r *repo.Repo, spec string) (RefUpdate, bool, error) {
+	force := strings.HasPrefix(spec, "+")
+	src, dst, hasDst := strings.Cut(strings.TrimPrefix(spec, "+"), ":")
+
+	var u RefUpdate
+	if src == "" {
+		if !hasDst || dst == "" {
+			return u, false, fmt.Errorf("invalid refspec '%s'", spec)
+		}
+		u.Dst = fullName(dst, repo.HeadsDir)
+		return u, force, refs.ValidateName(u.Dst)
+	}
+
+	srcRef, hash, err := resolvePushSource(r, src)
+	if err != nil {
+		return u, false, err
+	}
+	u.Src, u.New = srcRef, hash
+	switch {
+	case !hasDst && srcRef == "":
+		return u, false, fmt.Errorf("'%s' is not a branch or tag; use '%s:<dst>' to push it", src, src)
+	case !hasDst:
+		u.Dst = srcRef
+	default:
+		dir := repo.HeadsDir
+		if strings.HasPrefix(srcRef, repo.TagsDir+"/") {
+			dir = repo.TagsDir
+		}
+		u.Dst = fullName(dst, dir)
+	}
+	return u, force, refs.ValidateName(u.Dst)
+}
+
+// resolvePushSource resolves the local side of a push refspec. A branch or
+// tag name yields its full ref name; other revisions only a hash.
+func resolvePushSource(r *repo.Repo, src string) (string, string, error) {
+	candidates := []string{src}
+	if src == refs.Head {
+		// HEAD stands for the current branch
+		current, detached, err := refs.CurrentBranch(r)
+		if err != nil {
+			return "", "", err
+		}
+		if !detached {
+			candidates = []string{current}
+		}
+	} else if !strings.HasPrefix(src, repo.RefsDir+"/") {
+		candidates = []string{branch.Ref(src), repo.TagsDir + "/" + src}
+	}
+	for _, name := range candidates {
+		hash, err := refs.Resolve(r, name)
+		if err == nil {
+			return name, hash, nil
+		}
+		if !errors.Is(err, refs.ErrNotFound) {
+			return "", "", err
+		}
+	}
+	hash, err := revision.Resolve(r, src)
+	if err != nil {
+		return "", "", fmt.Errorf("src refspec %s does not match any", src)
+	}
+	return "", hash, nil
+}
+
+// fullName qualifies a short ref name with dir, e.g. refs/heads.
+func fullName(name, dir string) string {
+	if strings.HasPrefix(name, repo.RefsDir+"/") {
+		return name
+	}
+	return dir + "/" + name
+}
+
+// checkPush fills in the current value of u.Dst on dst and decides whether
+// the update may go ahead.
+func checkPush(r, dst *repo.Repo, u *RefUpdate, force bool) error {
+	old, err := refs.Resolve(dst, u.Dst)
+	if err != nil && !errors.Is(err, refs.ErrNotFound) {
+		return err
+	}
+	u.Old = old
+
+	reject := func(reason string) error {
+		u.Status, u.Reason = Rejected, reason
+		return nil
+	}
+	switch {
+	case u.New == "" && old == "":
+		return reject("remote ref does not exist")
+	case u.New == old:
+		u.Status = UpToDate
+		return nil
+	}
+
+	if !dst.Bare() {
+		checkedOut, detached, err := refs.CurrentBranch(dst)
+		if err != nil {
+			return err
+		}
+		if !detached && checkedOut == u.Dst {
+			return reject("branch is currently checked out")
+		}
+	}
+
+	switch {
+	case u.New == "":
+		u.Status = Deleted
+	case old == "":
+		u.Status = Created
+	case strings.HasPrefix(u.Dst, repo.TagsDir+"/") && !force:
+		return reject("already exists")
+	case !r.HasObject(old):
+		if !force {
+			return reject("fetch first")
+		}
+		u.Status = Forced
+	default:
+		ok, err := history.IsAncestor(r, old, u.New)
+		if err != nil {
+			return err
+		}
+		u.Status = FastForward
+		if !ok {
+			if !force {
+				return reject("non-fast-forward")
+			}
+			u.Status = Forced
+		}
+	}
+	return nil
+}
+
+// applyPush makes the update on dst, provided the ref still holds the
+// value checkPush saw.
+func applyPush(dst *repo.Repo, u *RefUpdate) error {
+	if u.Status == Deleted {
+		return refs.DeleteNoDeref(dst, u.Dst, u.Old)
+	}
+	old := u.Old
+	if old == "" {
+		old = refs.ZeroHash
+	}
+	return refs.UpdateNoDeref(dst, u.Dst, u.New, old)
+}
+
+// updateTracking records a pushed ref in the remote-tracking ref it is
+// fetched into.
+func updateTracking(r *repo.Repo, rem *Remote, u *RefUpdate) error {
+	for _, spec := range rem.Fetch {
+		tracking, ok := branch.MapRefspec(spec, u.Dst)
+		if !ok {
+			continue
+		}
+		if u.New == "" {
+			err := refs.DeleteNoDeref(r, tracking, "")
+			if errors.Is(err, refs.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+		return refs.UpdateNoDeref(r, tracking, u.New, "")
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+// Package remote shares history with other repositories on the same
+// machine or a shared file system. Remotes are configured by name in
+// remote.<name>.url and remote.<name>.fetch; fetch copies the objects a
+// remote has that are missing locally and records its branches as
+// remote-tracking refs under refs/remotes/<name>/, push does the opposite
+// for local branches, and clone creates a repository from a remote.
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/branch"
+	"github.com/MahendraDani/gitloom.git/internal/config"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+const (
+	// Origin is the remote clone creates.
+	Origin = "origin"
+
+	fileScheme = "file://"
+)
+
+// Remote is a configured remote repository.
+type Remote struct {
+	Name string
+	URL  string
+	// Fetch holds the refspecs mapping the remote's refs to local ones,
+	// e.g. "+refs/heads/*:refs/remotes/origin/*".
+	Fetch []string
+}
+
+// DefaultFetch returns the refspec a new remote fetches with.
+func DefaultFetch(name string) string {
+	return fmt.Sprintf("+%s/*:%s/%s/*", repo.HeadsDir, branch.RemotesDir, name)
+}
+
+// ValidateName checks that name can be used for a new remote.
+func ValidateName(name string) error {
+	if name == "" || strings.HasPrefix(name, "-") || strings.Contains(name, "/") {
+		return fmt.Errorf("'%s' is not a valid remote name", name)
+	}
+	if err := refs.ValidateName(branch.RemotesDir + "/" + name); err != nil {
+		return fmt.Errorf("'%s' is not a valid remote name", name)
+	}
+	return nil
+}
+
+// Get returns the remote called name.
+func Get(r *repo.Repo, name string) (*Remote, error) {
+	cfg, err := r.Config()
+	if err != nil {
+		return nil, err
+	}
+	url, ok := cfg.Get("remote." + name + ".url")
+	if !ok {
+		return nil, fmt.Errorf("no such remote '%s'", name)
+	}
+	rem := &Remote{Name: name, URL: url, Fetch: cfg.GetAll("remote." + name + ".fetch")}
+	if len(rem.Fetch) == 0 {
+		rem.Fetch = []string{DefaultFetch(name)}
+	}
+	return rem, nil
+}
+
+// List returns the configured remotes in the order they were added.
+func List(r *repo.Repo) ([]*Remote, error) {
+	cfg, err := r.Config()
+	if err != nil {
+		return nil, err
+	}
+	var remotes []*Remote
+	seen := make(map[string]bool)
+	for _, e := range cfg.Entries() {
+		name, ok := strings.CutPrefix(e.Key, "remote.")
+		if !ok {
+			continue
+		}
+		if name, ok = strings.CutSuffix(name, ".url"); !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		rem, err := Get(r, name)
+		if err != nil {
+			return nil, err
+		}
+		remotes = append(remotes, rem)
+	}
+	return remotes, nil
+}
+
+// Add configures a remote called name at url, fetching every branch into
+// refs/remotes/<name>/.
+func Add(r *repo.Repo, name, url string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if _, err := Get(r, name); err == nil {
+		return fmt.Errorf("remote %s already exists", name)
+	}
+	return r.EditConfig(func(f *config.File) error {
+		if err := f.Set("remote."+name+".url", url); err != nil {
+			return err
+		}
+		return f.Set("remote."+name+".fetch", DefaultFetch(name))
+	})
+}
+
+// Remove deletes remote name, its remote-tracking refs and the tracking
+// configuration of branches that follow it.
+func Remove(r *repo.Repo, name string) error {
+	if _, err := Get(r, name); err != nil {
+		return err
+	}
+	tracking, err := refs.List(r, branch.RemotesDir+"/"+name+"/")
+	if err != nil {
+		return err
+	}
+	for _, ref := range tracking {
+		if err := refs.DeleteNoDeref(r, ref.Name, ""); err != nil && !errors.Is(err, refs.ErrNotFound) {
+			return err
+		}
+	}
+
+	cfg, err := r.Config()
+	if err != nil {
+		return err
+	}
+	return r.EditConfig(func(f *config.File) error {
+		for _, e := range cfg.Entries() {
+			b, ok := strings.CutPrefix(e.Key, "branch.")
+			if !ok || e.Value != name {
+				continue
+			}
+			if b, ok = strings.CutSuffix(b, ".remote"); !ok {
+				continue
+			}
+			if _, err := f.Unset("branch." + b + ".remote"); err != nil {
+				return err
+			}
+			if _, err := f.Unset("branch." + b + ".merge"); err != nil {
+				return err
+			}
+		}
+		_, err := f.RemoveSection("remote." + name)
+		return err
+	})
+}
+
+// Open opens the repository url names: a path or file:// URL of a working
+// tree holding a .gitloom or .git directory, of such a directory itself, or
+// of a bare repository. Relative paths are taken from the current directory.
+func Open(url string) (*repo.Repo, error) {
+	path := strings.TrimPrefix(url, fileScheme)
+	if strings.Contains(path, "://") {
+		return nil, fmt.Errorf("unsupported remote '%s': only local paths and file:// URLs can be used", url)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range []string{repo.RepoDirName, repo.GitDirName} {
+		if fi, err := os.Stat(filepath.Join(abs, dir)); err == nil && fi.IsDir() {
+			abs = filepath.Join(abs, dir)
+			break
+		}
+	}
+
+	for _, name := range []string{repo.HeadFile, repo.ObjectsDir, repo.RefsDir} {
+		if _, err := os.Stat(filepath.Join(abs, name)); err != nil {
+			return nil, fmt.Errorf("'%s' does not appear to be a gitloom repository", url)
+		}
+	}
+	return repo.NewRepo(abs), nil
+}
@@ -0,0 +1,336 @@
+package remote_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MahendraDani/gitloom.git/internal/branch"
+	"github.com/MahendraDani/gitloom.git/internal/checkout"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/remote"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/tag"
+	"github.com/MahendraDani/gitloom.git/internal/testrepo"
+)
+
+// commitFile writes name to the working tree of r and commits everything.
+func commitFile(t *testing.T, r *repo.Repo, name, content string) string {
+	t.Helper()
+	testrepo.WriteFile(t, r.WorkTree(), name, content)
+	return testrepo.CommitAll(t, r, "add "+name)
+}
+
+func resolve(t *testing.T, r *repo.Repo, name string) string {
+	t.Helper()
+	hash, err := refs.Resolve(r, name)
+	if err != nil {
+		t.Fatalf("resolving %s: %v", name, err)
+	}
+	return hash
+}
+
+// cloneBare clones src into a new bare repository and opens it.
+func cloneBare(t *testing.T, src string) *repo.Repo {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "shared.git")
+	if _, err := remote.Clone(src, path, remote.CloneOptions{Bare: true}); err != nil {
+		t.Fatalf("bare Clone failed: %v", err)
+	}
+	shared, err := remote.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return shared
+}
+
+func TestAddListRemove(t *testing.T) {
+	r := testrepo.New(t)
+	commitFile(t, r, "a.txt", "a\n")
+
+	if err := remote.Add(r, "origin", "/srv/project"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := remote.Add(r, "origin", "/elsewhere"); err == nil {
+		t.Fatal("expected adding origin twice to fail")
+	}
+	if err := remote.Add(r, "a/b", "/elsewhere"); err == nil {
+		t.Fatal("expected an invalid remote name to be refused")
+	}
+	if err := remote.Add(r, "backup", "file:///mnt/backup"); err != nil {
+		t.Fatal(err)
+	}
+
+	remotes, err := remote.List(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remotes) != 2 || remotes[0].Name != "origin" || remotes[0].URL != "/srv/project" || remotes[1].Name != "backup" {
+		t.Fatalf("unexpected remotes: %+v", remotes)
+	}
+	if remotes[0].Fetch[0] != "+refs/heads/*:refs/remotes/origin/*" {
+		t.Fatalf("unexpected fetch refspec %q", remotes[0].Fetch[0])
+	}
+
+	head := resolve(t, r, refs.Head)
+	tracking := branch.RemotesDir + "/origin/main"
+	if err := refs.UpdateNoDeref(r, tracking, head, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := refs.SetSymbolic(r, branch.RemotesDir+"/origin/HEAD", tracking); err != nil {
+		t.Fatal(err)
+	}
+	if err := branch.SetUpstream(r, "main", "origin/main"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := remote.Remove(r, "origin"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := remote.Get(r, "origin"); err == nil {
+		t.Fatal("expected origin to be gone")
+	}
+	if left, err := refs.List(r, branch.RemotesDir+"/"); err != nil || len(left) != 0 {
+		t.Fatalf("remote-tracking refs left behind: %v, %v", left, err)
+	}
+	if upstream, err := branch.Upstream(r, "main"); err != nil || upstream != "" {
+		t.Fatalf("main still tracks %q, %v", upstream, err)
+	}
+	if err := remote.Remove(r, "origin"); err == nil {
+		t.Fatal("expected removing a missing remote to fail")
+	}
+}
+
+func TestCloneAndFetch(t *testing.T) {
+	src := testrepo.New(t)
+	srcDir := src.WorkTree()
+	first := commitFile(t, src, "a.txt", "a\n")
+	if _, err := tag.Create(src, "v1.0", "HEAD", "first release", false); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "clone")
+	res, err := remote.Clone(srcDir, dst, remote.CloneOptions{})
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	r := res.Repo
+	if res.Branch != "main" || resolve(t, r, refs.Head) != first {
+		t.Fatalf("unexpected clone: %+v", res)
+	}
+	if resolve(t, r, "refs/remotes/origin/main") != first || resolve(t, r, "refs/remotes/origin/HEAD") != first {
+		t.Fatal("remote-tracking refs were not set up")
+	}
+	if resolve(t, r, tag.Ref("v1.0")) != resolve(t, src, tag.Ref("v1.0")) {
+		t.Fatal("the tag was not fetched")
+	}
+	if upstream, err := branch.Upstream(r, "main"); err != nil || upstream != "refs/remotes/origin/main" {
+		t.Fatalf("main tracks %q, %v", upstream, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "a.txt")); err != nil || string(data) != "a\n" {
+		t.Fatalf("a.txt = %q, %v", data, err)
+	}
+
+	// Only the objects of the new commit are copied: commit, tree and blob
+	second := commitFile(t, src, "b.txt", "b\n")
+	fetched, err := remote.Fetch(r, remote.Origin, remote.FetchOptions{})
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if fetched.Objects != 3 {
+		t.Fatalf("fetched %d objects, want 3", fetched.Objects)
+	}
+	if len(fetched.Updates) != 1 || fetched.Updates[0].Status != remote.FastForward || fetched.Updates[0].New != second {
+		t.Fatalf("unexpected updates: %+v", fetched.Updates)
+	}
+
+	if fetched, err = remote.Fetch(r, remote.Origin, remote.FetchOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if fetched.Objects != 0 || fetched.Updates[0].Status != remote.UpToDate {
+		t.Fatalf("expected nothing new, got %+v", fetched)
+	}
+}
+
+func TestFetch_ForcedUpdateAndPrune(t *testing.T) {
+	src := testrepo.New(t)
+	srcDir := src.WorkTree()
+	base := commitFile(t, src, "a.txt", "a\n")
+	if err := branch.Create(src, "topic", base, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := checkout.Switch(src, "topic", checkout.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, src, "b.txt", "b\n")
+
+	res, err := remote.Clone(srcDir, filepath.Join(t.TempDir(), "clone"), remote.CloneOptions{})
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	r := res.Repo
+
+	// Rewrite topic on the remote, then delete main
+	if err := branch.Create(src, "other", base, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := checkout.Switch(src, "other", checkout.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	rewritten := commitFile(t, src, "c.txt", "c\n")
+	if err := branch.Create(src, "topic", rewritten, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := branch.Delete(src, "main", true); err != nil {
+		t.Fatal(err)
+	}
+
+	fetched, err := remote.Fetch(r, remote.Origin, remote.FetchOptions{Prune: true})
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	statuses := make(map[string]remote.Status)
+	for _, u := range fetched.Updates {
+		statuses[u.Dst] = u.Status
+	}
+	if statuses["refs/remotes/origin/topic"] != remote.Forced || statuses["refs/remotes/origin/other"] != remote.Created ||
+		statuses["refs/remotes/origin/main"] != remote.Deleted {
+		t.Fatalf("unexpected updates: %+v", fetched.Updates)
+	}
+	if resolve(t, r, "refs/remotes/origin/topic") != rewritten {
+		t.Fatal("origin/topic was not updated")
+	}
+	if _, err := refs.Resolve(r, "refs/remotes/origin/main"); !errors.Is(err, refs.ErrNotFound) {
+		t.Fatalf("expected origin/main to be pruned, got %v", err)
+	}
+}
+
+func TestPush(t *testing.T) {
+	src := testrepo.New(t)
+	srcDir := src.WorkTree()
+	base := commitFile(t, src, "a.txt", "a\n")
+	shared := cloneBare(t, srcDir)
+
+	res, err := remote.Clone(shared.Path, filepath.Join(t.TempDir(), "clone"), remote.CloneOptions{})
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	r := res.Repo
+	next := commitFile(t, r, "b.txt", "b\n")
+
+	pushed, err := remote.Push(r, remote.Origin, nil, remote.PushOptions{})
+	if err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if pushed.Objects != 3 || len(pushed.Updates) != 1 || pushed.Updates[0].Status != remote.FastForward {
+		t.Fatalf("unexpected push: %+v", pushed)
+	}
+	if resolve(t, shared, branch.Ref("main")) != next || resolve(t, r, "refs/remotes/origin/main") != next {
+		t.Fatal("main was not updated on the remote and in origin/main")
+	}
+
+	// A new branch, with -u
+	if err := branch.Create(r, "topic", base, false); err != nil {
+		t.Fatal(err)
+	}
+	pushed, err = remote.Push(r, remote.Origin, []string{"topic"}, remote.PushOptions{SetUpstream: true})
+	if err != nil || pushed.Updates[0].Status != remote.Created {
+		t.Fatalf("pushing topic: %+v, %v", pushed, err)
+	}
+	if upstream, err := branch.Upstream(r, "topic"); err != nil || upstream != "refs/remotes/origin/topic" {
+		t.Fatalf("topic tracks %q, %v", upstream, err)
+	}
+
+	// Moving main back is not a fast-forward
+	pushed, err = remote.Push(r, remote.Origin, []string{"topic:main"}, remote.PushOptions{})
+	if err != nil || pushed.Updates[0].Status != remote.Rejected || pushed.Updates[0].Reason != "non-fast-forward" {
+		t.Fatalf("expected a non-fast-forward rejection, got %+v, %v", pushed, err)
+	}
+	if resolve(t, shared, branch.Ref("main")) != next {
+		t.Fatal("a rejected push moved main")
+	}
+	pushed, err = remote.Push(r, remote.Origin, []string{"+topic:main"}, remote.PushOptions{})
+	if err != nil || pushed.Updates[0].Status != remote.Forced || resolve(t, shared, branch.Ref("main")) != base {
+		t.Fatalf("forced push: %+v, %v", pushed, err)
+	}
+
+	// Tags are not moved once they exist
+	if _, err := tag.Create(r, "v1.0", base, "", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := remote.Push(r, remote.Origin, []string{"v1.0"}, remote.PushOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tag.Create(r, "v1.0", next, "", true); err != nil {
+		t.Fatal(err)
+	}
+	pushed, err = remote.Push(r, remote.Origin, []string{"v1.0"}, remote.PushOptions{})
+	if err != nil || pushed.Updates[0].Status != remote.Rejected {
+		t.Fatalf("expected moving a tag to be rejected, got %+v, %v", pushed, err)
+	}
+
+	// Deleting a branch removes its remote-tracking ref too
+	pushed, err = remote.Push(r, remote.Origin, []string{":topic"}, remote.PushOptions{})
+	if err != nil || pushed.Updates[0].Status != remote.Deleted {
+		t.Fatalf("deleting topic: %+v, %v", pushed, err)
+	}
+	for _, p := range []struct {
+		r    *repo.Repo
+		name string
+	}{{shared, branch.Ref("topic")}, {r, "refs/remotes/origin/topic"}} {
+		if _, err := refs.Resolve(p.r, p.name); !errors.Is(err, refs.ErrNotFound) {
+			t.Fatalf("expected %s to be deleted, got %v", p.name, err)
+		}
+	}
+}
+
+func TestPush_RefusesCheckedOutBranch(t *testing.T) {
+	src := testrepo.New(t)
+	srcDir := src.WorkTree()
+	first := commitFile(t, src, "a.txt", "a\n")
+
+	res, err := remote.Clone(srcDir, filepath.Join(t.TempDir(), "clone"), remote.CloneOptions{})
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	commitFile(t, res.Repo, "b.txt", "b\n")
+
+	pushed, err := remote.Push(res.Repo, remote.Origin, nil, remote.PushOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pushed.Updates[0].Status != remote.Rejected || pushed.Updates[0].Reason != "branch is currently checked out" {
+		t.Fatalf("unexpected update: %+v", pushed.Updates[0])
+	}
+	if resolve(t, src, refs.Head) != first {
+		t.Fatal("the checked out branch of the remote was moved")
+	}
+}
+
+func TestClone_Refusals(t *testing.T) {
+	srcDir := testrepo.New(t).WorkTree()
+
+	dst := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dst, "keep"), nil, repo.FilePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := remote.Clone(srcDir, dst, remote.CloneOptions{}); err == nil {
+		t.Fatal("expected cloning into a non-empty directory to fail")
+	}
+	missing := filepath.Join(t.TempDir(), "missing")
+	if _, err := remote.Clone(filepath.Join(srcDir, "nope"), missing, remote.CloneOptions{}); err == nil {
+		t.Fatal("expected cloning a missing repository to fail")
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Fatalf("a failed clone left %s behind", missing)
+	}
+	if _, err := remote.Open("ssh://host/repo"); err == nil {
+		t.Fatal("expected ssh URLs to be refused")
+	}
+
+	res, err := remote.Clone("file://"+srcDir, filepath.Join(t.TempDir(), "empty"), remote.CloneOptions{})
+	if err != nil || !res.Empty {
+		t.Fatalf("cloning an empty repository: %+v, %v", res, err)
+	}
+}
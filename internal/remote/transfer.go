@@ -0,0 +1,79 @@
+package remote
+
+import (
+	"fmt"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// missingObjects walks the objects reachable from wants in src and returns
+// those dst does not have, every object after the objects it refers to. A
+// commit, tree or tag dst already has is taken to come with everything it
+// reaches, since refs are only written once all their objects are present,
+// so the walk goes no further than the history the two have in common.
+func missingObjects(src, dst *repo.Repo, wants []string) ([]string, error) {
+	type frame struct {
+		hash string
+		done bool // the objects it refers to have been visited
+	}
+
+	var missing []string
+	seen := make(map[string]bool)
+	stack := make([]frame, 0, len(wants))
+	for _, hash := range wants {
+		stack = append(stack, frame{hash: hash})
+	}
+
+	for len(stack) > 0 {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if f.done {
+			missing = append(missing, f.hash)
+			continue
+		}
+		if seen[f.hash] || dst.HasObject(f.hash) {
+			continue
+		}
+		seen[f.hash] = true
+
+		obj, err := src.ReadObject(f.hash)
+		if err != nil {
+			return nil, fmt.Errorf("object %s: %w", f.hash, err)
+		}
+		stack = append(stack, frame{hash: f.hash, done: true})
+
+		switch o := obj.(type) {
+		case *internal.Tag:
+			stack = append(stack, frame{hash: o.Object})
+		case *internal.Commit:
+			stack = append(stack, frame{hash: o.Tree})
+			for _, p := range o.Parents {
+				stack = append(stack, frame{hash: p})
+			}
+		case *internal.Tree:
+			for _, e := range o.Entries {
+				switch {
+				case e.Mode == internal.ModeGitlink:
+				case e.Mode == internal.ModeTree:
+					stack = append(stack, frame{hash: e.Hash})
+				case !seen[e.Hash] && !dst.HasObject(e.Hash):
+					// Blobs refer to nothing, so they are not read
+					seen[e.Hash] = true
+					missing = append(missing, e.Hash)
+				}
+			}
+		}
+	}
+	return missing, nil
+}
+
+// copyObjects writes the objects hashes from src into dst, in order.
+func copyObjects(src, dst *repo.Repo, hashes []string) error {
+	for _, hash := range hashes {
+		if err := repo.CopyObject(src, dst, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package repo
+
+import (
+	"compress/zlib"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal/config"
+)
+
+// Config returns the configuration of r: the user-wide file, then
+// .gitloom/config, then values given with -c, each taking precedence over
+// the ones before. It is read once; call ReloadConfig after changing it.
+func (r *Repo) Config() (*config.Config, error) {
+	if r.config != nil {
+		return r.config, nil
+	}
+
+	global, err := config.LoadGlobal()
+	if err != nil {
+		return nil, err
+	}
+	local, err := config.Load(r.ConfigPath())
+	if err != nil {
+		return nil, err
+	}
+	r.config = config.New(global, local, config.CommandLine())
+	return r.config, nil
+}
+
+// ReloadConfig forgets the cached configuration.
+func (r *Repo) ReloadConfig() {
+	r.config = nil
+}
+
+// ConfigPath returns the location of the repository configuration file.
+func (r *Repo) ConfigPath() string {
+	return filepath.Join(r.Path, ConfigFile)
+}
+
+// EditConfig applies change to the repository configuration file and
+// saves it.
+func (r *Repo) EditConfig(change func(f *config.File) error) error {
+	f, err := config.Load(r.ConfigPath())
+	if err != nil {
+		return err
+	}
+	before := string(f.Bytes())
+	if err := change(f); err != nil {
+		return err
+	}
+	if string(f.Bytes()) == before {
+		return nil
+	}
+	if err := f.Save(); err != nil {
+		return err
+	}
+	r.ReloadConfig()
+	return nil
+}
+
+// UserName returns the name to record in commits and tags, from user.name.
+// It is empty when not configured.
+func (r *Repo) UserName() (string, error) {
+	cfg, err := r.Config()
+	if err != nil {
+		return "", err
+	}
+	return cfg.String("user.name", ""), nil
+}
+
+// UserEmail returns the email address to record in commits and tags, from
+// user.email. It is empty when not configured.
+func (r *Repo) UserEmail() (string, error) {
+	cfg, err := r.Config()
+	if err != nil {
+		return "", err
+	}
+	return cfg.String("user.email", ""), nil
+}
+
+// LooseCompression returns the zlib level for loose objects, from
+// core.looseCompression or else core.compression.
+func (r *Repo) LooseCompression() (int, error) {
+	return r.compression("core.looseCompression")
+}
+
+// PackCompression returns the zlib level for pack entries, from
+// pack.compression or else core.compression.
+func (r *Repo) PackCompression() (int, error) {
+	return r.compression("pack.compression")
+}
+
+func (r *Repo) compression(key string) (int, error) {
+	cfg, err := r.Config()
+	if err != nil {
+		return 0, err
+	}
+	if _, ok := cfg.Get(key); !ok {
+		key = "core.compression"
+	}
+	level, err := cfg.Int(key, zlib.DefaultCompression)
+	if err != nil {
+		return 0, err
+	}
+	if level < zlib.DefaultCompression || level > zlib.BestCompression {
+		return 0, fmt.Errorf("bad zlib compression level %d for '%s'", level, key)
+	}
+	return int(level), nil
+}
+
+// DefaultBranch returns the branch a new repository starts on, from
+// init.defaultBranch in the user-wide file or on the command line.
+func DefaultBranch() (string, error) {
+	global, err := config.LoadGlobal()
+	if err != nil {
+		return "", err
+	}
+	branch := config.New(global, config.CommandLine()).String("init.defaultBranch", MainBranch)
+	if branch == "" || strings.ContainsAny(branch, " ~^:?*[\\") || strings.Contains(branch, "..") ||
+		strings.HasPrefix(branch, "-") || strings.HasPrefix(branch, "/") || strings.HasSuffix(branch, "/") {
+		return "", fmt.Errorf("invalid branch name in init.defaultBranch: %q", branch)
+	}
+	return branch, nil
+}
@@ -0,0 +1,424 @@
+package repo
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+)
+
+// maxHeaderLength bounds the "<type> <size>\x00" header read from an object.
+const maxHeaderLength = 64
+
+// ErrObjectNotFound is returned when an object is not stored in the repository.
+var ErrObjectNotFound = errors.New("object not found")
+
+// WriteObject stores a typed object in the object database and returns its hash.
+// Trees are validated first, so an entry with a malformed hash or name is
+// never stored.
+func (r *Repo) WriteObject(object internal.Object) (string, error) {
+	if t, ok := object.(*internal.Tree); ok {
+		if err := t.Validate(); err != nil {
+			return "", err
+		}
+	}
+	return r.WriteRawObject(object.Type(), object.Serialize())
+}
+
+// ReadObject loads the object with the given hash and parses it into its concrete type.
+func (r *Repo) ReadObject(hash string) (internal.Object, error) {
+	objType, data, err := r.ReadRawObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	return internal.ParseObject(objType, data)
+}
+
+// WriteRawObject stores an already serialized object body under
+// .gitloom/objects/xx/yyyy... and returns its hash. Existing objects are not rewritten.
+func (r *Repo) WriteRawObject(objType string, data []byte) (string, error) {
+	return r.WriteObjectStream(objType, int64(len(data)), bytes.NewReader(data))
+}
+
+// WriteObjectStream stores an object whose body of exactly size bytes is
+// read from body. The content is hashed and compressed in a single pass
+// into a temporary file, so objects larger than memory can be stored.
+//
+// The temporary file is fsynced and made read-only before it is renamed
+// to its final path, so a crash or a full disk can never leave a truncated
+// object behind a valid hash.
+func (r *Repo) WriteObjectStream(objType string, size int64, body io.Reader) (string, error) {
+	return r.writeLoose(objType, size, body, r.HasObject)
+}
+
+// CopyObject streams the object hash from src into dst, checking that its
+// contents still hash to hash on the way.
+func CopyObject(src, dst *Repo, hash string) error {
+	obj, err := src.OpenObject(hash)
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+
+	written, err := dst.WriteObjectStream(obj.Type, obj.Size, obj)
+	if err != nil {
+		return err
+	}
+	if written != hash {
+		return fmt.Errorf("object %s is corrupt: contents hash to %s", hash, written)
+	}
+	return nil
+}
+
+// WriteLooseObject stores an object as a loose object even when it is
+// already packed, as needed before deleting the pack that holds it.
+func (r *Repo) WriteLooseObject(objType string, data []byte) (string, error) {
+	return r.writeLoose(objType, int64(len(data)), bytes.NewReader(data), func(hash string) bool {
+		_, err := os.Stat(r.LooseObjectPath(hash))
+		return err == nil
+	})
+}
+
+// writeLoose streams an object into place unless exists reports that it
+// is already stored.
+func (r *Repo) writeLoose(objType string, size int64, body io.Reader, exists func(string) bool) (string, error) {
+	level, err := r.LooseCompression()
+	if err != nil {
+		return "", err
+	}
+
+	objectsDir := filepath.Join(r.Path, ObjectsDir)
+	if err := os.MkdirAll(objectsDir, DirPerm); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(objectsDir, "tmp_obj_")
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	h := sha1.New()
+	zw, err := zlib.NewWriterLevel(tmp, level)
+	if err != nil {
+		tmp.Close()
+		return "", err
+	}
+	w := io.MultiWriter(h, zw)
+
+	if _, err := w.Write(internal.Header(objType, int(size))); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	n, err := io.Copy(w, body)
+	if err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if n != size {
+		tmp.Close()
+		return "", fmt.Errorf("object body is %d bytes, expected %d", n, size)
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Chmod(ObjectPerm); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	hash := hex.EncodeToString(h.Sum(nil))
+	objPath := r.LooseObjectPath(hash)
+
+	// Avoid rewriting existing objects
+	if exists(hash) {
+		return hash, nil
+	}
+
+	objDir := filepath.Dir(objPath)
+	if err := os.MkdirAll(objDir, DirPerm); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpPath, objPath); err != nil {
+		return "", err
+	}
+
+	// Persist the new directory entry as well as the file contents
+	if err := syncDir(objDir); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// ReadRawObject returns the type and body of the object with the given hash.
+func (r *Repo) ReadRawObject(hash string) (string, []byte, error) {
+	obj, err := r.OpenObject(hash)
+	if err != nil {
+		return "", nil, err
+	}
+	defer obj.Close()
+
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		return "", nil, err
+	}
+	return obj.Type, data, nil
+}
+
+// ObjectInfo returns the type and body size of an object by reading only its header.
+func (r *Repo) ObjectInfo(hash string) (string, int64, error) {
+	obj, err := r.openLoose(hash)
+	if errors.Is(err, ErrObjectNotFound) {
+		p, perr := r.findPacked(hash)
+		if perr != nil {
+			return "", 0, perr
+		}
+		if p != nil {
+			return p.Info(hash)
+		}
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	defer obj.Close()
+	return obj.Type, obj.Size, nil
+}
+
+// ObjectReader streams the body of a stored object. Type and Size come
+// from the object header; reading past Size or finding fewer bytes than
+// Size is reported as an error. Packed objects are inflated into memory
+// when opened, since their deltas have to be applied as a whole.
+type ObjectReader struct {
+	Type string
+	Size int64
+
+	hash   string
+	file   *os.File
+	zr     io.ReadCloser
+	br     *bufio.Reader
+	remain int64
+}
+
+// OpenObject opens the object with the given hash for streaming, looking
+// for a loose object first and then in the packs. The caller must Close it.
+func (r *Repo) OpenObject(hash string) (*ObjectReader, error) {
+	obj, err := r.openLoose(hash)
+	if !errors.Is(err, ErrObjectNotFound) {
+		return obj, err
+	}
+
+	p, perr := r.findPacked(hash)
+	if perr != nil {
+		return nil, perr
+	}
+	if p == nil {
+		return nil, err
+	}
+	objType, data, err := p.Read(hash)
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectReader{
+		Type:   objType,
+		Size:   int64(len(data)),
+		hash:   hash,
+		br:     bufio.NewReader(bytes.NewReader(data)),
+		remain: int64(len(data)),
+	}, nil
+}
+
+func (r *Repo) openLoose(hash string) (*ObjectReader, error) {
+	if !internal.IsHash(hash) {
+		return nil, fmt.Errorf("invalid object name %q", hash)
+	}
+
+	f, err := os.Open(r.LooseObjectPath(hash))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, hash)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open object file: %w", err)
+	}
+
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to create zlib reader: %w", err)
+	}
+
+	obj := &ObjectReader{hash: hash, file: f, zr: zr, br: bufio.NewReader(zr)}
+	if err := obj.readHeader(); err != nil {
+		obj.Close()
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (o *ObjectReader) readHeader() error {
+	header, err := o.br.ReadSlice(0)
+	if err != nil || len(header) > maxHeaderLength {
+		return errors.New("invalid object format (missing header)")
+	}
+
+	objType, sizeStr, found := strings.Cut(string(header[:len(header)-1]), " ")
+	if !found {
+		return errors.New("invalid object header format")
+	}
+
+	size, err := strconv.ParseInt(sizeStr, 10, 64)
+	if err != nil || size < 0 {
+		return fmt.Errorf("object %s has invalid size %q", o.hash, sizeStr)
+	}
+
+	o.Type, o.Size, o.remain = objType, size, size
+	return nil
+}
+
+func (o *ObjectReader) Read(p []byte) (int, error) {
+	if o.remain == 0 {
+		// The body must end exactly where the header said it would
+		_, err := o.br.ReadByte()
+		if err == nil {
+			return 0, fmt.Errorf("object %s is longer than its header size %d", o.hash, o.Size)
+		}
+		if err != io.EOF {
+			return 0, fmt.Errorf("failed while reading compressed data: %w", err)
+		}
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > o.remain {
+		p = p[:o.remain]
+	}
+	n, err := o.br.Read(p)
+	o.remain -= int64(n)
+	if err == io.EOF {
+		if o.remain > 0 {
+			return n, fmt.Errorf("object %s is shorter than its header size %d", o.hash, o.Size)
+		}
+		err = nil
+	}
+	if err != nil {
+		return n, fmt.Errorf("failed while reading compressed data: %w", err)
+	}
+	return n, nil
+}
+
+func (o *ObjectReader) Close() error {
+	if o.file == nil {
+		return nil
+	}
+	o.zr.Close()
+	return o.file.Close()
+}
+
+// HasObject reports whether the object with the given hash is stored in r.
+func (r *Repo) HasObject(hash string) bool {
+	if !internal.IsHash(hash) {
+		return false
+	}
+	if _, err := os.Stat(r.LooseObjectPath(hash)); err == nil {
+		return true
+	}
+	p, err := r.findPacked(hash)
+	return err == nil && p != nil
+}
+
+// FindObjects returns the sorted hashes of all stored objects, loose or
+// packed, starting with the hex prefix, which must be at least two
+// characters long.
+func (r *Repo) FindObjects(prefix string) ([]string, error) {
+	if len(prefix) < 2 {
+		return nil, errors.New("object name prefix too short")
+	}
+
+	entries, err := os.ReadDir(filepath.Join(r.Path, ObjectsDir, prefix[:2]))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var hashes []string
+	for _, entry := range entries {
+		hash := prefix[:2] + entry.Name()
+		if strings.HasPrefix(hash, prefix) && internal.IsHash(hash) {
+			seen[hash] = true
+			hashes = append(hashes, hash)
+		}
+	}
+
+	packed, err := r.findPackedPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, hash := range packed {
+		if !seen[hash] {
+			seen[hash] = true
+			hashes = append(hashes, hash)
+		}
+	}
+	sort.Strings(hashes)
+	return hashes, nil
+}
+
+// LooseObjectPath returns where the loose copy of hash is stored.
+func (r *Repo) LooseObjectPath(hash string) string {
+	return filepath.Join(r.Path, ObjectsDir, hash[:2], hash[2:])
+}
+
+// LooseObjects returns the sorted hashes of all loose objects.
+func (r *Repo) LooseObjects() ([]string, error) {
+	dirs, err := os.ReadDir(filepath.Join(r.Path, ObjectsDir))
+	if err != nil {
+		return nil, err
+	}
+
+	var hashes []string
+	for _, dir := range dirs {
+		if !dir.IsDir() || len(dir.Name()) != 2 {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(r.Path, ObjectsDir, dir.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if hash := dir.Name() + entry.Name(); internal.IsHash(hash) {
+				hashes = append(hashes, hash)
+			}
+		}
+	}
+	return hashes, nil
+}
+
+// RemoveLooseObject deletes the loose copy of hash, and its fanout
+// directory once that is empty.
+func (r *Repo) RemoveLooseObject(hash string) error {
+	path := r.LooseObjectPath(hash)
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	// Fails harmlessly while other objects share the directory
+	os.Remove(filepath.Dir(path))
+	return nil
+}
@@ -0,0 +1,202 @@
+package repo
+
+import (
+	"compress/zlib"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/MahendraDani/gitloom.git/internal/pack"
+)
+
+// PackDir holds packfiles and their indexes, relative to the repository.
+const PackDir = "objects/pack"
+
+// Packs returns the packs in the repository, opening them on first use.
+// Packs that cannot be opened, e.g. because their index is truncated, are
+// left out, as git does, so the objects stored elsewhere stay readable;
+// BadPacks says why.
+func (r *Repo) Packs() ([]*pack.Pack, error) {
+	if r.packsLoaded {
+		return r.packs, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(r.Path, PackDir, "pack-*.pack"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var packs []*pack.Pack
+	var bad []error
+	for _, path := range paths {
+		// A pack whose index is not in place yet is still being written
+		if _, err := os.Stat(pack.IndexPath(path)); os.IsNotExist(err) {
+			continue
+		}
+		p, err := pack.Open(path)
+		if err != nil {
+			bad = append(bad, err)
+			continue
+		}
+		packs = append(packs, p)
+	}
+
+	r.packs, r.badPacks, r.packsLoaded = packs, bad, true
+	return packs, nil
+}
+
+// BadPacks returns why each pack left out by Packs could not be opened.
+func (r *Repo) BadPacks() ([]error, error) {
+	if _, err := r.Packs(); err != nil {
+		return nil, err
+	}
+	return r.badPacks, nil
+}
+
+// ReloadPacks closes the open packs so that the next lookup sees packs
+// added or removed since they were opened.
+func (r *Repo) ReloadPacks() {
+	for _, p := range r.packs {
+		p.Close()
+	}
+	r.packs, r.badPacks, r.packsLoaded = nil, nil, false
+}
+
+// WritePack packs the given objects into a new pack in the repository
+// and returns its checksum. The loose copies are left in place. Unless
+// opts sets a compression level, pack.compression is used.
+func (r *Repo) WritePack(hashes []string, opts pack.Options) (string, error) {
+	if opts.Compression == 0 {
+		level, err := r.PackCompression()
+		if err != nil {
+			return "", err
+		}
+		if level == zlib.NoCompression {
+			level = pack.NoCompression
+		}
+		opts.Compression = level
+	}
+
+	dir := filepath.Join(r.Path, PackDir)
+	checksum, err := pack.Write(dir, r, hashes, opts)
+	if err != nil {
+		return "", err
+	}
+	if err := syncDir(dir); err != nil {
+		return "", err
+	}
+	r.ReloadPacks()
+	return checksum, nil
+}
+
+// AddPack copies the pack at packPath and its index into the repository
+// unless a pack with the same checksum is already there, and reports
+// whether it did. The index is moved into place last, so the pack only
+// becomes visible once both files are complete.
+func (r *Repo) AddPack(packPath string) (bool, error) {
+	p, err := pack.Open(packPath)
+	if err != nil {
+		return false, err
+	}
+	checksum := p.Checksum()
+	p.Close()
+
+	dir := filepath.Join(r.Path, PackDir)
+	base := filepath.Join(dir, "pack-"+checksum)
+	if _, err := os.Stat(base + ".idx"); err == nil {
+		return false, nil
+	}
+	if err := os.MkdirAll(dir, DirPerm); err != nil {
+		return false, err
+	}
+
+	if err := copyFile(packPath, base+".pack", "tmp_pack_"); err != nil {
+		return false, err
+	}
+	if err := copyFile(pack.IndexPath(packPath), base+".idx", "tmp_idx_"); err != nil {
+		return false, err
+	}
+	if err := syncDir(dir); err != nil {
+		return false, err
+	}
+	r.ReloadPacks()
+	return true, nil
+}
+
+// copyFile writes a read-only copy of src to dst through a temporary file
+// in the same directory.
+func copyFile(src, dst, tmpPrefix string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), tmpPrefix)
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Chmod(tmpName, ObjectPerm); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return os.Rename(tmpName, dst)
+}
+
+// findPacked returns the pack holding hash, or nil.
+func (r *Repo) findPacked(hash string) (*pack.Pack, error) {
+	packs, err := r.Packs()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range packs {
+		if p.Has(hash) {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+// findPackedPrefix returns the packed objects whose names start with prefix.
+func (r *Repo) findPackedPrefix(prefix string) ([]string, error) {
+	packs, err := r.Packs()
+	if err != nil {
+		return nil, err
+	}
+	var hashes []string
+	for _, p := range packs {
+		hashes = append(hashes, p.Index.FindPrefix(prefix)...)
+	}
+	return hashes, nil
+}
+
+// RemovePack deletes the pack with the given checksum. The index goes
+// first so that no index is ever left naming a missing pack.
+func (r *Repo) RemovePack(checksum string) error {
+	r.ReloadPacks()
+	base := filepath.Join(r.Path, PackDir, "pack-"+checksum)
+	if err := os.Remove(base + ".idx"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(base + ".pack"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
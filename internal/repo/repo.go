@@ -2,47 +2,116 @@ package repo
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/MahendraDani/gitloom.git/internal/config"
+	"github.com/MahendraDani/gitloom.git/internal/pack"
 )
 
 const (
 	RepoDirName = ".gitloom"
-	HeadFile    = "HEAD"
-	RefsDir     = "refs"
-	HeadsDir    = "refs/heads"
-	ObjectsDir  = "objects"
-	MainBranch  = "main"
-
-	DirPerm  = 0755
-	FilePerm = 0644
+	// GitDirName is used instead of RepoDirName by git-compatible repositories.
+	GitDirName = ".git"
+	ConfigFile = "config"
+	HeadFile   = "HEAD"
+	RefsDir    = "refs"
+	HeadsDir   = "refs/heads"
+	TagsDir    = "refs/tags"
+	ObjectsDir = "objects"
+	MainBranch = "main"
+
+	// CompatibleKey marks a .git directory that gitloom may use.
+	CompatibleKey = "gitloom.compatible"
+
+	DirPerm    = 0755
+	FilePerm   = 0644
+	ObjectPerm = 0444 // objects are immutable once written
 )
 
 type Repo struct {
 	Path string
+
+	packs       []*pack.Pack
+	badPacks    []error
+	packsLoaded bool
+	config      *config.Config
 }
 
 func NewRepo(path string) *Repo {
 	return &Repo{Path: path}
 }
 
+// WorkTree returns the directory containing the .gitloom directory.
+func (r *Repo) WorkTree() string {
+	return filepath.Dir(r.Path)
+}
+
+// Bare reports whether r is a bare repository, one that is not the
+// .gitloom or .git directory of a working tree.
+func (r *Repo) Bare() bool {
+	name := filepath.Base(r.Path)
+	return name != RepoDirName && name != GitDirName
+}
+
+// GitCompatible reports whether r is stored in a .git directory, where git
+// and gitloom can both operate on it.
+func (r *Repo) GitCompatible() bool {
+	return filepath.Base(r.Path) == GitDirName
+}
+
+// FindRepo looks for a repository in startPath and its parents. A .gitloom
+// directory takes precedence over a .git directory in the same place, and
+// a .git directory is only used if it was made git-compatible, so that
+// gitloom never rewrites an ordinary git checkout.
 func FindRepo(startPath string) (*Repo, error) {
 	path, err := filepath.Abs(startPath)
 	if err != nil {
 		return nil, err
 	}
+	gitOnly := ""
 	for {
 		repoPath := filepath.Join(path, RepoDirName)
 		if _, err := os.Stat(repoPath); err == nil {
 			return NewRepo(repoPath), nil
 		}
 
+		gitPath := filepath.Join(path, GitDirName)
+		if fi, err := os.Stat(gitPath); err == nil && fi.IsDir() {
+			if compatible(gitPath) {
+				return NewRepo(gitPath), nil
+			}
+			if gitOnly == "" {
+				gitOnly = path
+			}
+		}
+
 		parent := filepath.Dir(path)
 		if parent == path {
-			return nil, errors.New("gitloom repository not found")
+			break
 		}
 		path = parent
 	}
+	if gitOnly != "" {
+		return nil, fmt.Errorf("gitloom repository not found; %s is a git repository, run 'gitloom init --git-compatible' there to use it", gitOnly)
+	}
+	return nil, errors.New("gitloom repository not found")
+}
+
+// compatible reports whether the .git directory at path has been marked
+// with CompatibleKey.
+func compatible(path string) bool {
+	f, err := config.Load(filepath.Join(path, ConfigFile))
+	if err != nil {
+		return false
+	}
+	value, ok := f.Get(CompatibleKey)
+	if !ok {
+		return false
+	}
+	on, err := config.ParseBool(value)
+	return err == nil && on
 }
 
 func (r *Repo) Init() error {
@@ -60,6 +129,11 @@ func (r *Repo) Init() error {
 		return err
 	}
 
+	branch, err := DefaultBranch()
+	if err != nil {
+		return err
+	}
+
 	// create refs/head dir
 	if err := os.MkdirAll(filepath.Join(repoPath, HeadsDir), DirPerm); err != nil {
 		return err
@@ -70,8 +144,13 @@ func (r *Repo) Init() error {
 		return err
 	}
 
+	// create config file
+	if err := os.WriteFile(filepath.Join(repoPath, ConfigFile), []byte(initialConfig(false)), FilePerm); err != nil {
+		return err
+	}
+
 	// create HEAD file
-	headContent := []byte("ref: refs/heads/main\n")
+	headContent := []byte("ref: refs/heads/" + branch + "\n")
 	if err := os.WriteFile(filepath.Join(repoPath, HeadFile), headContent, FilePerm); err != nil {
 		return err
 	}
@@ -80,6 +159,72 @@ func (r *Repo) Init() error {
 	return nil
 }
 
-// TODO: implement them
-// func (r *Repo) WriteObject(object internal.Object) (string, error)
-// func (r *Repo) ReadObject(hash string) (internal.Object, error)
+// InitGitCompatible creates a .git directory in r.Path laid out the way git
+// expects, so git and gitloom can share the repository. An existing git
+// repository is reused; reused reports whether that happened. Either way
+// CompatibleKey is set, which is what lets FindRepo use the directory.
+func (r *Repo) InitGitCompatible() (reused bool, err error) {
+	path, err := filepath.Abs(r.Path)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := os.Stat(filepath.Join(path, RepoDirName)); err == nil {
+		return false, errors.New("repository already exists")
+	}
+
+	repoPath := filepath.Join(path, GitDirName)
+	if _, err := os.Stat(filepath.Join(repoPath, HeadFile)); err == nil {
+		reused = true
+	} else if err := InitGitDir(repoPath, false); err != nil {
+		return false, err
+	}
+
+	r.Path = repoPath
+	err = r.EditConfig(func(f *config.File) error {
+		return f.Set(CompatibleKey, "true")
+	})
+	return reused, err
+}
+
+// InitGitDir creates the directories and files git requires of a
+// repository at path. bare repositories have no working tree.
+func InitGitDir(path string, bare bool) error {
+	branch, err := DefaultBranch()
+	if err != nil {
+		return err
+	}
+
+	dirs := []string{
+		HeadsDir,
+		TagsDir,
+		ObjectsDir + "/info",
+		ObjectsDir + "/pack",
+		"info",
+	}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(filepath.Join(path, filepath.FromSlash(dir)), DirPerm); err != nil {
+			return err
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(path, ConfigFile), []byte(initialConfig(bare)), FilePerm); err != nil {
+		return err
+	}
+
+	headContent := []byte("ref: refs/heads/" + branch + "\n")
+	return os.WriteFile(filepath.Join(path, HeadFile), headContent, FilePerm)
+}
+
+// initialConfig returns the configuration file of a new repository, which
+// git also accepts.
+func initialConfig(bare bool) string {
+	config := fmt.Sprintf("[core]\n"+
+		"\trepositoryformatversion = 0\n"+
+		"\tfilemode = true\n"+
+		"\tbare = %t\n", bare)
+	if !bare {
+		config += "\tlogallrefupdates = true\n"
+	}
+	return config
+}
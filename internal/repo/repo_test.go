@@ -1,14 +1,22 @@
 package repo_test
 
 import (
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/config"
+	"github.com/MahendraDani/gitloom.git/internal/pack"
 	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/testrepo"
 )
 
 func TestInitRepository(t *testing.T) {
+	testrepo.Isolate(t)
 	tempDir := t.TempDir()
 
 	// Run the function under test
@@ -69,6 +77,7 @@ func TestInitRepository(t *testing.T) {
  2. The error message matches "repository already exists"
 */
 func TestInitRepositoryAlreadyExists(t *testing.T) {
+	testrepo.Isolate(t)
 	tempDir := t.TempDir()
 
 	gitloomPath := filepath.Join(tempDir, repo.RepoDirName)
@@ -86,6 +95,7 @@ func TestInitRepositoryAlreadyExists(t *testing.T) {
 }
 
 func TestInitRepositoryInvalidPath(t *testing.T) {
+	testrepo.Isolate(t)
 	// Provide an invalid path — e.g. a directory we can't create inside.
 	// On Unix, /root usually requires root privileges.
 	invalidPath := "/root/gitloom-test-invalid"
@@ -101,3 +111,361 @@ func TestInitRepositoryInvalidPath(t *testing.T) {
 		t.Fatalf("unexpected 'repository already exists' error for invalid path")
 	}
 }
+
+func TestWriteAndReadObject(t *testing.T) {
+	r := testrepo.New(t)
+
+	blob := internal.NewBlob([]byte("hello world\n"))
+	hash, err := r.WriteObject(blob)
+	if err != nil {
+		t.Fatalf("WriteObject returned error: %v", err)
+	}
+
+	if hash != internal.HashOf(blob) {
+		t.Fatalf("expected hash %s, got %s", internal.HashOf(blob), hash)
+	}
+
+	objPath := filepath.Join(r.Path, repo.ObjectsDir, hash[:2], hash[2:])
+	if _, err := os.Stat(objPath); err != nil {
+		t.Fatalf("expected object file at %s, but got error: %v", objPath, err)
+	}
+
+	obj, err := r.ReadObject(hash)
+	if err != nil {
+		t.Fatalf("ReadObject returned error: %v", err)
+	}
+
+	got, ok := obj.(*internal.Blob)
+	if !ok {
+		t.Fatalf("expected *internal.Blob, got %T", obj)
+	}
+	if string(got.Data) != "hello world\n" {
+		t.Fatalf("unexpected blob content: %q", got.Data)
+	}
+}
+
+func TestWriteObjectRejectsInvalidTree(t *testing.T) {
+	r := testrepo.New(t)
+
+	for _, hash := range []string{"", "1234", strings.Repeat("z", 40)} {
+		tree := &internal.Tree{Entries: []internal.TreeEntry{{Mode: internal.ModeBlob, Name: "file.txt", Hash: hash}}}
+		if _, err := r.WriteObject(tree); err == nil {
+			t.Errorf("expected error writing a tree entry with hash %q", hash)
+		}
+	}
+	loose, err := r.LooseObjects()
+	if err != nil || len(loose) != 0 {
+		t.Fatalf("expected no objects to be stored, got %v (%v)", loose, err)
+	}
+}
+
+func TestReadObjectMissing(t *testing.T) {
+	r := testrepo.New(t)
+
+	if _, err := r.ReadObject("3b18e512dba79e4c8300dd08aeb37f8e728b8dad"); err == nil {
+		t.Fatalf("expected error reading missing object, got nil")
+	}
+}
+
+func TestWriteObjectStreamAndOpenObject(t *testing.T) {
+	r := testrepo.New(t)
+
+	content := strings.Repeat("streamed content\n", 10000)
+	hash, err := r.WriteObjectStream(internal.BlobType, int64(len(content)), strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("WriteObjectStream returned error: %v", err)
+	}
+	if expected := internal.Hash(internal.BlobType, []byte(content)); hash != expected {
+		t.Fatalf("expected hash %s, got %s", expected, hash)
+	}
+
+	obj, err := r.OpenObject(hash)
+	if err != nil {
+		t.Fatalf("OpenObject returned error: %v", err)
+	}
+	defer obj.Close()
+
+	if obj.Type != internal.BlobType || obj.Size != int64(len(content)) {
+		t.Fatalf("unexpected header: type=%s size=%d", obj.Type, obj.Size)
+	}
+
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		t.Fatalf("failed to read object body: %v", err)
+	}
+	if string(data) != content {
+		t.Fatalf("streamed body does not match written content")
+	}
+
+	// No temporary files are left behind in the objects directory
+	entries, err := os.ReadDir(filepath.Join(r.Path, repo.ObjectsDir))
+	if err != nil {
+		t.Fatalf("failed to read objects dir: %v", err)
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			t.Errorf("unexpected file left in objects dir: %s", e.Name())
+		}
+	}
+}
+
+func TestWriteObjectStreamSizeMismatch(t *testing.T) {
+	r := testrepo.New(t)
+
+	if _, err := r.WriteObjectStream(internal.BlobType, 100, strings.NewReader("short")); err == nil {
+		t.Fatalf("expected error for body shorter than size, got nil")
+	}
+}
+
+// failingReader returns some data and then an error, like a file on a
+// disk that goes away mid-read.
+type failingReader struct{ sent bool }
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	if f.sent {
+		return 0, errors.New("read failed")
+	}
+	f.sent = true
+	return copy(p, "partial"), nil
+}
+
+func TestWriteObjectStreamFailureLeavesNoObject(t *testing.T) {
+	r := testrepo.New(t)
+
+	if _, err := r.WriteObjectStream(internal.BlobType, 100, &failingReader{}); err == nil {
+		t.Fatalf("expected error from failing reader, got nil")
+	}
+
+	err := filepath.WalkDir(filepath.Join(r.Path, repo.ObjectsDir), func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			t.Errorf("unexpected file left after failed write: %s", path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to walk objects dir: %v", err)
+	}
+}
+
+func TestWriteObjectIsReadOnly(t *testing.T) {
+	r := testrepo.New(t)
+
+	hash, err := r.WriteObject(internal.NewBlob([]byte("hello world\n")))
+	if err != nil {
+		t.Fatalf("WriteObject returned error: %v", err)
+	}
+
+	fi, err := os.Stat(filepath.Join(r.Path, repo.ObjectsDir, hash[:2], hash[2:]))
+	if err != nil {
+		t.Fatalf("failed to stat object: %v", err)
+	}
+	if fi.Mode().Perm() != repo.ObjectPerm {
+		t.Fatalf("expected object permissions %o, got %o", repo.ObjectPerm, fi.Mode().Perm())
+	}
+
+	// Writing the same object again is a no-op rather than a permission error
+	if _, err := r.WriteObject(internal.NewBlob([]byte("hello world\n"))); err != nil {
+		t.Fatalf("rewriting existing object returned error: %v", err)
+	}
+}
+
+func TestPackedObjectsAreReadable(t *testing.T) {
+	r := testrepo.New(t)
+
+	content := strings.Repeat("packed content\n", 100)
+	hash, err := r.WriteRawObject(internal.BlobType, []byte(content))
+	if err != nil {
+		t.Fatalf("failed to write object: %v", err)
+	}
+	if _, err := r.WritePack([]string{hash}, pack.Options{}); err != nil {
+		t.Fatalf("failed to write pack: %v", err)
+	}
+
+	// Remove the loose copy so reads must go through the pack
+	loose := filepath.Join(r.Path, repo.ObjectsDir, hash[:2], hash[2:])
+	if err := os.Remove(loose); err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.HasObject(hash) {
+		t.Fatalf("HasObject is false for a packed object")
+	}
+	objType, size, err := r.ObjectInfo(hash)
+	if err != nil || objType != internal.BlobType || size != int64(len(content)) {
+		t.Fatalf("ObjectInfo = %s, %d, %v", objType, size, err)
+	}
+	_, data, err := r.ReadRawObject(hash)
+	if err != nil || string(data) != content {
+		t.Fatalf("ReadRawObject returned %q, %v", data, err)
+	}
+	found, err := r.FindObjects(hash[:6])
+	if err != nil || len(found) != 1 || found[0] != hash {
+		t.Fatalf("FindObjects = %v, %v", found, err)
+	}
+}
+
+func TestBadPackIsSkipped(t *testing.T) {
+	r := testrepo.New(t)
+
+	packed, err := r.WriteRawObject(internal.BlobType, []byte("packed\n"))
+	if err != nil {
+		t.Fatalf("failed to write object: %v", err)
+	}
+	checksum, err := r.WritePack([]string{packed}, pack.Options{})
+	if err != nil {
+		t.Fatalf("failed to write pack: %v", err)
+	}
+	idxPath := filepath.Join(r.Path, repo.PackDir, "pack-"+checksum+".idx")
+	if err := os.Chmod(idxPath, repo.FilePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(idxPath, 10); err != nil {
+		t.Fatal(err)
+	}
+	r.ReloadPacks()
+
+	// Objects that are also stored loose stay readable
+	loose, err := r.WriteRawObject(internal.BlobType, []byte("loose\n"))
+	if err != nil {
+		t.Fatalf("failed to write object: %v", err)
+	}
+	for _, hash := range []string{packed, loose} {
+		if _, _, err := r.ReadRawObject(hash); err != nil {
+			t.Fatalf("ReadRawObject(%s) returned error: %v", hash, err)
+		}
+	}
+
+	bad, err := r.BadPacks()
+	if err != nil {
+		t.Fatalf("BadPacks returned error: %v", err)
+	}
+	if len(bad) != 1 || !strings.Contains(bad[0].Error(), idxPath) {
+		t.Fatalf("expected the truncated index to be reported, got %v", bad)
+	}
+}
+
+func TestInitGitCompatible(t *testing.T) {
+	testrepo.Isolate(t)
+	tempDir := t.TempDir()
+
+	r := repo.NewRepo(tempDir)
+	reused, err := r.InitGitCompatible()
+	if err != nil {
+		t.Fatalf("InitGitCompatible returned error: %v", err)
+	}
+	if reused {
+		t.Fatalf("expected a new repository, got reused")
+	}
+	if r.Path != filepath.Join(tempDir, repo.GitDirName) || !r.GitCompatible() {
+		t.Fatalf("unexpected repository path %s", r.Path)
+	}
+
+	for _, name := range []string{"HEAD", "config", "objects/pack", "objects/info", "refs/heads", "refs/tags"} {
+		if _, err := os.Stat(filepath.Join(r.Path, filepath.FromSlash(name))); err != nil {
+			t.Errorf("expected %s to exist: %v", name, err)
+		}
+	}
+
+	sub := filepath.Join(tempDir, "a", "b")
+	if err := os.MkdirAll(sub, repo.DirPerm); err != nil {
+		t.Fatalf("failed to create subdirectory: %v", err)
+	}
+	found, err := repo.FindRepo(sub)
+	if err != nil {
+		t.Fatalf("FindRepo returned error: %v", err)
+	}
+	if found.Path != r.Path {
+		t.Fatalf("expected FindRepo to return %s, got %s", r.Path, found.Path)
+	}
+
+	if reused, err := repo.NewRepo(tempDir).InitGitCompatible(); err != nil || !reused {
+		t.Fatalf("expected the existing .git to be reused, got reused=%v err=%v", reused, err)
+	}
+
+	// .gitloom takes precedence once both exist
+	if err := repo.NewRepo(tempDir).Init(); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	found, err = repo.FindRepo(sub)
+	if err != nil {
+		t.Fatalf("FindRepo returned error: %v", err)
+	}
+	if found.Path != filepath.Join(tempDir, repo.RepoDirName) {
+		t.Fatalf("expected FindRepo to prefer .gitloom, got %s", found.Path)
+	}
+}
+
+func TestFindRepoIgnoresPlainGitRepository(t *testing.T) {
+	testrepo.Isolate(t)
+	tempDir := t.TempDir()
+	if err := repo.InitGitDir(filepath.Join(tempDir, repo.GitDirName), false); err != nil {
+		t.Fatalf("InitGitDir returned error: %v", err)
+	}
+
+	if _, err := repo.FindRepo(tempDir); err == nil {
+		t.Fatalf("expected FindRepo to ignore a .git directory without %s", repo.CompatibleKey)
+	}
+
+	// Opting in marks the existing repository
+	if reused, err := repo.NewRepo(tempDir).InitGitCompatible(); err != nil || !reused {
+		t.Fatalf("expected the existing .git to be reused, got reused=%v err=%v", reused, err)
+	}
+	found, err := repo.FindRepo(tempDir)
+	if err != nil {
+		t.Fatalf("FindRepo returned error: %v", err)
+	}
+	if found.Path != filepath.Join(tempDir, repo.GitDirName) {
+		t.Fatalf("expected FindRepo to return the .git directory, got %s", found.Path)
+	}
+}
+
+func TestConfigAccessors(t *testing.T) {
+	global := filepath.Join(t.TempDir(), "global")
+	if err := os.WriteFile(global, []byte("[init]\n\tdefaultBranch = trunk\n[core]\n\tcompression = 1\n"), repo.FilePerm); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(config.GlobalEnv, global)
+
+	r := repo.NewRepo(t.TempDir())
+	if err := r.Init(); err != nil {
+		t.Fatalf("failed to init repository: %v", err)
+	}
+	head, err := os.ReadFile(filepath.Join(r.Path, repo.HeadFile))
+	if err != nil || string(head) != "ref: refs/heads/trunk\n" {
+		t.Fatalf("expected HEAD on init.defaultBranch, got %q (%v)", head, err)
+	}
+
+	local, err := config.Load(r.ConfigPath())
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	local.Set("user.name", "Jane Doe")
+	local.Set("pack.compression", "9")
+	if err := local.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	r.ReloadConfig()
+
+	if name, err := r.UserName(); err != nil || name != "Jane Doe" {
+		t.Errorf("expected user.name Jane Doe, got %q (%v)", name, err)
+	}
+	if level, err := r.LooseCompression(); err != nil || level != 1 {
+		t.Errorf("expected loose compression 1 from core.compression, got %d (%v)", level, err)
+	}
+	if level, err := r.PackCompression(); err != nil || level != 9 {
+		t.Errorf("expected pack compression 9, got %d (%v)", level, err)
+	}
+
+	// An invalid level is reported when writing objects
+	local.Set("core.looseCompression", "12")
+	if err := local.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	r.ReloadConfig()
+	if _, err := r.WriteObject(internal.NewBlob([]byte("data\n"))); err == nil {
+		t.Fatalf("expected an error for compression level 12")
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package repo
+
+// syncDir is a no-op on platforms that cannot fsync a directory.
+func syncDir(dir string) error {
+	return nil
+}
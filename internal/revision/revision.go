@@ -0,0 +1,254 @@
+package revision
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/history"
+	"github.com/MahendraDani/gitloom.git/internal/index"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// MinPrefixLength is the shortest abbreviated hash that is accepted.
+const MinPrefixLength = 4
+
+var (
+	// ErrUnknown is returned when a revision does not name any object.
+	ErrUnknown = errors.New("unknown revision")
+	// ErrAmbiguous is returned when an abbreviated hash matches several objects.
+	ErrAmbiguous = errors.New("ambiguous revision")
+)
+
+// Resolve turns a revision into a full object hash. It understands
+//
+//	<hash> and unique hash prefixes of at least MinPrefixLength characters
+//	HEAD, @, branch, tag and remote-tracking names (main, tags/v1, origin/main)
+//	<rev>~<n>        the n-th first-parent ancestor
+//	<rev>^<n>        the n-th parent (^0 is the commit itself)
+//	<rev>^{<type>}   the object peeled to commit, tree or blob; ^{} peels tags
+//	<rev>:<path>     the blob or tree at path in the tree of rev
+//	:<path>          the blob staged for path in the index
+func Resolve(r *repo.Repo, rev string) (string, error) {
+	if rev == "" {
+		return "", fmt.Errorf("%w: empty revision", ErrUnknown)
+	}
+
+	if base, path, found := strings.Cut(rev, ":"); found {
+		if base == "" {
+			return resolveIndexPath(r, path)
+		}
+		hash, err := Resolve(r, base)
+		if err != nil {
+			return "", err
+		}
+		return resolveTreePath(r, hash, path, rev)
+	}
+
+	name, suffixes := splitSuffixes(rev)
+	hash, err := resolveName(r, name)
+	if err != nil {
+		return "", err
+	}
+
+	for suffixes != "" {
+		hash, suffixes, err = applySuffix(r, hash, suffixes, rev)
+		if err != nil {
+			return "", err
+		}
+	}
+	return hash, nil
+}
+
+// ResolveCommit resolves rev and peels it to a commit.
+func ResolveCommit(r *repo.Repo, rev string) (string, error) {
+	hash, err := Resolve(r, rev)
+	if err != nil {
+		return "", err
+	}
+	return Peel(r, hash, internal.CommitType)
+}
+
+// Peel follows tags starting at hash until an object of objType is found.
+// A commit peels to its tree when objType is tree. An empty objType peels
+// tags to whatever they point to.
+func Peel(r *repo.Repo, hash, objType string) (string, error) {
+	for {
+		obj, err := r.ReadObject(hash)
+		if err != nil {
+			return "", err
+		}
+
+		if obj.Type() == objType || (objType == "" && obj.Type() != internal.TagType) {
+			return hash, nil
+		}
+
+		switch o := obj.(type) {
+		case *internal.Tag:
+			hash = o.Object
+		case *internal.Commit:
+			if objType != internal.TreeType {
+				return "", fmt.Errorf("object %s is a commit, not a %s", hash, objType)
+			}
+			hash = o.Tree
+		default:
+			return "", fmt.Errorf("object %s is a %s, not a %s", hash, obj.Type(), objType)
+		}
+	}
+}
+
+// splitSuffixes separates "main~2^2" into "main" and "~2^2".
+func splitSuffixes(rev string) (string, string) {
+	i := strings.IndexAny(rev, "~^")
+	if i < 0 {
+		return rev, ""
+	}
+	return rev[:i], rev[i:]
+}
+
+func resolveName(r *repo.Repo, name string) (string, error) {
+	if name == "@" {
+		name = refs.Head
+	}
+
+	if internal.IsHash(name) {
+		if !r.HasObject(name) {
+			return "", fmt.Errorf("%w: %s", ErrUnknown, name)
+		}
+		return name, nil
+	}
+
+	hash, err := refs.ResolveShort(r, name)
+	if err == nil {
+		return hash, nil
+	}
+	if !errors.Is(err, refs.ErrNotFound) {
+		return "", err
+	}
+
+	if len(name) >= MinPrefixLength && internal.IsHashPrefix(name) {
+		matches, err := r.FindObjects(name)
+		if err != nil {
+			return "", err
+		}
+		switch len(matches) {
+		case 1:
+			return matches[0], nil
+		case 0:
+		default:
+			return "", fmt.Errorf("%w: short object name %s matches %s", ErrAmbiguous, name, strings.Join(matches, ", "))
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrUnknown, name)
+}
+
+// applySuffix applies the first ~, ^ or ^{type} operator in suffixes to
+// hash and returns the remaining suffixes.
+func applySuffix(r *repo.Repo, hash, suffixes, rev string) (string, string, error) {
+	op := suffixes[0]
+	rest := suffixes[1:]
+
+	if op == '^' && strings.HasPrefix(rest, "{") {
+		end := strings.IndexByte(rest, '}')
+		if end < 0 {
+			return "", "", fmt.Errorf("%w: %s", ErrUnknown, rev)
+		}
+		peeled, err := Peel(r, hash, rest[1:end])
+		return peeled, rest[end+1:], err
+	}
+
+	digits := 0
+	for digits < len(rest) && rest[digits] >= '0' && rest[digits] <= '9' {
+		digits++
+	}
+	n := 1
+	if digits > 0 {
+		var err error
+		if n, err = strconv.Atoi(rest[:digits]); err != nil {
+			return "", "", fmt.Errorf("%w: %s", ErrUnknown, rev)
+		}
+	}
+	rest = rest[digits:]
+
+	commitHash, err := Peel(r, hash, internal.CommitType)
+	if err != nil {
+		return "", "", err
+	}
+
+	if op == '^' {
+		if n == 0 {
+			return commitHash, rest, nil
+		}
+		c, err := history.ReadCommit(r, commitHash)
+		if err != nil {
+			return "", "", err
+		}
+		if n > len(c.Parents) {
+			return "", "", fmt.Errorf("%w: %s has no parent %d", ErrUnknown, commitHash, n)
+		}
+		return c.Parents[n-1], rest, nil
+	}
+
+	for i := 0; i < n; i++ {
+		c, err := history.ReadCommit(r, commitHash)
+		if err != nil {
+			return "", "", err
+		}
+		if len(c.Parents) == 0 {
+			return "", "", fmt.Errorf("%w: %s has only %d ancestors", ErrUnknown, rev, i)
+		}
+		commitHash = c.Parents[0]
+	}
+	return commitHash, rest, nil
+}
+
+// resolveTreePath finds path inside the tree of the commit, tag or tree hash.
+func resolveTreePath(r *repo.Repo, hash, path, rev string) (string, error) {
+	current, err := Peel(r, hash, internal.TreeType)
+	if err != nil {
+		return "", err
+	}
+
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		if part == "" {
+			continue
+		}
+
+		obj, err := r.ReadObject(current)
+		if err != nil {
+			return "", err
+		}
+		t, ok := obj.(*internal.Tree)
+		if !ok {
+			return "", fmt.Errorf("%w: path %s does not exist in %s", ErrUnknown, path, rev)
+		}
+
+		found := false
+		for _, e := range t.Entries {
+			if e.Name == part {
+				current, found = e.Hash, true
+				break
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("%w: path %s does not exist in %s", ErrUnknown, path, rev)
+		}
+	}
+	return current, nil
+}
+
+func resolveIndexPath(r *repo.Repo, path string) (string, error) {
+	idx, err := index.Read(r)
+	if err != nil {
+		return "", err
+	}
+	e, ok := idx.Entry(strings.Trim(path, "/"))
+	if !ok {
+		return "", fmt.Errorf("%w: path %s is not in the index", ErrUnknown, path)
+	}
+	return e.Hash, nil
+}
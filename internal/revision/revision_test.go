@@ -0,0 +1,147 @@
+package revision_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/commit"
+	"github.com/MahendraDani/gitloom.git/internal/index"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/revision"
+	"github.com/MahendraDani/gitloom.git/internal/testrepo"
+)
+
+type fixture struct {
+	r                      *repo.Repo
+	root                   string
+	first, second, topic   string
+	merge                  string
+	readmeBlob, secondTree string
+}
+
+// setupHistory creates first <- second <- merge, where merge also has topic
+// (a child of first) as its second parent. main points to merge.
+func setupHistory(t *testing.T) *fixture {
+	t.Helper()
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
+	f := &fixture{r: r, root: tempDir}
+
+	commitFile := func(name, content, message string) string {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), repo.DirPerm); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), repo.FilePerm); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		if err := index.Add(r, []string{path}); err != nil {
+			t.Fatalf("index.Add returned error: %v", err)
+		}
+		res, err := commit.Commit(r, message)
+		if err != nil {
+			t.Fatalf("Commit returned error: %v", err)
+		}
+		return res.Hash
+	}
+
+	f.first = commitFile("README.md", "hello\n", "first")
+	f.second = commitFile("docs/guide.md", "guide\n", "second")
+
+	secondCommit, err := r.ReadObject(f.second)
+	if err != nil {
+		t.Fatalf("ReadObject returned error: %v", err)
+	}
+	f.secondTree = secondCommit.(*internal.Commit).Tree
+	f.readmeBlob = internal.HashOf(internal.NewBlob([]byte("hello\n")))
+
+	if f.topic, err = commit.CommitTree(r, f.secondTree, []string{f.first}, "topic"); err != nil {
+		t.Fatalf("CommitTree returned error: %v", err)
+	}
+	if f.merge, err = commit.CommitTree(r, f.secondTree, []string{f.second, f.topic}, "merge"); err != nil {
+		t.Fatalf("CommitTree returned error: %v", err)
+	}
+	if err := refs.Update(r, "refs/heads/main", f.merge, f.second); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if err := refs.Update(r, "refs/heads/topic", f.topic, ""); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	return f
+}
+
+func TestResolve(t *testing.T) {
+	f := setupHistory(t)
+
+	cases := map[string]string{
+		f.first:                f.first,
+		f.first[:7]:            f.first,
+		"HEAD":                 f.merge,
+		"@":                    f.merge,
+		"main":                 f.merge,
+		"refs/heads/topic":     f.topic,
+		"HEAD~1":               f.second,
+		"HEAD~2":               f.first,
+		"HEAD~":                f.second,
+		"main^":                f.second,
+		"main^2":               f.topic,
+		"main^0":               f.merge,
+		"main^2~1":             f.first,
+		"HEAD^^":               f.first,
+		"HEAD^{tree}":          f.secondTree,
+		"HEAD:":                f.secondTree,
+		"HEAD:README.md":       f.readmeBlob,
+		"HEAD~2:README.md":     f.readmeBlob,
+		f.merge[:8] + ":docs/": "",
+		":README.md":           f.readmeBlob,
+	}
+
+	for rev, expected := range cases {
+		hash, err := revision.Resolve(f.r, rev)
+		if err != nil {
+			t.Errorf("Resolve(%q) returned error: %v", rev, err)
+			continue
+		}
+		if expected != "" && hash != expected {
+			t.Errorf("Resolve(%q) = %s, want %s", rev, hash, expected)
+		}
+	}
+}
+
+func TestResolve_Errors(t *testing.T) {
+	f := setupHistory(t)
+
+	for _, rev := range []string{"missing", "HEAD~5", "main^3", "HEAD:nope.txt", "HEAD:README.md/x", "abc", ":nope"} {
+		if _, err := revision.Resolve(f.r, rev); !errors.Is(err, revision.ErrUnknown) {
+			t.Errorf("Resolve(%q): expected ErrUnknown, got %v", rev, err)
+		}
+	}
+}
+
+func TestResolve_AmbiguousPrefix(t *testing.T) {
+	f := setupHistory(t)
+
+	// Store blobs until two of them share a 4 character prefix
+	seen := map[string]string{}
+	var prefix string
+	for i := 0; prefix == ""; i++ {
+		blob := internal.NewBlob([]byte(fmt.Sprintf("blob %d\n", i)))
+		hash, err := f.r.WriteObject(blob)
+		if err != nil {
+			t.Fatalf("WriteObject returned error: %v", err)
+		}
+		if _, ok := seen[hash[:4]]; ok {
+			prefix = hash[:4]
+		}
+		seen[hash[:4]] = hash
+	}
+
+	if _, err := revision.Resolve(f.r, prefix); !errors.Is(err, revision.ErrAmbiguous) {
+		t.Fatalf("expected ErrAmbiguous for %s, got %v", prefix, err)
+	}
+}
@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signature identifies the author, committer or tagger of an object,
+// serialized as "Name <email> <unix seconds> <+hhmm>".
+type Signature struct {
+	Name  string
+	Email string
+	When  time.Time
+}
+
+func (s Signature) String() string {
+	return fmt.Sprintf("%s <%s> %d %s", s.Name, s.Email, s.When.Unix(), s.When.Format("-0700"))
+}
+
+func ParseSignature(line string) (Signature, error) {
+	open := strings.IndexByte(line, '<')
+	closing := strings.LastIndexByte(line, '>')
+	if open < 0 || closing < open {
+		return Signature{}, fmt.Errorf("invalid signature %q: missing email", line)
+	}
+
+	name := strings.TrimSpace(line[:open])
+	email := line[open+1 : closing]
+
+	fields := strings.Fields(line[closing+1:])
+	if len(fields) != 2 {
+		return Signature{}, fmt.Errorf("invalid signature %q: missing timestamp or timezone", line)
+	}
+
+	secs, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return Signature{}, fmt.Errorf("invalid signature timestamp %q: %w", fields[0], err)
+	}
+
+	offset, err := parseTimezone(fields[1])
+	if err != nil {
+		return Signature{}, err
+	}
+
+	when := time.Unix(secs, 0).In(time.FixedZone("", offset))
+	return Signature{Name: name, Email: email, When: when}, nil
+}
+
+// parseTimezone converts a "+hhmm"/"-hhmm" offset into seconds east of UTC.
+func parseTimezone(tz string) (int, error) {
+	if len(tz) != 5 || (tz[0] != '+' && tz[0] != '-') {
+		return 0, fmt.Errorf("invalid timezone %q", tz)
+	}
+	hours, err := strconv.Atoi(tz[1:3])
+	if err != nil {
+		return 0, fmt.Errorf("invalid timezone %q", tz)
+	}
+	minutes, err := strconv.Atoi(tz[3:5])
+	if err != nil {
+		return 0, fmt.Errorf("invalid timezone %q", tz)
+	}
+	offset := hours*3600 + minutes*60
+	if tz[0] == '-' {
+		offset = -offset
+	}
+	return offset, nil
+}
+
+// splitHeaders separates a commit or tag body into its header lines and
+// message, which are divided by the first blank line.
+func splitHeaders(data []byte) ([]string, string, error) {
+	text := string(data)
+	headers, message, found := strings.Cut(text, "\n\n")
+	if !found {
+		// An object with no message still terminates its headers with a newline.
+		if !strings.HasSuffix(text, "\n") {
+			return nil, "", errors.New("invalid object: missing header terminator")
+		}
+		headers = strings.TrimSuffix(text, "\n")
+	}
+	return strings.Split(headers, "\n"), message, nil
+}
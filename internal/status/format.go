@@ -0,0 +1,104 @@
+package status
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/MahendraDani/gitloom.git/internal/branch"
+)
+
+// WriteLong prints s in the layout of git status.
+func (s *Status) WriteLong(w io.Writer) {
+	if s.Detached {
+		fmt.Fprintln(w, "HEAD detached")
+	} else {
+		fmt.Fprintf(w, "On branch %s\n", branch.ShortName(s.Branch))
+	}
+
+	if s.Merging {
+		if len(s.Unmerged) > 0 {
+			fmt.Fprintln(w, "You have unmerged paths.")
+			fmt.Fprintln(w, "  (fix conflicts and run \"gitloom commit\")")
+			fmt.Fprintln(w, "  (use \"gitloom merge --abort\" to abort the merge)")
+		} else {
+			fmt.Fprintln(w, "All conflicts fixed but you are still merging.")
+			fmt.Fprintln(w, "  (use \"gitloom commit\" to conclude merge)")
+		}
+		fmt.Fprintln(w)
+	}
+
+	if s.Clean() {
+		fmt.Fprintln(w, "nothing to commit, working tree clean")
+		return
+	}
+
+	if len(s.Staged) > 0 {
+		fmt.Fprintln(w, "Changes to be committed:")
+		writeChanges(w, s.Staged)
+	}
+	if len(s.Unmerged) > 0 {
+		fmt.Fprintln(w, "Unmerged paths:")
+		for _, c := range s.Unmerged {
+			fmt.Fprintf(w, "\t%-17s%s\n", c.Kind.String()+":", c.Path)
+		}
+		fmt.Fprintln(w)
+	}
+	if len(s.Unstaged) > 0 {
+		fmt.Fprintln(w, "Changes not staged for commit:")
+		writeChanges(w, s.Unstaged)
+	}
+	if len(s.Untracked) > 0 {
+		fmt.Fprintln(w, "Untracked files:")
+		for _, path := range s.Untracked {
+			fmt.Fprintf(w, "\t%s\n", path)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func writeChanges(w io.Writer, changes []Change) {
+	for _, c := range changes {
+		fmt.Fprintf(w, "\t%-12s%s\n", c.Kind.String()+":", c.Path)
+	}
+	fmt.Fprintln(w)
+}
+
+// WriteShort prints s as "XY path" lines, where X is the staged and Y the
+// unstaged state of each path, like git status --short.
+func (s *Status) WriteShort(w io.Writer) {
+	codes := make(map[string][2]string)
+	for _, c := range s.Staged {
+		code := codes[c.Path]
+		code[0] = c.Kind.Code()
+		codes[c.Path] = code
+	}
+	for _, c := range s.Unstaged {
+		code := codes[c.Path]
+		code[1] = c.Kind.Code()
+		codes[c.Path] = code
+	}
+	for _, c := range s.Unmerged {
+		code := c.Kind.Code()
+		codes[c.Path] = [2]string{code[:1], code[1:]}
+	}
+
+	paths := make([]string, 0, len(codes))
+	for path := range codes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		code := codes[path]
+		for i := range code {
+			if code[i] == "" {
+				code[i] = " "
+			}
+		}
+		fmt.Fprintf(w, "%s%s %s\n", code[0], code[1], path)
+	}
+	for _, path := range s.Untracked {
+		fmt.Fprintf(w, "?? %s\n", path)
+	}
+}
@@ -0,0 +1,360 @@
+package status
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/history"
+	"github.com/MahendraDani/gitloom.git/internal/ignore"
+	"github.com/MahendraDani/gitloom.git/internal/index"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/tree"
+	"github.com/MahendraDani/gitloom.git/internal/worktree"
+)
+
+type ChangeKind int
+
+const (
+	Added ChangeKind = iota
+	Modified
+	Deleted
+
+	// Kinds of merge conflicts, by which sides changed the path
+	BothModified
+	BothAdded
+	BothDeleted
+	AddedByUs
+	AddedByThem
+	DeletedByUs
+	DeletedByThem
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "new file"
+	case Modified:
+		return "modified"
+	case Deleted:
+		return "deleted"
+	case BothModified:
+		return "both modified"
+	case BothAdded:
+		return "both added"
+	case BothDeleted:
+		return "both deleted"
+	case AddedByUs:
+		return "added by us"
+	case AddedByThem:
+		return "added by them"
+	case DeletedByUs:
+		return "deleted by us"
+	case DeletedByThem:
+		return "deleted by them"
+	default:
+		return "unknown"
+	}
+}
+
+// Code returns the single letter used for k by status --short, or both
+// letters for a merge conflict.
+func (k ChangeKind) Code() string {
+	switch k {
+	case Added:
+		return "A"
+	case Modified:
+		return "M"
+	case Deleted:
+		return "D"
+	case BothModified:
+		return "UU"
+	case BothAdded:
+		return "AA"
+	case BothDeleted:
+		return "DD"
+	case AddedByUs:
+		return "AU"
+	case AddedByThem:
+		return "UA"
+	case DeletedByUs:
+		return "DU"
+	case DeletedByThem:
+		return "UD"
+	default:
+		return "?"
+	}
+}
+
+// Change is a single path that differs between two states.
+type Change struct {
+	Path string
+	Kind ChangeKind
+}
+
+// Status compares HEAD, the index and the working tree.
+type Status struct {
+	Branch    string // full name of the current branch, empty when detached
+	Detached  bool
+	Merging   bool     // a merge stopped and waits to be committed
+	Staged    []Change // HEAD tree vs index
+	Unstaged  []Change // index vs working tree
+	Unmerged  []Change // paths with merge conflicts
+	Untracked []string // directories end with "/"
+}
+
+// Clean reports whether there is nothing to commit and no untracked files.
+func (s *Status) Clean() bool {
+	return len(s.Staged) == 0 && len(s.Unstaged) == 0 && len(s.Unmerged) == 0 && len(s.Untracked) == 0
+}
+
+// Compute collects the status of r. Files whose cached stat data in the
+// index is still current are not rehashed, and stat data of files found
+// unchanged after rehashing is written back so the next run is fast.
+func Compute(r *repo.Repo) (*Status, error) {
+	s := &Status{}
+
+	branch, detached, err := refs.CurrentBranch(r)
+	if err != nil {
+		return nil, err
+	}
+	s.Branch, s.Detached = branch, detached
+
+	idx, err := index.Read(r)
+	if err != nil {
+		return nil, err
+	}
+
+	headFiles, err := headTree(r)
+	if err != nil {
+		return nil, err
+	}
+	s.Staged = diffHeadIndex(headFiles, idx)
+	s.Unmerged = unmerged(idx)
+
+	switch _, err := refs.Resolve(r, refs.MergeHead); {
+	case err == nil:
+		s.Merging = true
+	case !errors.Is(err, refs.ErrNotFound):
+		return nil, err
+	}
+
+	refreshed, err := s.diffIndexWorkTree(r, idx)
+	if err != nil {
+		return nil, err
+	}
+	if refreshed {
+		// Best effort: another process may hold the index lock
+		_ = idx.Write(r)
+	}
+
+	if s.Untracked, err = untracked(r, idx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// headTree flattens the tree of the commit HEAD points to. An unborn
+// branch has no files.
+func headTree(r *repo.Repo) (map[string]internal.TreeEntry, error) {
+	head, err := refs.Resolve(r, refs.Head)
+	if errors.Is(err, refs.ErrNotFound) {
+		return map[string]internal.TreeEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := history.ReadCommit(r, head)
+	if err != nil {
+		return nil, err
+	}
+	return tree.Flatten(r, c.Tree)
+}
+
+func diffHeadIndex(headFiles map[string]internal.TreeEntry, idx *index.Index) []Change {
+	var changes []Change
+	staged := make(map[string]bool, len(idx.Entries))
+
+	for _, e := range idx.Entries {
+		staged[e.Path] = true
+		if e.Stage != 0 {
+			continue
+		}
+		head, ok := headFiles[e.Path]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Path: e.Path, Kind: Added})
+		case head.Hash != e.Hash || head.Mode != fmt.Sprintf("%o", e.Mode):
+			changes = append(changes, Change{Path: e.Path, Kind: Modified})
+		}
+	}
+
+	for path := range headFiles {
+		if !staged[path] {
+			changes = append(changes, Change{Path: path, Kind: Deleted})
+		}
+	}
+
+	sortChanges(changes)
+	return changes
+}
+
+// diffIndexWorkTree fills s.Unstaged and reports whether any index entry had
+// its stat data refreshed.
+func (s *Status) diffIndexWorkTree(r *repo.Repo, idx *index.Index) (bool, error) {
+	root := r.WorkTree()
+	refreshed := false
+
+	for _, e := range idx.Entries {
+		if e.Stage != 0 {
+			continue
+		}
+		full := filepath.Join(root, filepath.FromSlash(e.Path))
+		fi, err := os.Lstat(full)
+		if err != nil && !os.IsNotExist(err) {
+			return false, err
+		}
+		var mode string
+		ok := err == nil
+		if ok {
+			mode, ok = worktree.Mode(fi)
+		}
+		if !ok {
+			s.Unstaged = append(s.Unstaged, Change{Path: e.Path, Kind: Deleted})
+			continue
+		}
+
+		// A file that became executable, or a symlink, is modified even
+		// when its content is the same
+		if mode != fmt.Sprintf("%o", e.Mode) {
+			s.Unstaged = append(s.Unstaged, Change{Path: e.Path, Kind: Modified})
+			continue
+		}
+
+		if e.StatMatches(fi) && !idx.IsRacy(e) {
+			continue
+		}
+
+		hash, err := worktree.HashFile(r, full, fi, false)
+		if err != nil {
+			return false, err
+		}
+		if hash != e.Hash {
+			s.Unstaged = append(s.Unstaged, Change{Path: e.Path, Kind: Modified})
+			continue
+		}
+
+		if !e.StatMatches(fi) {
+			refreshed = idx.RefreshStat(e.Path, fi) || refreshed
+		}
+	}
+	return refreshed, nil
+}
+
+// unmerged lists the paths with merge conflicts, telling from the stages
+// present which sides added, changed or deleted each.
+func unmerged(idx *index.Index) []Change {
+	var changes []Change
+	for _, path := range idx.Unmerged() {
+		stages := idx.Stages(path)
+		base := stages[index.StageBase].Path != ""
+		ours := stages[index.StageOurs].Path != ""
+		theirs := stages[index.StageTheirs].Path != ""
+
+		kind := BothModified
+		switch {
+		case !base && ours && theirs:
+			kind = BothAdded
+		case !ours && !theirs:
+			kind = BothDeleted
+		case !base && ours:
+			kind = AddedByUs
+		case !base && theirs:
+			kind = AddedByThem
+		case !ours:
+			kind = DeletedByUs
+		case !theirs:
+			kind = DeletedByThem
+		}
+		changes = append(changes, Change{Path: path, Kind: kind})
+	}
+	return changes
+}
+
+// untracked lists files in the working tree that are not in the index.
+// Directories without any tracked files are reported once, as "dir/".
+func untracked(r *repo.Repo, idx *index.Index) ([]string, error) {
+	tracked := make(map[string]bool, len(idx.Entries))
+	trackedDirs := make(map[string]bool)
+	for _, e := range idx.Entries {
+		tracked[e.Path] = true
+		for dir := filepath.ToSlash(filepath.Dir(e.Path)); dir != "."; dir = filepath.ToSlash(filepath.Dir(dir)) {
+			trackedDirs[dir] = true
+		}
+	}
+
+	m, err := ignore.New(r)
+	if err != nil {
+		return nil, err
+	}
+
+	root := r.WorkTree()
+	var result []string
+	err = worktree.Walk(root, "", m, func(rel string, d fs.DirEntry) error {
+		if d.IsDir() {
+			if trackedDirs[rel] {
+				return nil
+			}
+			hasFiles, err := containsFiles(root, rel, m)
+			if err != nil {
+				return err
+			}
+			if hasFiles {
+				result = append(result, rel+"/")
+			}
+			return fs.SkipDir
+		}
+
+		if !tracked[rel] {
+			result = append(result, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(result)
+	return result, nil
+}
+
+// containsFiles reports whether the directory rel has any file below it
+// that is not ignored.
+func containsFiles(root, rel string, m *ignore.Matcher) (bool, error) {
+	found := false
+	err := worktree.Walk(root, rel, m, func(rel string, d fs.DirEntry) error {
+		if !d.IsDir() {
+			found = true
+			return errStopWalk
+		}
+		return nil
+	})
+	if err != nil && err != errStopWalk {
+		return false, err
+	}
+	return found, nil
+}
+
+var errStopWalk = errors.New("stop walk")
+
+func sortChanges(changes []Change) {
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+}
@@ -0,0 +1,148 @@
+package status_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MahendraDani/gitloom.git/internal/index"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/status"
+	"github.com/MahendraDani/gitloom.git/internal/testrepo"
+)
+
+// setupCommittedRepo creates a repository with tracked.txt and keep.txt committed.
+func setupCommittedRepo(t *testing.T) (*repo.Repo, string) {
+	t.Helper()
+	r := testrepo.New(t)
+	testrepo.WriteFile(t, r.WorkTree(), "tracked.txt", "tracked\n")
+	testrepo.WriteFile(t, r.WorkTree(), "keep.txt", "keep\n")
+	testrepo.CommitAll(t, r, "initial")
+	return r, r.WorkTree()
+}
+
+func TestCompute_Clean(t *testing.T) {
+	r, _ := setupCommittedRepo(t)
+
+	s, err := status.Compute(r)
+	if err != nil {
+		t.Fatalf("Compute returned error: %v", err)
+	}
+	if !s.Clean() {
+		t.Fatalf("expected clean status, got %+v", s)
+	}
+	if s.Branch != "refs/heads/main" {
+		t.Errorf("expected branch refs/heads/main, got %q", s.Branch)
+	}
+}
+
+func TestCompute_AllKinds(t *testing.T) {
+	r, root := setupCommittedRepo(t)
+
+	// staged: new file and deletion
+	testrepo.WriteFile(t, root, "added.txt", "added\n")
+	if err := os.Remove(filepath.Join(root, "keep.txt")); err != nil {
+		t.Fatalf("failed to remove keep.txt: %v", err)
+	}
+	if err := index.Add(r, []string{filepath.Join(root, "added.txt"), filepath.Join(root, "keep.txt")}); err != nil {
+		t.Fatalf("index.Add returned error: %v", err)
+	}
+
+	// unstaged: modification
+	testrepo.WriteFile(t, root, "tracked.txt", "changed\n")
+
+	// untracked: file and directory
+	testrepo.WriteFile(t, root, "notes.txt", "notes\n")
+	testrepo.WriteFile(t, root, "build/out/bin", "binary\n")
+
+	s, err := status.Compute(r)
+	if err != nil {
+		t.Fatalf("Compute returned error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	s.WriteShort(&buf)
+	expected := strings.Join([]string{
+		"A  added.txt",
+		"D  keep.txt",
+		" M tracked.txt",
+		"?? build/",
+		"?? notes.txt",
+		"",
+	}, "\n")
+	if buf.String() != expected {
+		t.Fatalf("unexpected short status:\n got:\n%s\nwant:\n%s", buf.String(), expected)
+	}
+}
+
+func TestCompute_RefreshesStatOfUnchangedFiles(t *testing.T) {
+	r, root := setupCommittedRepo(t)
+
+	// Touch the file without changing its content
+	path := filepath.Join(root, "tracked.txt")
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("failed to touch file: %v", err)
+	}
+
+	s, err := status.Compute(r)
+	if err != nil {
+		t.Fatalf("Compute returned error: %v", err)
+	}
+	if len(s.Unstaged) != 0 {
+		t.Fatalf("expected touched file to be unchanged, got %+v", s.Unstaged)
+	}
+
+	idx, err := index.Read(r)
+	if err != nil {
+		t.Fatalf("index.Read returned error: %v", err)
+	}
+	e, _ := idx.Entry("tracked.txt")
+	if e.MTime.Unix() != later.Unix() {
+		t.Fatalf("expected index mtime to be refreshed to %v, got %v", later, e.MTime)
+	}
+}
+
+func TestCompute_SkipsIgnoredFiles(t *testing.T) {
+	r, root := setupCommittedRepo(t)
+
+	testrepo.WriteFile(t, root, ".gitloom/info/exclude", "*.tmp\nbuild/\n")
+	testrepo.WriteFile(t, root, "scratch.tmp", "tmp\n")
+	testrepo.WriteFile(t, root, "build/out.bin", "binary\n")
+
+	s, err := status.Compute(r)
+	if err != nil {
+		t.Fatalf("Compute returned error: %v", err)
+	}
+	if len(s.Untracked) != 0 {
+		t.Fatalf("expected ignored files to be hidden, got %v", s.Untracked)
+	}
+
+	// Explicitly adding an ignored file is refused
+	if err := index.Add(r, []string{filepath.Join(root, "scratch.tmp")}); err == nil {
+		t.Fatalf("expected error adding an ignored file, got nil")
+	}
+}
+
+func TestCompute_ModeChange(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("executable bits need a POSIX filesystem")
+	}
+	r, tempDir := setupCommittedRepo(t)
+
+	if err := os.Chmod(filepath.Join(tempDir, "tracked.txt"), 0755); err != nil {
+		t.Fatalf("failed to chmod: %v", err)
+	}
+
+	s, err := status.Compute(r)
+	if err != nil {
+		t.Fatalf("Compute returned error: %v", err)
+	}
+	if len(s.Unstaged) != 1 || s.Unstaged[0] != (status.Change{Path: "tracked.txt", Kind: status.Modified}) {
+		t.Fatalf("expected tracked.txt to be modified, got %+v", s.Unstaged)
+	}
+}
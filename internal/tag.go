@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Tag gives a permanent name to another object, usually a commit,
+// along with who created it and a message.
+type Tag struct {
+	Object  string
+	ObjType string
+	Name    string
+	Tagger  Signature
+	Message string
+}
+
+func ParseTag(data []byte) (*Tag, error) {
+	headers, message, err := splitHeaders(data)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Tag{Message: message}
+	for _, line := range headers {
+		key, value, found := strings.Cut(line, " ")
+		if !found {
+			return nil, fmt.Errorf("invalid tag header %q", line)
+		}
+
+		switch key {
+		case "object":
+			t.Object = value
+		case "type":
+			t.ObjType = value
+		case "tag":
+			t.Name = value
+		case "tagger":
+			if t.Tagger, err = ParseSignature(value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if t.Object == "" || t.ObjType == "" {
+		return nil, errors.New("invalid tag: missing object or type")
+	}
+	return t, nil
+}
+
+func (t *Tag) Type() string {
+	return TagType
+}
+
+func (t *Tag) Serialize() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "object %s\n", t.Object)
+	fmt.Fprintf(&buf, "type %s\n", t.ObjType)
+	fmt.Fprintf(&buf, "tag %s\n", t.Name)
+	fmt.Fprintf(&buf, "tagger %s\n", t.Tagger)
+	buf.WriteString("\n")
+	buf.WriteString(t.Message)
+	return buf.Bytes()
+}
@@ -0,0 +1,189 @@
+// Package tag creates, lists and deletes tags. A lightweight tag is a ref
+// under refs/tags naming an object directly; an annotated tag names a tag
+// object that records the tagger and a message along with the object.
+package tag
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/commit"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/revision"
+)
+
+const tagsPrefix = repo.TagsDir + "/"
+
+// Tag describes a tag ref.
+type Tag struct {
+	Name string // short name, e.g. "v1.0"
+	Hash string // object the ref points to: a tag object when annotated
+
+	// Object is what the tag names after peeling tag objects, usually a
+	// commit, and ObjType its type.
+	Object, ObjType string
+
+	// Annotation is the tag object, nil for a lightweight tag.
+	Annotation *internal.Tag
+}
+
+// Ref returns the full ref name of a tag.
+func Ref(name string) string {
+	return tagsPrefix + name
+}
+
+// ValidateName checks that name can be used for a new tag.
+func ValidateName(name string) error {
+	if name == "" || strings.HasPrefix(name, "-") {
+		return fmt.Errorf("'%s' is not a valid tag name", name)
+	}
+	if err := refs.ValidateName(Ref(name)); err != nil {
+		return fmt.Errorf("'%s' is not a valid tag name", name)
+	}
+	return nil
+}
+
+// Exists reports whether the tag name exists.
+func Exists(r *repo.Repo, name string) (bool, error) {
+	_, err := refs.Resolve(r, Ref(name))
+	if errors.Is(err, refs.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Create makes tag name point at the object rev resolves to. When message
+// is not empty a tag object holding it is written, tagged by the
+// committer identity, and the tag points at that instead. An existing tag
+// is only replaced when force is set; the hash it pointed to is returned,
+// or an empty string when the tag is new.
+func Create(r *repo.Repo, name, rev, message string, force bool) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	hash, err := revision.Resolve(r, rev)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve '%s' as a valid ref", rev)
+	}
+
+	oldHash, err := refs.Resolve(r, Ref(name))
+	switch {
+	case errors.Is(err, refs.ErrNotFound):
+		oldHash = ""
+	case err != nil:
+		return "", err
+	case !force:
+		return "", fmt.Errorf("tag '%s' already exists", name)
+	}
+
+	if message != "" {
+		if hash, err = writeTagObject(r, name, hash, message); err != nil {
+			return "", err
+		}
+	}
+
+	expected := refs.ZeroHash
+	if oldHash != "" {
+		expected = oldHash
+	}
+	if err := refs.UpdateNoDeref(r, Ref(name), hash, expected); err != nil {
+		return "", err
+	}
+	return oldHash, nil
+}
+
+// writeTagObject stores a tag object naming hash and returns its hash.
+func writeTagObject(r *repo.Repo, name, hash, message string) (string, error) {
+	objType, _, err := r.ObjectInfo(hash)
+	if err != nil {
+		return "", err
+	}
+	tagger, err := commit.Signature(r, "committer", time.Now())
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasSuffix(message, "\n") {
+		message += "\n"
+	}
+
+	t := &internal.Tag{Object: hash, ObjType: objType, Name: name, Tagger: tagger, Message: message}
+	return r.WriteObject(t)
+}
+
+// Delete removes tag name and returns the hash it pointed to.
+func Delete(r *repo.Repo, name string) (string, error) {
+	hash, err := refs.Resolve(r, Ref(name))
+	if errors.Is(err, refs.ErrNotFound) {
+		return "", fmt.Errorf("tag '%s' not found", name)
+	}
+	if err != nil {
+		return "", err
+	}
+	return hash, refs.Delete(r, Ref(name), hash)
+}
+
+// List returns the tags sorted by name. When patterns are given, only tags
+// whose name matches one of them are returned; patterns use the syntax of
+// path.Match, so "v1.*" matches v1.0 but not v1.0/rc.
+func List(r *repo.Repo, patterns []string) ([]Tag, error) {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %v", p, err)
+		}
+	}
+	all, err := refs.List(r, tagsPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []Tag
+	for _, ref := range all {
+		name := strings.TrimPrefix(ref.Name, tagsPrefix)
+		if !matchAny(patterns, name) {
+			continue
+		}
+		t, err := Read(r, name, ref.Hash)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, nil
+}
+
+// Read describes tag name pointing at hash, peeling annotated tags.
+func Read(r *repo.Repo, name, hash string) (Tag, error) {
+	t := Tag{Name: name, Hash: hash, Object: hash}
+	for {
+		obj, err := r.ReadObject(t.Object)
+		if err != nil {
+			return Tag{}, err
+		}
+		annotation, ok := obj.(*internal.Tag)
+		if !ok {
+			t.ObjType = obj.Type()
+			return t, nil
+		}
+		if t.Annotation == nil {
+			t.Annotation = annotation
+		}
+		t.Object = annotation.Object
+	}
+}
+
+func matchAny(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
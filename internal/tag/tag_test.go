@@ -0,0 +1,115 @@
+package tag_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/refs"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/revision"
+	"github.com/MahendraDani/gitloom.git/internal/tag"
+	"github.com/MahendraDani/gitloom.git/internal/testrepo"
+)
+
+func setupRepo(t *testing.T) (*repo.Repo, string) {
+	t.Helper()
+	r := testrepo.New(t)
+	testrepo.WriteFile(t, r.WorkTree(), "f.txt", "one\n")
+	return r, testrepo.CommitAll(t, r, "first")
+}
+
+func TestCreate_Lightweight(t *testing.T) {
+	r, head := setupRepo(t)
+
+	if old, err := tag.Create(r, "v0.1", "HEAD", "", false); err != nil || old != "" {
+		t.Fatalf("Create() = %q, %v", old, err)
+	}
+	if hash, err := refs.Resolve(r, tag.Ref("v0.1")); err != nil || hash != head {
+		t.Fatalf("refs/tags/v0.1 = %s, %v, want %s", hash, err, head)
+	}
+	if _, err := tag.Create(r, "v0.1", "HEAD", "", false); err == nil {
+		t.Fatal("expected an existing tag to be refused without force")
+	}
+	for _, name := range []string{"", "-v", "a..b", "v1.0.lock"} {
+		if err := tag.ValidateName(name); err == nil {
+			t.Errorf("ValidateName(%q) succeeded", name)
+		}
+	}
+}
+
+func TestCreate_Annotated(t *testing.T) {
+	r, head := setupRepo(t)
+
+	if _, err := tag.Create(r, "v1.0", "HEAD", "Release 1.0", false); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	hash, err := refs.Resolve(r, tag.Ref("v1.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := r.ReadObject(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotation, ok := obj.(*internal.Tag)
+	if !ok {
+		t.Fatalf("refs/tags/v1.0 points at a %s, want a tag", obj.Type())
+	}
+	if annotation.Object != head || annotation.ObjType != internal.CommitType || annotation.Name != "v1.0" ||
+		annotation.Tagger.Name != "Jane Doe" || annotation.Message != "Release 1.0\n" {
+		t.Fatalf("unexpected tag object: %+v", annotation)
+	}
+
+	if peeled, err := revision.Resolve(r, "v1.0^{}"); err != nil || peeled != head {
+		t.Fatalf("v1.0^{} = %s, %v, want %s", peeled, err, head)
+	}
+
+	old, err := tag.Create(r, "v1.0", "HEAD", "", true)
+	if err != nil || old != hash {
+		t.Fatalf("forced Create() = %q, %v, want %s", old, err, hash)
+	}
+}
+
+func TestListAndDelete(t *testing.T) {
+	r, head := setupRepo(t)
+	for _, name := range []string{"v1.0", "v1.1", "v2.0", "rc/v2.1"} {
+		if _, err := tag.Create(r, name, "HEAD", "", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := tag.Create(r, "v1.2", "HEAD", "annotated", false); err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := tag.List(r, []string{"v1.*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tg := range tags {
+		names = append(names, tg.Name)
+		if tg.Object != head || tg.ObjType != internal.CommitType {
+			t.Errorf("%s names %s %s, want commit %s", tg.Name, tg.ObjType, tg.Object, head)
+		}
+		if (tg.Annotation != nil) != (tg.Name == "v1.2") {
+			t.Errorf("%s: unexpected annotation %+v", tg.Name, tg.Annotation)
+		}
+	}
+	if len(names) != 3 || names[0] != "v1.0" || names[1] != "v1.1" || names[2] != "v1.2" {
+		t.Fatalf("List(v1.*) = %v", names)
+	}
+	if all, err := tag.List(r, nil); err != nil || len(all) != 5 {
+		t.Fatalf("List() returned %d tags, %v", len(all), err)
+	}
+
+	if hash, err := tag.Delete(r, "v1.0"); err != nil || hash != head {
+		t.Fatalf("Delete() = %s, %v", hash, err)
+	}
+	if _, err := refs.Resolve(r, tag.Ref("v1.0")); !errors.Is(err, refs.ErrNotFound) {
+		t.Fatalf("expected v1.0 to be gone, got %v", err)
+	}
+	if _, err := tag.Delete(r, "v1.0"); err == nil {
+		t.Fatal("expected deleting a missing tag to fail")
+	}
+}
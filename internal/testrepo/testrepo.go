@@ -0,0 +1,75 @@
+// Package testrepo creates throwaway repositories for tests.
+package testrepo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MahendraDani/gitloom.git/internal/commit"
+	"github.com/MahendraDani/gitloom.git/internal/config"
+	"github.com/MahendraDani/gitloom.git/internal/index"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// Isolate replaces the user-wide configuration with an empty one and makes
+// commits and tags by Jane Doe for the rest of the test, so results do not
+// depend on the machine running the tests.
+func Isolate(t testing.TB) {
+	t.Helper()
+	t.Setenv(config.GlobalEnv, filepath.Join(t.TempDir(), "global"))
+	t.Setenv(commit.AuthorNameEnv, "Jane Doe")
+	t.Setenv(commit.AuthorEmailEnv, "jane@example.com")
+	t.Setenv(commit.CommitterNameEnv, "Jane Doe")
+	t.Setenv(commit.CommitterEmailEnv, "jane@example.com")
+}
+
+// New initializes a repository in a temporary directory of an isolated
+// test.
+func New(t testing.TB) *repo.Repo {
+	t.Helper()
+	Isolate(t)
+
+	r := repo.NewRepo(t.TempDir())
+	if err := r.Init(); err != nil {
+		t.Fatalf("failed to init repository: %v", err)
+	}
+	return r
+}
+
+// WriteFile writes content to the slash separated path name under dir,
+// creating missing directories.
+func WriteFile(t testing.TB, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), repo.DirPerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), repo.FilePerm); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// ReadFile returns the contents of the slash separated path name under dir.
+func ReadFile(t testing.TB, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// CommitAll stages the whole working tree of r and commits it, returning
+// the new commit's hash.
+func CommitAll(t testing.TB, r *repo.Repo, message string) string {
+	t.Helper()
+	if err := index.Add(r, []string{r.WorkTree()}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	res, err := commit.Commit(r, message)
+	if err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	return res.Hash
+}
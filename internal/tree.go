@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	ModeTree       = "40000"
+	ModeBlob       = "100644"
+	ModeExecutable = "100755"
+	ModeSymlink    = "120000" // a blob holding the link target
+	ModeGitlink    = "160000" // a commit in another repository, as used by submodules
+)
+
+// TreeEntry is a single "<mode> <name>\x00<20 byte hash>" record of a tree.
+type TreeEntry struct {
+	Mode string
+	Name string
+	Hash string
+}
+
+// Tree lists the blobs and subtrees of a directory. Entries are
+// serialized in the order they appear; callers are responsible for sorting.
+type Tree struct {
+	Entries []TreeEntry
+}
+
+// ObjectType returns the type of object the entry points to, derived from its mode.
+func (e TreeEntry) ObjectType() string {
+	switch e.Mode {
+	case ModeTree:
+		return TreeType
+	case ModeGitlink:
+		return CommitType
+	default:
+		return BlobType
+	}
+}
+
+// PaddedMode returns the mode zero-padded to six digits, as git prints it.
+func (e TreeEntry) PaddedMode() string {
+	return fmt.Sprintf("%06s", e.Mode)
+}
+
+// SortTreeEntries orders entries the way git does: by name, comparing a
+// subtree's name as if it ended in "/", so "a.txt" sorts before the
+// directory "a" while the file "a" sorts before "a.txt".
+func SortTreeEntries(entries []TreeEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].sortKey() < entries[j].sortKey()
+	})
+}
+
+func (e TreeEntry) sortKey() string {
+	if e.Mode == ModeTree {
+		return e.Name + "/"
+	}
+	return e.Name
+}
+
+// NewTree returns a tree of entries, checking that each one can be
+// serialized.
+func NewTree(entries []TreeEntry) (*Tree, error) {
+	t := &Tree{Entries: entries}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Validate checks that every entry can be serialized: its hash must be a
+// full object name and its name must be non-empty without a slash or NUL
+// byte.
+func (t *Tree) Validate() error {
+	for _, e := range t.Entries {
+		if !IsHash(e.Hash) {
+			return fmt.Errorf("invalid hash %q for tree entry %q", e.Hash, e.Name)
+		}
+		if e.Name == "" || strings.ContainsAny(e.Name, "/\x00") {
+			return fmt.Errorf("invalid tree entry name %q", e.Name)
+		}
+	}
+	return nil
+}
+
+func ParseTree(data []byte) (*Tree, error) {
+	t := &Tree{}
+	i := 0
+	for i < len(data) {
+		// Parse mode
+		j := bytes.IndexByte(data[i:], ' ')
+		if j < 0 {
+			return nil, errors.New("invalid tree entry: missing space after mode")
+		}
+		mode := string(data[i : i+j])
+		i += j + 1
+
+		// Parse filename
+		k := bytes.IndexByte(data[i:], 0)
+		if k < 0 {
+			return nil, errors.New("invalid tree entry: missing null terminator after filename")
+		}
+		name := string(data[i : i+k])
+		i += k + 1
+
+		// Parse hash (20 bytes)
+		if i+20 > len(data) {
+			return nil, errors.New("invalid tree entry: incomplete hash")
+		}
+		hash := hex.EncodeToString(data[i : i+20])
+		i += 20
+
+		t.Entries = append(t.Entries, TreeEntry{Mode: mode, Name: name, Hash: hash})
+	}
+	return t, nil
+}
+
+func (t *Tree) Type() string {
+	return TreeType
+}
+
+// Serialize encodes the entries, which must pass Validate.
+func (t *Tree) Serialize() []byte {
+	var buf bytes.Buffer
+	for _, e := range t.Entries {
+		var hash [20]byte
+		decoded, _ := hex.DecodeString(e.Hash)
+		copy(hash[:], decoded)
+		buf.WriteString(e.Mode + " " + e.Name + "\x00")
+		buf.Write(hash[:])
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,46 @@
+package tree
+
+import (
+	"fmt"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// Flatten reads the tree treeHash and all of its subtrees, returning every
+// blob entry keyed by its slash separated path from the root of the tree.
+// An empty treeHash, e.g. for a branch without commits, has no files.
+func Flatten(r *repo.Repo, treeHash string) (map[string]internal.TreeEntry, error) {
+	files := make(map[string]internal.TreeEntry)
+	if treeHash == "" {
+		return files, nil
+	}
+	if err := flatten(r, treeHash, "", files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func flatten(r *repo.Repo, treeHash, prefix string, files map[string]internal.TreeEntry) error {
+	obj, err := r.ReadObject(treeHash)
+	if err != nil {
+		return err
+	}
+	t, ok := obj.(*internal.Tree)
+	if !ok {
+		return fmt.Errorf("object %s is a %s, not a tree", treeHash, obj.Type())
+	}
+
+	for _, e := range t.Entries {
+		path := prefix + e.Name
+		if e.Mode == internal.ModeTree {
+			if err := flatten(r, e.Hash, path+"/", files); err != nil {
+				return err
+			}
+			continue
+		}
+		e.Name = path
+		files[path] = e
+	}
+	return nil
+}
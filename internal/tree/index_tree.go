@@ -0,0 +1,62 @@
+package tree
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/index"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// WriteIndexTree creates tree objects for the staged entries of idx and
+// returns the hash of the root tree. An index with unresolved merge
+// conflicts cannot be written.
+func WriteIndexTree(r *repo.Repo, idx *index.Index) (string, error) {
+	if unmerged := idx.Unmerged(); len(unmerged) > 0 {
+		return "", fmt.Errorf("%s: unmerged (resolve the conflict and add it first)", unmerged[0])
+	}
+	return writeIndexTree(r, idx.Entries, "")
+}
+
+// writeIndexTree writes the tree for the directory prefix. entries are the
+// index entries below prefix, sorted by path.
+func writeIndexTree(r *repo.Repo, entries []index.Entry, prefix string) (string, error) {
+	var treeEntries []internal.TreeEntry
+
+	for i := 0; i < len(entries); {
+		rest := strings.TrimPrefix(entries[i].Path, prefix)
+		name, _, isDir := strings.Cut(rest, "/")
+
+		if !isDir {
+			treeEntries = append(treeEntries, internal.TreeEntry{
+				Mode: fmt.Sprintf("%o", entries[i].Mode),
+				Name: name,
+				Hash: entries[i].Hash,
+			})
+			i++
+			continue
+		}
+
+		// Collect every entry inside this subdirectory
+		subPrefix := prefix + name + "/"
+		j := i
+		for j < len(entries) && strings.HasPrefix(entries[j].Path, subPrefix) {
+			j++
+		}
+
+		subTreeHash, err := writeIndexTree(r, entries[i:j], subPrefix)
+		if err != nil {
+			return "", err
+		}
+		treeEntries = append(treeEntries, internal.TreeEntry{Mode: internal.ModeTree, Name: name, Hash: subTreeHash})
+		i = j
+	}
+
+	internal.SortTreeEntries(treeEntries)
+	t, err := internal.NewTree(treeEntries)
+	if err != nil {
+		return "", err
+	}
+	return r.WriteObject(t)
+}
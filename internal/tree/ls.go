@@ -0,0 +1,83 @@
+package tree
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// LsOptions mirrors the flags of ls-tree.
+type LsOptions struct {
+	Recursive bool // descend into subtrees
+	DirsOnly  bool // show only tree entries
+	ShowTrees bool // show tree entries themselves when recursing
+	NameOnly  bool // print only paths
+	Long      bool // include blob sizes
+}
+
+// LsTree writes the entries of the tree treeHash to w, one per line as
+// "<mode> <type> <hash>\t<path>", with paths relative to the tree.
+func LsTree(r *repo.Repo, treeHash string, opts LsOptions, w io.Writer) error {
+	return lsTree(r, treeHash, "", opts, w)
+}
+
+func lsTree(r *repo.Repo, treeHash, prefix string, opts LsOptions, w io.Writer) error {
+	obj, err := r.ReadObject(treeHash)
+	if err != nil {
+		return err
+	}
+	t, ok := obj.(*internal.Tree)
+	if !ok {
+		return fmt.Errorf("object %s is a %s, not a tree", treeHash, obj.Type())
+	}
+
+	for _, e := range t.Entries {
+		path := prefix + e.Name
+		isTree := e.Mode == internal.ModeTree
+
+		// A recursive listing shows the contents of a subtree in its place,
+		// unless trees were asked for explicitly
+		recurse := isTree && opts.Recursive
+		show := !recurse || opts.ShowTrees || opts.DirsOnly
+		if opts.DirsOnly && !isTree {
+			show = false
+		}
+
+		if show {
+			if err := writeLsEntry(r, e, path, opts, w); err != nil {
+				return err
+			}
+		}
+		if recurse {
+			if err := lsTree(r, e.Hash, path+"/", opts, w); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeLsEntry(r *repo.Repo, e internal.TreeEntry, path string, opts LsOptions, w io.Writer) error {
+	if opts.NameOnly {
+		_, err := fmt.Fprintln(w, path)
+		return err
+	}
+
+	if !opts.Long {
+		_, err := fmt.Fprintf(w, "%s %s %s\t%s\n", e.PaddedMode(), e.ObjectType(), e.Hash, path)
+		return err
+	}
+
+	size := "-"
+	if e.ObjectType() == internal.BlobType {
+		_, n, err := r.ObjectInfo(e.Hash)
+		if err != nil {
+			return err
+		}
+		size = fmt.Sprintf("%d", n)
+	}
+	_, err := fmt.Fprintf(w, "%s %s %s %7s\t%s\n", e.PaddedMode(), e.ObjectType(), e.Hash, size, path)
+	return err
+}
@@ -1,80 +1,90 @@
 package tree
 
 import (
-	"bytes"
-	"encoding/hex"
-	"os"
 	"path/filepath"
-	"sort"
+	"strings"
 
-	"github.com/MahendraDani/gitloom.git/internal/object"
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/ignore"
 	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/worktree"
 )
 
 // WriteTree creates a tree object representing the current state
 // of the working directory, recursively including subdirectories.
+// Paths matched by .gitloomignore rules are left out.
 func WriteTree(dir string, r *repo.Repo) (string, error) {
-	entries, err := os.ReadDir(dir)
+	m, err := ignore.New(r)
 	if err != nil {
 		return "", err
 	}
 
-	var treeBuf bytes.Buffer
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
 
-	// Sort entries to ensure deterministic order
-	sort.Slice(entries, func(i, j int) bool {
-		return entries[i].Name() < entries[j].Name()
-	})
+	// Ignore rules are relative to the working tree; a directory outside
+	// of it is snapshotted as is.
+	root := r.WorkTree()
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		root, rel, m = abs, "", nil
+	}
+	if rel == "." {
+		rel = ""
+	}
 
-	for _, entry := range entries {
-		name := entry.Name()
+	return writeTree(r, m, root, filepath.ToSlash(rel))
+}
 
-		// Ignore the .gitloom directory
-		if name == repo.RepoDirName {
-			continue
-		}
+func writeTree(r *repo.Repo, m *ignore.Matcher, root, rel string) (string, error) {
+	entries, err := worktree.ReadDir(root, rel, m)
+	if err != nil {
+		return "", err
+	}
 
-		fullPath := filepath.Join(dir, name)
+	var treeEntries []internal.TreeEntry
+
+	for _, entry := range entries {
+		name := entry.Name()
+		childRel := worktree.Join(rel, name)
 
 		if entry.IsDir() {
 			// Recursively write subdirectory tree
-			subTreeHash, err := WriteTree(fullPath, r)
-			if err != nil {
-				return "", err
-			}
-
-			hashBytes, err := hex.DecodeString(subTreeHash)
+			subTreeHash, err := writeTree(r, m, root, childRel)
 			if err != nil {
 				return "", err
 			}
 
 			// Mode 40000 for directories
-			treeBuf.WriteString("40000 " + name + "\x00")
-			treeBuf.Write(hashBytes)
-
-		} else if entry.Type().IsRegular() {
-			// Create blob object for the file
-			blobHash, err := object.HashObject(fullPath, r, true)
-			if err != nil {
-				return "", err
-			}
+			treeEntries = append(treeEntries, internal.TreeEntry{Mode: internal.ModeTree, Name: name, Hash: subTreeHash})
+			continue
+		}
 
-			hashBytes, err := hex.DecodeString(blobHash)
-			if err != nil {
-				return "", err
-			}
+		// Regular files, executables and symlinks; anything else is skipped
+		fi, err := entry.Info()
+		if err != nil {
+			return "", err
+		}
+		mode, ok := worktree.Mode(fi)
+		if !ok {
+			continue
+		}
 
-			// Mode 100644 for normal files
-			treeBuf.WriteString("100644 " + name + "\x00")
-			treeBuf.Write(hashBytes)
+		fullPath := filepath.Join(root, filepath.FromSlash(childRel))
+		blobHash, err := worktree.HashFile(r, fullPath, fi, true)
+		if err != nil {
+			return "", err
 		}
+		treeEntries = append(treeEntries, internal.TreeEntry{Mode: mode, Name: name, Hash: blobHash})
 	}
 
 	// Write the tree object to the .gitloom/objects directory
-	treeHash, err := object.HashRawObject(treeBuf.Bytes(), "tree", r, true)
+	internal.SortTreeEntries(treeEntries)
+	t, err := internal.NewTree(treeEntries)
 	if err != nil {
 		return "", err
 	}
-
-	return treeHash, nil
+	return r.WriteObject(t)
 }
@@ -3,22 +3,21 @@ package tree_test
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 
+	"github.com/MahendraDani/gitloom.git/internal/index"
 	"github.com/MahendraDani/gitloom.git/internal/object"
 	"github.com/MahendraDani/gitloom.git/internal/repo"
+	"github.com/MahendraDani/gitloom.git/internal/testrepo"
 	"github.com/MahendraDani/gitloom.git/internal/tree"
 )
 
 func TestWriteTree_SingleFile(t *testing.T) {
 	// Create a temporary directory for the repo
-	tempDir := t.TempDir()
-
-	r := repo.NewRepo(tempDir)
-	if err := r.Init(); err != nil {
-		t.Fatalf("failed to init repository: %v", err)
-	}
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
 
 	// Create a test file in the root
 	filePath := filepath.Join(tempDir, "hello.txt")
@@ -51,12 +50,8 @@ func TestWriteTree_SingleFile(t *testing.T) {
 }
 
 func TestWriteTree_IgnoresGitloomDir(t *testing.T) {
-	tempDir := t.TempDir()
-
-	r := repo.NewRepo(tempDir)
-	if err := r.Init(); err != nil {
-		t.Fatalf("failed to init repository: %v", err)
-	}
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
 
 	filePath := filepath.Join(tempDir, "hello.txt")
 	content := []byte("hello world\n")
@@ -65,7 +60,7 @@ func TestWriteTree_IgnoresGitloomDir(t *testing.T) {
 	}
 
 	filePathWithinGitloom := filepath.Join(tempDir, repo.RepoDirName, "config")
-	fileContent := []byte("config file\n")
+	fileContent := []byte("[user]\n\tname = config file\n")
 	if err := os.WriteFile(filePathWithinGitloom, fileContent, repo.FilePerm); err != nil {
 		t.Fatalf("failed to write test file: %v", err)
 	}
@@ -95,12 +90,8 @@ func TestWriteTree_IgnoresGitloomDir(t *testing.T) {
 }
 
 func TestWriteTree_MultipleFiles(t *testing.T) {
-	tempDir := t.TempDir()
-
-	r := repo.NewRepo(tempDir)
-	if err := r.Init(); err != nil {
-		t.Fatalf("failed to init repository: %v", err)
-	}
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
 
 	files := map[string]string{
 		"a.txt": "alpha\n",
@@ -145,12 +136,8 @@ func TestWriteTree_MultipleFiles(t *testing.T) {
 }
 
 func TestWriteTree_EmptyDirectory(t *testing.T) {
-	tempDir := t.TempDir()
-
-	r := repo.NewRepo(tempDir)
-	if err := r.Init(); err != nil {
-		t.Fatalf("failed to init repository: %v", err)
-	}
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
 
 	entries, err := os.ReadDir(tempDir)
 	if err != nil {
@@ -190,12 +177,8 @@ func TestWriteTree_EmptyDirectory(t *testing.T) {
 }
 
 func TestWriteTree_WithSubdirectories(t *testing.T) {
-	tempDir := t.TempDir()
-
-	r := repo.NewRepo(tempDir)
-	if err := r.Init(); err != nil {
-		t.Fatalf("failed to init repository: %v", err)
-	}
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
 	// Create files
 	rootFile := filepath.Join(tempDir, "file1.txt")
 	if err := os.WriteFile(rootFile, []byte("root content\n"), repo.FilePerm); err != nil {
@@ -286,3 +269,267 @@ func TestWriteTree_WithSubdirectories(t *testing.T) {
 		t.Fatalf("expected subdir tree to contain file2.txt and file3.txt, got:\n%s", subTree)
 	}
 }
+
+func TestWriteIndexTree_MatchesWorkingTree(t *testing.T) {
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
+
+	files := map[string]string{
+		"a.txt":            "alpha\n",
+		"subdir/b.txt":     "bravo\n",
+		"subdir/deep/c.md": "charlie\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), repo.DirPerm); err != nil {
+			t.Fatalf("failed to create dir for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), repo.FilePerm); err != nil {
+			t.Fatalf("failed to write file %s: %v", name, err)
+		}
+	}
+
+	if err := index.Add(r, []string{tempDir}); err != nil {
+		t.Fatalf("index.Add returned error: %v", err)
+	}
+	idx, err := index.Read(r)
+	if err != nil {
+		t.Fatalf("index.Read returned error: %v", err)
+	}
+
+	indexTreeHash, err := tree.WriteIndexTree(r, idx)
+	if err != nil {
+		t.Fatalf("WriteIndexTree returned error: %v", err)
+	}
+
+	dirTreeHash, err := tree.WriteTree(tempDir, r)
+	if err != nil {
+		t.Fatalf("WriteTree returned error: %v", err)
+	}
+
+	if indexTreeHash != dirTreeHash {
+		t.Fatalf("index tree %s does not match working tree %s", indexTreeHash, dirTreeHash)
+	}
+}
+
+func TestWriteTree_GitEntryOrder(t *testing.T) {
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
+	if err := os.MkdirAll(filepath.Join(tempDir, "foo"), repo.DirPerm); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"foo.txt": "one\n", "foo/bar.txt": "two\n"} {
+		if err := os.WriteFile(filepath.Join(tempDir, filepath.FromSlash(name)), []byte(content), repo.FilePerm); err != nil {
+			t.Fatalf("failed to write file %s: %v", name, err)
+		}
+	}
+
+	// git sorts the directory "foo" as "foo/", after "foo.txt"; this is
+	// the hash git write-tree gives the same files
+	const want = "03ac851a69549797327721f3c511b3282be7bb61"
+
+	dirTreeHash, err := tree.WriteTree(tempDir, r)
+	if err != nil {
+		t.Fatalf("WriteTree returned error: %v", err)
+	}
+	if dirTreeHash != want {
+		t.Errorf("WriteTree = %s, want %s", dirTreeHash, want)
+	}
+
+	if err := index.Add(r, []string{tempDir}); err != nil {
+		t.Fatalf("index.Add returned error: %v", err)
+	}
+	idx, err := index.Read(r)
+	if err != nil {
+		t.Fatalf("index.Read returned error: %v", err)
+	}
+	indexTreeHash, err := tree.WriteIndexTree(r, idx)
+	if err != nil {
+		t.Fatalf("WriteIndexTree returned error: %v", err)
+	}
+	if indexTreeHash != want {
+		t.Errorf("WriteIndexTree = %s, want %s", indexTreeHash, want)
+	}
+}
+
+func TestWriteIndexTree_OnlyStagedFiles(t *testing.T) {
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
+
+	for _, name := range []string{"staged.txt", "unstaged.txt"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(name), repo.FilePerm); err != nil {
+			t.Fatalf("failed to write file %s: %v", name, err)
+		}
+	}
+
+	if err := index.Add(r, []string{filepath.Join(tempDir, "staged.txt")}); err != nil {
+		t.Fatalf("index.Add returned error: %v", err)
+	}
+	idx, err := index.Read(r)
+	if err != nil {
+		t.Fatalf("index.Read returned error: %v", err)
+	}
+
+	treeHash, err := tree.WriteIndexTree(r, idx)
+	if err != nil {
+		t.Fatalf("WriteIndexTree returned error: %v", err)
+	}
+
+	output, err := object.CatFile(r, treeHash, "p")
+	if err != nil {
+		t.Fatalf("CatFile -p returned error: %v", err)
+	}
+	if !strings.Contains(output, "staged.txt") || strings.Contains(output, "unstaged.txt") {
+		t.Fatalf("expected tree to contain only staged.txt, got:\n%s", output)
+	}
+}
+
+func TestWriteTree_HonorsIgnoreFile(t *testing.T) {
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
+
+	files := map[string]string{
+		".gitloomignore":    "*.log\nnode_modules/\n",
+		"main.go":           "package main\n",
+		"debug.log":         "noise\n",
+		"node_modules/x.js": "x\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), repo.DirPerm); err != nil {
+			t.Fatalf("failed to create dir for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), repo.FilePerm); err != nil {
+			t.Fatalf("failed to write file %s: %v", name, err)
+		}
+	}
+
+	treeHash, err := tree.WriteTree(tempDir, r)
+	if err != nil {
+		t.Fatalf("WriteTree returned error: %v", err)
+	}
+
+	output, err := object.CatFile(r, treeHash, "p")
+	if err != nil {
+		t.Fatalf("CatFile -p returned error: %v", err)
+	}
+	if !strings.Contains(output, "main.go") || !strings.Contains(output, ".gitloomignore") {
+		t.Errorf("expected tree to contain main.go and .gitloomignore, got:\n%s", output)
+	}
+	if strings.Contains(output, "debug.log") || strings.Contains(output, "node_modules") {
+		t.Errorf("expected ignored paths to be left out, got:\n%s", output)
+	}
+}
+
+// writeNestedTree writes a.txt, src/b.txt and src/lib/c.txt and returns
+// the repository and root tree hash.
+func writeNestedTree(t *testing.T) (*repo.Repo, string) {
+	t.Helper()
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
+	if err := os.MkdirAll(filepath.Join(tempDir, "src", "lib"), repo.DirPerm); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"a.txt":         "alpha\n",
+		"src/b.txt":     "bravo\n",
+		"src/lib/c.txt": "charlie\n",
+	} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), repo.FilePerm); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	treeHash, err := tree.WriteTree(tempDir, r)
+	if err != nil {
+		t.Fatalf("WriteTree returned error: %v", err)
+	}
+	return r, treeHash
+}
+
+func TestCatFile_TreeEntryTypes(t *testing.T) {
+	r, treeHash := writeNestedTree(t)
+
+	output, err := object.CatFile(r, treeHash, "p")
+	if err != nil {
+		t.Fatalf("CatFile -p returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 entries, got:\n%s", output)
+	}
+	if !strings.HasPrefix(lines[0], "100644 blob ") || !strings.HasSuffix(lines[0], "\ta.txt") {
+		t.Errorf("unexpected blob entry %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "040000 tree ") || !strings.HasSuffix(lines[1], "\tsrc") {
+		t.Errorf("unexpected tree entry %q", lines[1])
+	}
+}
+
+func TestLsTree(t *testing.T) {
+	r, treeHash := writeNestedTree(t)
+
+	tests := []struct {
+		name string
+		opts tree.LsOptions
+		want []string
+	}{
+		{"default", tree.LsOptions{NameOnly: true}, []string{"a.txt", "src"}},
+		{"recursive", tree.LsOptions{Recursive: true, NameOnly: true}, []string{"a.txt", "src/b.txt", "src/lib/c.txt"}},
+		{"recursive with trees", tree.LsOptions{Recursive: true, ShowTrees: true, NameOnly: true},
+			[]string{"a.txt", "src", "src/b.txt", "src/lib", "src/lib/c.txt"}},
+		{"dirs only", tree.LsOptions{DirsOnly: true, NameOnly: true}, []string{"src"}},
+		{"recursive dirs only", tree.LsOptions{Recursive: true, DirsOnly: true, NameOnly: true}, []string{"src", "src/lib"}},
+	}
+	for _, tt := range tests {
+		var buf strings.Builder
+		if err := tree.LsTree(r, treeHash, tt.opts, &buf); err != nil {
+			t.Fatalf("%s: LsTree returned error: %v", tt.name, err)
+		}
+		if got := strings.Fields(buf.String()); strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	var buf strings.Builder
+	if err := tree.LsTree(r, treeHash, tree.LsOptions{Long: true}, &buf); err != nil {
+		t.Fatalf("LsTree -l returned error: %v", err)
+	}
+	// Blobs show their size right-aligned, trees a dash
+	out := buf.String()
+	if !strings.HasPrefix(out, "100644 blob ") || !strings.Contains(out, "      6\ta.txt\n") ||
+		!strings.Contains(out, "      -\tsrc\n") {
+		t.Errorf("unexpected long listing:\n%s", out)
+	}
+}
+
+func TestWriteTree_ExecutableAndSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("executable bits and symlinks need a POSIX filesystem")
+	}
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
+	if err := os.WriteFile(filepath.Join(tempDir, "run.sh"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+	if err := os.Symlink("run.sh", filepath.Join(tempDir, "link")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	treeHash, err := tree.WriteTree(tempDir, r)
+	if err != nil {
+		t.Fatalf("WriteTree returned error: %v", err)
+	}
+	output, err := object.CatFile(r, treeHash, "p")
+	if err != nil {
+		t.Fatalf("CatFile -p returned error: %v", err)
+	}
+
+	if !strings.Contains(output, "120000 blob ") || !strings.Contains(output, "\tlink\n") {
+		t.Errorf("expected a symlink entry, got:\n%s", output)
+	}
+	if !strings.Contains(output, "100755 blob ") || !strings.Contains(output, "\trun.sh\n") {
+		t.Errorf("expected an executable entry, got:\n%s", output)
+	}
+}
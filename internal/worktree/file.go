@@ -0,0 +1,76 @@
+package worktree
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/object"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// Mode returns the tree entry mode for a file in the working tree, which
+// fi must describe without following symlinks. It reports false for
+// directories and for files that cannot be stored, such as sockets.
+func Mode(fi os.FileInfo) (string, bool) {
+	switch {
+	case fi.Mode()&os.ModeSymlink != 0:
+		return internal.ModeSymlink, true
+	case !fi.Mode().IsRegular():
+		return "", false
+	case fi.Mode().Perm()&0100 != 0:
+		// Like git, only the owner's executable bit is recorded
+		return internal.ModeExecutable, true
+	default:
+		return internal.ModeBlob, true
+	}
+}
+
+// HashFile returns the blob hash of the file at path and stores the blob
+// if write is set. A symlink is hashed as a blob holding its target.
+func HashFile(r *repo.Repo, path string, fi os.FileInfo, write bool) (string, error) {
+	if fi.Mode()&os.ModeSymlink == 0 {
+		return object.HashObject(path, r, write)
+	}
+
+	target, err := os.Readlink(path)
+	if err != nil {
+		return "", err
+	}
+	return object.HashRawObject([]byte(filepath.ToSlash(target)), internal.BlobType, r, write)
+}
+
+// WriteFile restores the blob hash at path with the given tree entry
+// mode, creating parent directories and replacing whatever is there.
+// Symlinks are recreated pointing at the target stored in the blob, and
+// executable files get the executable bits the umask allows.
+func WriteFile(r *repo.Repo, path, mode, hash string) error {
+	if err := os.MkdirAll(filepath.Dir(path), repo.DirPerm); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if mode == internal.ModeSymlink {
+		_, target, err := r.ReadRawObject(hash)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(filepath.FromSlash(string(target)), path)
+	}
+
+	perm := os.FileMode(0666)
+	if mode == internal.ModeExecutable {
+		perm = 0777
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if err := object.WriteBlob(r, hash, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,82 @@
+package worktree
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/MahendraDani/gitloom.git/internal/ignore"
+	"github.com/MahendraDani/gitloom.git/internal/repo"
+)
+
+// WalkFunc is called by Walk for every file and directory in the working
+// tree. rel is slash separated and relative to the working tree root.
+// Returning fs.SkipDir for a directory skips its contents.
+type WalkFunc func(rel string, d fs.DirEntry) error
+
+// ReadDir returns the entries of the directory rel below root that belong
+// to the working tree, sorted by name. The .gitloom directory and paths
+// ignored by m are never included.
+func ReadDir(root, rel string, m *ignore.Matcher) ([]fs.DirEntry, error) {
+	entries, err := os.ReadDir(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil, err
+	}
+
+	kept := entries[:0]
+	for _, entry := range entries {
+		// Ignore the repository directory itself
+		if entry.Name() == repo.RepoDirName || entry.Name() == repo.GitDirName {
+			continue
+		}
+
+		ignored, _, err := m.Ignored(Join(rel, entry.Name()), entry.IsDir())
+		if err != nil {
+			return nil, err
+		}
+		if ignored {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept, nil
+}
+
+// Walk visits the working tree below the directory rel in lexical order,
+// skipping anything ReadDir leaves out.
+func Walk(root, rel string, m *ignore.Matcher, fn WalkFunc) error {
+	entries, err := ReadDir(root, rel, m)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		childRel := Join(rel, entry.Name())
+
+		err := fn(childRel, entry)
+		if entry.IsDir() {
+			if err == fs.SkipDir {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := Walk(root, childRel, m, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Join appends name to the slash separated directory dir.
+func Join(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
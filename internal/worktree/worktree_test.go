@@ -0,0 +1,63 @@
+package worktree_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/MahendraDani/gitloom.git/internal"
+	"github.com/MahendraDani/gitloom.git/internal/testrepo"
+	"github.com/MahendraDani/gitloom.git/internal/worktree"
+)
+
+func TestWriteFileRestoresModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("executable bits and symlinks need a POSIX filesystem")
+	}
+	r := testrepo.New(t)
+	tempDir := r.WorkTree()
+
+	script, err := r.WriteRawObject(internal.BlobType, []byte("#!/bin/sh\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := r.WriteRawObject(internal.BlobType, []byte("../bin/run.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scriptPath := filepath.Join(tempDir, "bin", "run.sh")
+	linkPath := filepath.Join(tempDir, "docs", "run")
+	if err := worktree.WriteFile(r, scriptPath, internal.ModeExecutable, script); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	if err := worktree.WriteFile(r, linkPath, internal.ModeSymlink, target); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+
+	for path, want := range map[string]string{scriptPath: internal.ModeExecutable, linkPath: internal.ModeSymlink} {
+		fi, err := os.Lstat(path)
+		if err != nil {
+			t.Fatalf("failed to stat %s: %v", path, err)
+		}
+		if mode, _ := worktree.Mode(fi); mode != want {
+			t.Errorf("%s has mode %s, want %s", path, mode, want)
+		}
+	}
+
+	// Hashing the restored files gives back the blobs they came from
+	fi, _ := os.Lstat(linkPath)
+	if hash, err := worktree.HashFile(r, linkPath, fi, false); err != nil || hash != target {
+		t.Errorf("symlink hashes to %s (%v), want %s", hash, err, target)
+	}
+
+	// Restoring over an existing file replaces it with a regular one
+	if err := worktree.WriteFile(r, scriptPath, internal.ModeBlob, script); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	fi, _ = os.Lstat(scriptPath)
+	if mode, _ := worktree.Mode(fi); mode != internal.ModeBlob {
+		t.Errorf("expected a non-executable file, got mode %s", mode)
+	}
+}